@@ -0,0 +1,269 @@
+// Command replay feeds recorded events (Kafka quarantine files or broadcast recordings)
+// through the same decode + handler pipeline as the server, to reproduce incidents locally.
+//
+//	go run ./cmd/replay -file logs/recordings/recording-....jsonl -speed 10 -dry-run \
+//	    -clients "owner-1:tablet:section-1=reservations.created,reservations.updated;admin:web=*"
+//
+// Without -target the messages are broadcast to an in-process hub populated with the
+// simulated -clients; with -target they are POSTed to the /v2/broadcast endpoint of a running
+// server. -dry-run only prints the topic and the clients that would receive each message.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"mesaYaWs/internal/config"
+	handler "mesaYaWs/internal/modules/realtime/application/handler"
+	"mesaYaWs/internal/modules/realtime/application/port"
+	usecase "mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	transport "mesaYaWs/internal/modules/realtime/interface"
+	"mesaYaWs/internal/platform/broker"
+	"mesaYaWs/internal/shared/auth"
+)
+
+func main() {
+	file := flag.String("file", "", "JSONL file with recorded events (required)")
+	speed := flag.Float64("speed", 1, "replay speed: 1 = original timing, 10 = ten times faster, 0 = no pauses")
+	topics := flag.String("topics", "", "comma separated topic patterns to replay (Kafka or message topics, glob syntax)")
+	target := flag.String("target", "", "base URL of a running server (e.g. http://localhost:8080); empty uses an in-process hub")
+	apiKey := flag.String("api-key", "", "API key sent to -target /v2/broadcast (X-API-Key)")
+	clients := flag.String("clients", "", "simulated in-process clients: user:session[:section]=topic1,topic2 (or =* for all), separated by ';'")
+	dryRun := flag.Bool("dry-run", false, "print which topics and clients would receive each message without broadcasting")
+	verbose := flag.Bool("v", false, "log pipeline details to stderr")
+	flag.Parse()
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if strings.TrimSpace(*file) == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := godotenv.Overload(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, ".env load warning: %v\n", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config load error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hub := infrastructure.NewHub()
+	if err := attachSimulatedClients(hub, *clients); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -clients: %v\n", err)
+		os.Exit(2)
+	}
+
+	var broadcaster port.Broadcaster
+	switch {
+	case *dryRun:
+		broadcaster = &dryRunBroadcaster{hub: hub, out: os.Stdout}
+	case strings.TrimSpace(*target) != "":
+		broadcaster = newHTTPBroadcaster(*target, *apiKey, cfg.REST.Timeout)
+	default:
+		hub.SetRecorder(&printRecorder{out: os.Stdout})
+		broadcaster = hub
+	}
+	broadcastUC := usecase.NewBroadcastUseCase(broadcaster)
+
+	// Snapshot and analytics refreshes hit the REST API; they only run for in-process replays.
+	var connectUC *usecase.ConnectSectionUseCase
+	var analyticsUC *usecase.AnalyticsUseCase
+	if !*dryRun && strings.TrimSpace(*target) == "" {
+		validator := auth.NewJWTValidatorWithPublicKey(cfg.Security.JWTSecret, cfg.Security.JWTPublicKey)
+		connectUC = usecase.NewConnectSectionUseCase(validator, infrastructure.NewSectionSnapshotHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil, cfg.Entities.Registry))
+		analyticsUC = usecase.NewAnalyticsUseCase(validator, infrastructure.NewAnalyticsHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil), cfg.Entities.Registry)
+		if err := analyticsUC.SetRegistry(usecase.DefaultAnalyticsRegistry(cfg.Entities.Registry)); err != nil {
+			fmt.Fprintf(os.Stderr, "analytics config error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	registry := infrastructure.NewHandlerRegistry()
+	registry.Use(infrastructure.RecoveryMiddleware(), infrastructure.LoggingMiddleware())
+	registry.Register(&handler.UserCreatedHandler{UseCase: broadcastUC})
+	for entity, topicList := range cfg.Kafka.Topics {
+		for _, topic := range topicList {
+			registry.Register(handler.NewEntityStreamHandler(entity, topic, cfg.Websocket.AllowedActions, broadcastUC, connectUC, analyticsUC))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	input, err := os.Open(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open replay file: %v\n", err)
+		os.Exit(1)
+	}
+	defer input.Close()
+
+	replayed, err := replayEvents(ctx, input, broker.ReplayOptions{Speed: *speed, Topics: splitList(*topics, ",")}, registry)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "replay failed after %d events: %v\n", replayed, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d events\n", replayed)
+}
+
+// replayEvents dispatches the events of input matching opts through registry, as the server
+// consumer would: raw Kafka records keep their partition and offset. It returns how many
+// events were replayed.
+func replayEvents(ctx context.Context, input io.Reader, opts broker.ReplayOptions, registry *infrastructure.HandlerRegistry) (int, error) {
+	replayed := 0
+	err := broker.ReadRecordedEvents(ctx, input, opts, func(event broker.RecordedEvent, msg *domain.Message) error {
+		replayed++
+		msgCtx := port.WithSourceTopic(ctx, event.Topic)
+		if len(event.Value) > 0 {
+			msgCtx = port.WithSourcePosition(msgCtx, port.SourcePosition{Partition: event.Partition, Offset: event.Offset})
+		}
+		if err := registry.DispatchFrom(msgCtx, event.Topic, msg); err != nil {
+			fmt.Fprintf(os.Stderr, "dispatch %s (%s): %v\n", event.Topic, msg.Topic, err)
+		}
+		return nil
+	})
+	return replayed, err
+}
+
+// attachSimulatedClients registers fake clients so routing can be observed without sockets.
+func attachSimulatedClients(hub *infrastructure.Hub, spec string) error {
+	for _, entry := range splitList(spec, ";") {
+		identity, topicList, _ := strings.Cut(entry, "=")
+		parts := strings.Split(identity, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return fmt.Errorf("%q: expected user:session[:section]", identity)
+		}
+		sectionID := ""
+		if len(parts) == 3 {
+			sectionID = parts[2]
+		}
+		client := infrastructure.NewClient(hub, nil, strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), sectionID, "", "", 1024, nil)
+		topics := splitList(topicList, ",")
+		if len(topics) == 1 && topics[0] == "*" {
+			hub.AttachClientToAll(client)
+			continue
+		}
+		hub.AttachClient(client, topics)
+	}
+	return nil
+}
+
+func splitList(raw, sep string) []string {
+	var values []string
+	for _, part := range strings.Split(raw, sep) {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			values = append(values, trimmed)
+		}
+	}
+	return values
+}
+
+// dryRunBroadcaster prints the routing decision for each message instead of sending it.
+type dryRunBroadcaster struct {
+	hub *infrastructure.Hub
+	out io.Writer
+}
+
+func (b *dryRunBroadcaster) Broadcast(ctx context.Context, msg *domain.Message) {
+	delivered, dropped := b.hub.Targets(msg)
+	printDelivery(b.out, infrastructure.Delivery{
+		Kind:        "dry-run",
+		SourceTopic: port.SourceTopic(ctx),
+		Message:     msg,
+		Delivered:   delivered,
+		Dropped:     dropped,
+	})
+}
+
+// printRecorder prints what the in-process hub actually delivered.
+type printRecorder struct {
+	out io.Writer
+}
+
+func (r *printRecorder) RecordDelivery(_ context.Context, delivery infrastructure.Delivery) {
+	printDelivery(r.out, delivery)
+}
+
+func printDelivery(out io.Writer, delivery infrastructure.Delivery) {
+	msg := delivery.Message
+	dropped := make([]string, 0, len(delivery.Dropped))
+	for _, drop := range delivery.Dropped {
+		dropped = append(dropped, drop.Client+"("+drop.Reason+")")
+	}
+	fmt.Fprintf(out, "%s %-9s %s -> %s resource=%s clients=[%s] dropped=[%s]\n",
+		msg.Timestamp.Format(time.RFC3339),
+		delivery.Kind,
+		delivery.SourceTopic,
+		msg.Topic,
+		msg.ResourceID,
+		strings.Join(delivery.Delivered, ","),
+		strings.Join(dropped, ","),
+	)
+}
+
+// httpBroadcaster forwards messages to the /v2/broadcast endpoint of a running server.
+type httpBroadcaster struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPBroadcaster(baseURL, apiKey string, timeout time.Duration) *httpBroadcaster {
+	return &httpBroadcaster{
+		url:    strings.TrimRight(strings.TrimSpace(baseURL), "/") + "/v2/broadcast",
+		apiKey: strings.TrimSpace(apiKey),
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (b *httpBroadcaster) Broadcast(ctx context.Context, msg *domain.Message) {
+	body, err := json.Marshal(transport.BroadcastV2Request{
+		Topic:      msg.Topic,
+		Entity:     msg.Entity,
+		Action:     msg.Action,
+		ResourceID: msg.ResourceID,
+		Data:       msg.Data,
+		Metadata:   msg.Metadata,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "encode %s: %v\n", msg.Topic, err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "build request: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.apiKey != "" {
+		req.Header.Set(auth.HeaderAPIKey, b.apiKey)
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "broadcast %s: %v\n", msg.Topic, err)
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	fmt.Fprintf(os.Stdout, "%s sent      %s -> %s resource=%s status=%d\n", msg.Timestamp.Format(time.RFC3339), port.SourceTopic(ctx), msg.Topic, msg.ResourceID, resp.StatusCode)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	transport "mesaYaWs/internal/modules/realtime/interface"
+	"mesaYaWs/internal/platform/broker"
+)
+
+type replayedEvent struct {
+	topic    string
+	position port.SourcePosition
+	hasPos   bool
+}
+
+type capturingHandler struct {
+	topic  string
+	events []replayedEvent
+}
+
+func (h *capturingHandler) Topic() string { return h.topic }
+
+func (h *capturingHandler) Handle(ctx context.Context, msg *domain.Message) error {
+	position, ok := port.SourcePositionFrom(ctx)
+	h.events = append(h.events, replayedEvent{topic: msg.Topic, position: position, hasPos: ok})
+	return nil
+}
+
+func TestReplayEvents_FiltersTopicsAndKeepsKafkaOffsets(t *testing.T) {
+	input, err := os.Open(filepath.Join("testdata", "events.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer input.Close()
+
+	registry := infrastructure.NewHandlerRegistry()
+	reservations := &capturingHandler{topic: "mesa-ya.reservations.events"}
+	tables := &capturingHandler{topic: "mesa-ya.tables.events"}
+	registry.Register(reservations)
+	registry.Register(tables)
+
+	replayed, err := replayEvents(context.Background(), input, broker.ReplayOptions{Topics: []string{"mesa-ya.reservations.*"}}, registry)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replayed != 2 || len(tables.events) != 0 {
+		t.Fatalf("expected 2 reservation events and no tables, got %d replayed / %d tables", replayed, len(tables.events))
+	}
+	want := []replayedEvent{
+		{topic: "reservations.created", position: port.SourcePosition{Partition: 1, Offset: 41}, hasPos: true},
+		{topic: "reservations.updated"},
+	}
+	if len(reservations.events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), reservations.events)
+	}
+	for i, event := range want {
+		if reservations.events[i] != event {
+			t.Fatalf("event %d: got %+v, want %+v", i, reservations.events[i], event)
+		}
+	}
+}
+
+func TestHTTPBroadcaster_PostsV2Requests(t *testing.T) {
+	var got transport.BroadcastV2Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/broadcast" || r.Header.Get("X-API-Key") != "key-1" {
+			t.Errorf("unexpected request %s (api key %q)", r.URL.Path, r.Header.Get("X-API-Key"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	broadcaster := newHTTPBroadcaster(server.URL+"/", "key-1", 0)
+	broadcaster.Broadcast(context.Background(), &domain.Message{Topic: "reservations.updated", Entity: "reservations", Action: "updated", ResourceID: "r-1", Metadata: map[string]string{"restaurantId": "rest-1"}})
+
+	if got.Topic != "reservations.updated" || got.Entity != "reservations" || got.ResourceID != "r-1" || got.Metadata["restaurantId"] != "rest-1" {
+		t.Fatalf("unexpected request body: %+v", got)
+	}
+}
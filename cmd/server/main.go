@@ -18,12 +18,17 @@ import (
 
 	"mesaYaWs/internal/config"
 	handler "mesaYaWs/internal/modules/realtime/application/handler"
+	"mesaYaWs/internal/modules/realtime/application/port"
 	usecase "mesaYaWs/internal/modules/realtime/application/usecase"
 	"mesaYaWs/internal/modules/realtime/infrastructure"
 	transport "mesaYaWs/internal/modules/realtime/interface"
 	"mesaYaWs/internal/platform/broker"
+	"mesaYaWs/internal/platform/inbox"
+	"mesaYaWs/internal/platform/scheduler"
+	"mesaYaWs/internal/platform/webhook"
 	"mesaYaWs/internal/shared/auth"
 	"mesaYaWs/internal/shared/logging"
+	"mesaYaWs/internal/shared/metrics"
 )
 
 func main() {
@@ -48,14 +53,102 @@ func main() {
 	slog.SetDefault(logger)
 	slog.Info("logging initialized", slog.String("directory", cfg.Logging.Directory), slog.String("level", cfg.Logging.Level), slog.String("format", cfg.Logging.Format))
 	slog.Info("kafka env snapshot", slog.String("KAFKA_BROKERS", os.Getenv("KAFKA_BROKERS")), slog.String("KAFKA_BROKER", os.Getenv("KAFKA_BROKER")))
-	slog.Info("kafka config resolved", slog.Any("brokers", cfg.Kafka.Brokers), slog.String("group", cfg.Kafka.GroupID))
+	slog.Info("kafka config resolved", slog.Any("brokers", cfg.Kafka.Brokers), slog.String("group", cfg.Kafka.GroupID), slog.Bool("tls", cfg.Kafka.TLS.Enabled), slog.String("saslMechanism", cfg.Kafka.SASL.Mechanism))
 	slog.Info("security config", slog.Bool("hasPublicKey", cfg.Security.JWTPublicKey != ""), slog.Bool("hasSecret", cfg.Security.JWTSecret != ""))
 
+	// Entity registry: aliases, roles, REST paths and commands, handed to the components below
+	entityRegistry := cfg.Entities.Registry
+	slog.Info("entity registry loaded", slog.String("file", cfg.Entities.File), slog.Any("entities", entityRegistry.Names()))
+
+	// Broadcast authentication is checked before starting anything: missing keys abort startup
+	broadcastAuth := broadcastMiddlewares(cfg.Security.Broadcast)
+
 	hub := infrastructure.NewHub()
 	registry := infrastructure.NewHandlerRegistry()
+	registry.Use(
+		infrastructure.RecoveryMiddleware(),
+		infrastructure.LoggingMiddleware(),
+		infrastructure.MetricsMiddleware(metrics.Default),
+		infrastructure.TimeoutMiddleware(cfg.Kafka.HandlerTimeout),
+		infrastructure.DedupeMiddleware(cfg.Kafka.DedupeWindow),
+	)
+
+	// Optional recording of every outbound message (debugging / replay input)
+	var recorder *infrastructure.JSONLRecorder
+	if cfg.Recording.Dir != "" {
+		recorder, err = infrastructure.NewJSONLRecorder(infrastructure.RecorderOptions{
+			Dir:      cfg.Recording.Dir,
+			MaxBytes: cfg.Recording.MaxBytes,
+			MaxAge:   cfg.Recording.MaxAge,
+			Topics:   cfg.Recording.Topics,
+			Entities: cfg.Recording.Entities,
+		})
+		if err != nil {
+			slog.Error("broadcast recording disabled", slog.Any("error", err))
+			recorder = nil
+		} else {
+			hub.SetRecorder(recorder)
+		}
+	}
 
 	// Use cases
-	broadcastUC := usecase.NewBroadcastUseCase(hub)
+	// Outbound webhooks: consumed events (registered below) and HTTP/scheduled broadcasts
+	var webhooks *webhook.Dispatcher
+	if cfg.Webhooks.ConfigFile != "" {
+		endpoints, err := webhook.LoadEndpoints(cfg.Webhooks.ConfigFile)
+		if err == nil {
+			webhooks, err = webhook.NewDispatcher(endpoints, webhook.Options{
+				QueueDir:       cfg.Webhooks.QueueDir,
+				MaxAttempts:    cfg.Webhooks.MaxAttempts,
+				InitialBackoff: cfg.Webhooks.InitialBackoff,
+				MaxBackoff:     cfg.Webhooks.MaxBackoff,
+				Timeout:        cfg.Webhooks.Timeout,
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "webhook config error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var observers []port.Broadcaster
+	if webhooks != nil {
+		observers = append(observers, webhooks)
+	}
+	// Notification inbox: keeps the last notifications of every user for offline delivery
+	inboxStore, err := inbox.NewFileStore(cfg.Inbox.Dir, cfg.Inbox.Limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notification inbox error: %v\n", err)
+		os.Exit(1)
+	}
+	notificationInbox := usecase.NewNotificationInbox(inboxStore, hub, entityRegistry)
+	preferenceStore, err := inbox.NewPreferenceFile(cfg.Inbox.PreferencesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notification preferences error: %v\n", err)
+		os.Exit(1)
+	}
+	notificationInbox.SetPreferences(preferenceStore)
+	notificationPreferences := usecase.NewNotificationPreferencesUseCase(preferenceStore, entityRegistry)
+	observers = append(observers, notificationInbox)
+	notificationTemplates := usecase.DefaultNotificationTemplates()
+	if cfg.Inbox.TemplatesFile != "" {
+		notificationTemplates, err = usecase.LoadNotificationTemplates(cfg.Inbox.TemplatesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "notification templates error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	broadcastUC := usecase.NewBroadcastUseCase(hub, observers...)
+
+	// Scheduled broadcasts (deliverAt / cron on /v2/broadcast)
+	location := time.Local
+	if cfg.Scheduler.Timezone != "" {
+		location, _ = time.LoadLocation(cfg.Scheduler.Timezone)
+	}
+	broadcastScheduler, err := scheduler.New(broadcastUC, scheduler.Options{File: cfg.Scheduler.File, Location: location})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "scheduler error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Echo server
 	e := echo.New()
@@ -63,13 +156,36 @@ func main() {
 
 	// JWT validator used to validate tokens issued by the Nest auth service
 	validator := auth.NewJWTValidatorWithPublicKey(cfg.Security.JWTSecret, cfg.Security.JWTPublicKey)
-	snapshotFetcher := infrastructure.NewSectionSnapshotHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil)
+	snapshotFetcher := infrastructure.NewSectionSnapshotHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil, entityRegistry)
 	analyticsFetcher := infrastructure.NewAnalyticsHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil)
 	connectUC := usecase.NewConnectSectionUseCase(validator, snapshotFetcher)
-	analyticsUC := usecase.NewAnalyticsUseCase(validator, analyticsFetcher)
+	analyticsUC := usecase.NewAnalyticsUseCase(validator, analyticsFetcher, entityRegistry)
+	analyticsRegistry := usecase.DefaultAnalyticsRegistry(entityRegistry)
+	if cfg.Analytics.ConfigFile != "" {
+		analyticsRegistry, err = usecase.LoadAnalyticsRegistry(cfg.Analytics.ConfigFile, entityRegistry)
+	}
+	if err == nil {
+		err = analyticsUC.SetRegistry(analyticsRegistry)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "analytics config error: %v\n", err)
+		os.Exit(1)
+	}
+	analyticsUC.SetSharedFetchTTL(cfg.Analytics.SharedCacheTTL)
+	ownershipResolver := infrastructure.NewCachedRestaurantOwnership(infrastructure.NewRestaurantOwnershipHTTPClient(cfg.REST.BaseURL, cfg.REST.Timeout, nil), cfg.REST.OwnershipCacheTTL)
+	analyticsUC.SetOwnershipResolver(ownershipResolver)
+	for key, interval := range cfg.Analytics.RefreshIntervals {
+		if !analyticsUC.SetRefreshInterval(key, interval) {
+			slog.Warn("analytics refresh interval for unknown endpoint ignored", slog.String("key", key))
+		}
+	}
 
 	// Registrar handlers de tópicos (cada feature)
 	registry.Register(&handler.UserCreatedHandler{UseCase: broadcastUC})
+	if webhooks != nil {
+		// Every consumed event, catch-up included; HTTP broadcasts reach them as observer
+		registry.Register(webhooks)
+	}
 	for entity, topics := range cfg.Kafka.Topics {
 		for _, topic := range topics {
 			registry.Register(handler.NewEntityStreamHandler(entity, topic, cfg.Websocket.AllowedActions, broadcastUC, connectUC, analyticsUC))
@@ -79,27 +195,127 @@ func main() {
 	// Iniciar Kafka consumers (registrar topics desde config)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	if webhooks != nil {
+		go webhooks.Start(ctx)
+	}
+	go broadcastScheduler.Start(ctx)
+	go analyticsUC.StartPeriodicRefresh(ctx, broadcastUC)
 	// gather topics from config
 	topics := make([]string, 0)
 	for _, topicList := range cfg.Kafka.Topics {
 		topics = append(topics, topicList...)
 	}
-	broker.StartKafkaConsumers(ctx, registry, cfg.Kafka.Brokers, cfg.Kafka.GroupID, topics)
+	validationMode, err := broker.ParseValidationMode(cfg.Kafka.Validation.Mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kafka schema validation config error: %v\n", err)
+		os.Exit(1)
+	}
+	eventValidator, err := broker.NewEventValidator(validationMode, cfg.Kafka.Validation.SchemaDir, cfg.Kafka.Validation.QuarantineDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kafka schema validation config error: %v\n", err)
+		os.Exit(1)
+	}
+	kafkaDialer, err := broker.NewDialer(cfg.Kafka.TLS, cfg.Kafka.SASL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "kafka security config error: %v\n", err)
+		os.Exit(1)
+	}
+	consumerOpts := broker.ConsumerOptions{
+		Validator:        eventValidator,
+		Dialer:           kafkaDialer,
+		StartOffset:      broker.StartOffsetFromConfig(cfg.Kafka.StartOffset),
+		StartTime:        cfg.Kafka.StartTime,
+		CatchUpThreshold: cfg.Kafka.CatchUpThreshold,
+	}
+	var pubsub port.PubSubPort
+	var replay *broker.FileSource
+	switch cfg.PubSub.Driver {
+	case "memory":
+		pubsub = broker.NewMemoryPubSub()
+	case "file":
+		replay = broker.NewFileSource(cfg.PubSub.ReplayFile, broker.ReplayOptions{Speed: cfg.PubSub.ReplaySpeed})
+		pubsub = replay
+	default:
+		pubsub = broker.NewKafkaPubSub(cfg.Kafka.Brokers, cfg.Kafka.GroupID, consumerOpts)
+	}
+	slog.Info("pubsub driver selected", slog.String("driver", cfg.PubSub.Driver))
+	broker.StartConsumers(ctx, pubsub, registry, topics)
+	if replay != nil {
+		go func() {
+			if err := replay.Start(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("replay failed", slog.Any("error", err))
+			}
+		}()
+	}
+
+	// Pattern subscription: topics created after startup get an entity stream handler automatically
+	if cfg.Kafka.Discovery.Pattern != "" && cfg.PubSub.Driver == "kafka" {
+		discovery, err := broker.NewTopicDiscovery(
+			cfg.Kafka.Discovery.Pattern,
+			cfg.Kafka.Discovery.Interval,
+			cfg.Kafka.Brokers,
+			kafkaDialer,
+			pubsub,
+			registry,
+			func(topic, entity string) {
+				registry.Register(handler.NewEntityStreamHandler(entity, topic, cfg.Websocket.AllowedActions, broadcastUC, connectUC, analyticsUC))
+			},
+			topics,
+			entityRegistry,
+		)
+		if err != nil {
+			slog.Error("kafka topic discovery disabled", slog.Any("error", err))
+		} else {
+			go discovery.Run(ctx)
+		}
+	}
 
-	wsHandler := transport.NewWebsocketHandler(hub, connectUC, cfg.Websocket.DefaultEntity, cfg.Websocket.AllowedActions)
-	notificationsHandler := transport.NewNotificationsWebsocketHandler(hub, validator)
+	// Producer for client-originated events (table selection, presence, acks)
+	var emitUC *usecase.EmitEventUseCase
+	if cfg.Kafka.Producer.Enabled {
+		kafkaTransport, err := broker.NewTransport(cfg.Kafka.TLS, cfg.Kafka.SASL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "kafka security config error: %v\n", err)
+			os.Exit(1)
+		}
+		producer, err := broker.NewKafkaProducer(cfg.Kafka.Brokers, broker.ProducerOptions{
+			Transport:    kafkaTransport,
+			BatchSize:    cfg.Kafka.Producer.BatchSize,
+			BatchTimeout: cfg.Kafka.Producer.BatchTimeout,
+			MaxAttempts:  cfg.Kafka.Producer.MaxAttempts,
+		})
+		if err != nil {
+			slog.Error("kafka producer disabled", slog.Any("error", err))
+		} else {
+			defer producer.Close()
+			emitUC = usecase.NewEmitEventUseCase(producer, cfg.Kafka.Producer.Topic, cfg.Kafka.Producer.Topics, cfg.Kafka.Producer.Actions)
+		}
+	}
+
+	wsHandler := transport.NewWebsocketHandler(hub, connectUC, emitUC, entityRegistry, cfg.Websocket.DefaultEntity, cfg.Websocket.AllowedActions)
+	notificationsHandler := transport.NewNotificationsWebsocketHandler(hub, validator, usecase.NewNotificationAudience(ownershipResolver, entityRegistry), notificationInbox, notificationPreferences, notificationTemplates)
 	analyticsHandler := transport.NewAnalyticsWebsocketHandler(hub, analyticsUC)
 	broadcastHandler := transport.NewBroadcastHTTPHandler(broadcastUC)
 
 	// Generic entity routes: allow token in path or via query/header fallback
 	e.GET("/ws/:entity/:section/:token", wsHandler)
 	e.GET("/ws/:entity/:section", wsHandler)
+	// Several (entity, section) channels on one connection (join / leave commands)
+	e.GET("/ws", transport.NewMultiplexWebsocketHandler(hub, connectUC, emitUC, entityRegistry, cfg.Websocket.AllowedActions))
 	// Broadcast notifications stream
 	e.GET("/ws/notifications", notificationsHandler)
 	// Analytics websocket endpoints
 	e.GET("/ws/analytics/:scope/:entity", analyticsHandler)
-	// REST endpoint for broadcasting messages (used by n8n workflows)
-	e.POST("/broadcast", broadcastHandler)
+	// Several analytics dashboards on one connection (watch / unwatch commands)
+	e.GET("/ws/analytics", transport.NewAnalyticsMuxWebsocketHandler(hub, analyticsUC))
+	e.GET("/admin/analytics/registry", transport.NewAnalyticsRegistryHTTPHandler(analyticsUC, validator))
+	// REST endpoints for broadcasting messages (used by n8n workflows and backend services)
+	e.POST("/broadcast", broadcastHandler, broadcastAuth...)
+	e.POST("/v2/broadcast", transport.NewBroadcastV2HTTPHandler(broadcastUC, broadcastScheduler), broadcastAuth...)
+	e.GET("/v2/broadcast/scheduled", transport.NewScheduledBroadcastsListHandler(broadcastScheduler), broadcastAuth...)
+	e.DELETE("/v2/broadcast/scheduled/:id", transport.NewScheduledBroadcastCancelHandler(broadcastScheduler), broadcastAuth...)
+	// Operational counters (schema validation, deliveries)
+	e.GET("/metrics", transport.NewMetricsHTTPHandler(metrics.Default))
 
 	go func() {
 		if err := e.Start(":" + cfg.Server.Port); err != nil {
@@ -113,6 +329,36 @@ func main() {
 	<-stop
 	slog.Info("shutting down")
 	e.Close()
+	// Stop consumers and background jobs before flushing the recorder they feed
+	cancel()
+	if recorder != nil {
+		hub.SetRecorder(nil)
+		recorder.Close()
+	}
+}
+
+// broadcastMiddlewares returns the authentication required on the broadcast endpoints.
+func broadcastMiddlewares(cfg config.BroadcastAuthConfig) []echo.MiddlewareFunc {
+	if cfg.Mode == "off" {
+		slog.Warn("broadcast authentication disabled", slog.String("BROADCAST_AUTH", cfg.Mode))
+		return nil
+	}
+	if cfg.KeysFile == "" {
+		fmt.Fprintln(os.Stderr, "broadcast keys error: BROADCAST_KEYS_FILE is required when BROADCAST_AUTH=required (use BROADCAST_AUTH=off for local development)")
+		os.Exit(1)
+	}
+	keys, err := auth.LoadKeySet(cfg.KeysFile, cfg.SignatureTolerance)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "broadcast keys error: %v\n", err)
+		os.Exit(1)
+	}
+	if keys.Len() == 0 {
+		// Every /broadcast request would be rejected with 401
+		fmt.Fprintf(os.Stderr, "broadcast keys error: %s has no keys (use BROADCAST_AUTH=off for local development)\n", cfg.KeysFile)
+		os.Exit(1)
+	}
+	slog.Info("broadcast authentication enabled", slog.Int("keys", keys.Len()))
+	return []echo.MiddlewareFunc{transport.NewBroadcastAuthMiddleware(keys)}
 }
 
 func setupLogging(cfg config.LoggingConfig) (*os.File, *slog.Logger, error) {
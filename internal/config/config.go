@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"mesaYaWs/internal/shared/entities"
 )
 
 // Config groups the runtime configuration of the realtime service following
@@ -16,6 +19,13 @@ import (
 type Config struct {
 	Server    ServerConfig
 	Kafka     KafkaConfig
+	PubSub    PubSubConfig
+	Recording RecordingConfig
+	Webhooks  WebhookConfig
+	Scheduler SchedulerConfig
+	Inbox     InboxConfig
+	Entities  EntitiesConfig
+	Analytics AnalyticsConfig
 	Security  SecurityConfig
 	REST      RESTConfig
 	Logging   LoggingConfig
@@ -27,19 +37,164 @@ type ServerConfig struct {
 }
 
 type KafkaConfig struct {
-	Brokers []string
-	GroupID string
-	Topics  map[string][]string
+	Brokers    []string
+	GroupID    string
+	Topics     map[string][]string
+	Validation KafkaValidationConfig
+	TLS        KafkaTLSConfig
+	SASL       KafkaSASLConfig
+	Discovery  KafkaDiscoveryConfig
+	Producer   KafkaProducerConfig
+	// HandlerTimeout bounds each topic handler invocation.
+	HandlerTimeout time.Duration
+	// DedupeWindow drops Kafka redeliveries (same topic, partition and offset) handled by the
+	// same handler within the window. Zero disables it.
+	DedupeWindow time.Duration
+	// StartOffset is where a consumer group without committed offsets begins:
+	// earliest, latest or timestamp (events before StartTime are skipped).
+	StartOffset string
+	StartTime   time.Time
+	// CatchUpThreshold marks events older than this as catch-up: they refresh caches and
+	// analytics but are not broadcast to clients. Zero disables catch-up mode.
+	CatchUpThreshold time.Duration
+}
+
+// KafkaProducerConfig enables publishing client-originated websocket actions (Actions) to
+// Kafka. Events go to Topic unless Topics maps the action to its own topic.
+type KafkaProducerConfig struct {
+	Enabled      bool
+	Topic        string
+	Topics       map[string]string
+	Actions      []string
+	BatchSize    int
+	BatchTimeout time.Duration
+	MaxAttempts  int
+}
+
+// KafkaDiscoveryConfig enables subscribing to every topic matching Pattern. New topics are
+// picked up every Interval without restarting the service.
+type KafkaDiscoveryConfig struct {
+	Pattern  string
+	Interval time.Duration
+}
+
+// KafkaTLSConfig enables TLS towards the brokers. CA and client certificates are PEM
+// contents, loaded either inline from env or from the *_FILE paths.
+type KafkaTLSConfig struct {
+	Enabled            bool
+	CAPEM              string
+	CertPEM            string
+	KeyPEM             string
+	InsecureSkipVerify bool
+}
+
+// KafkaSASLConfig holds SASL credentials. Mechanism accepts plain, scram-sha-256 or scram-sha-512.
+type KafkaSASLConfig struct {
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// KafkaValidationConfig controls schema validation of incoming events.
+// Mode accepts off, warn or enforce.
+type KafkaValidationConfig struct {
+	Mode          string
+	SchemaDir     string
+	QuarantineDir string
+}
+
+// PubSubConfig selects where domain events are consumed from. Driver accepts kafka (default),
+// memory (in-process only, useful for tests and local development) or file, which replays a
+// JSONL recording (Kafka quarantine files, broadcast recordings) at ReplaySpeed.
+// A ReplaySpeed of 1 keeps the original timing, 10 is ten times faster and 0 disables pacing.
+type PubSubConfig struct {
+	Driver      string
+	ReplayFile  string
+	ReplaySpeed float64
+}
+
+// RecordingConfig enables the broadcast recorder when Dir is set. Files rotate when they
+// exceed MaxBytes or have been open for MaxAge; Topics (glob patterns) and Entities filter
+// what is recorded.
+type RecordingConfig struct {
+	Dir      string
+	MaxBytes int64
+	MaxAge   time.Duration
+	Topics   []string
+	Entities []string
+}
+
+// WebhookConfig enables outbound webhooks when ConfigFile (a JSON array of subscriptions)
+// is set. Pending deliveries are persisted under QueueDir and retried with exponential
+// backoff from InitialBackoff up to MaxBackoff, MaxAttempts times.
+type WebhookConfig struct {
+	ConfigFile     string
+	QueueDir       string
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+}
+
+// SchedulerConfig persists the scheduled broadcasts (deliverAt / cron on /v2/broadcast) in
+// File; an empty File keeps them in memory. Cron expressions are evaluated in Timezone
+// (IANA name, empty = local time).
+type SchedulerConfig struct {
+	File     string
+	Timezone string
+}
+
+// InboxConfig keeps the last Limit notifications of every user under Dir (one JSON file per
+// user) so /ws/notifications delivers the unread ones on connect. An empty Dir keeps them in
+// memory only. PreferencesFile persists the muted topics and quiet hours of every user.
+// TemplatesFile overrides the built-in notification titles and bodies (es/en).
+type InboxConfig struct {
+	Dir             string
+	Limit           int
+	PreferencesFile string
+	TemplatesFile   string
+}
+
+// AnalyticsConfig overrides the periodic refresh of analytics endpoints, keyed by analytics
+// key (e.g. analytics-admin-reservations). A zero duration disables it for that endpoint.
+// SharedCacheTTL is how long sessions with the same dashboard, query and audience reuse one
+// REST response. ConfigFile replaces the built-in endpoint registry (endpoints, aliases and
+// entity dependencies) with a JSON file; empty keeps the defaults.
+type AnalyticsConfig struct {
+	ConfigFile       string
+	RefreshIntervals map[string]time.Duration
+	SharedCacheTTL   time.Duration
+}
+
+// EntitiesConfig holds the entity registry (aliases, roles, REST paths, resource keys, Kafka
+// topics and command names), read from File or the built-in one when File is empty.
+type EntitiesConfig struct {
+	File     string
+	Registry *entities.Registry
 }
 
 type SecurityConfig struct {
 	JWTSecret    string
 	JWTPublicKey string
+	Broadcast    BroadcastAuthConfig
+}
+
+// BroadcastAuthConfig protects /broadcast. Mode "required" (default) accepts only the keys in
+// KeysFile (API key or HMAC signature) and the server refuses to start without keys; "off"
+// leaves the endpoint open for local development.
+// SignatureTolerance bounds the accepted clock skew and the replay window of signed requests.
+type BroadcastAuthConfig struct {
+	Mode               string
+	KeysFile           string
+	SignatureTolerance time.Duration
 }
 
+// RESTConfig points to the REST API. OwnershipCacheTTL is how long the restaurants owned by a
+// token are reused by the analytics and notification checks; zero disables the cache.
 type RESTConfig struct {
-	BaseURL string
-	Timeout time.Duration
+	BaseURL           string
+	Timeout           time.Duration
+	OwnershipCacheTTL time.Duration
 }
 
 type LoggingConfig struct {
@@ -61,14 +216,81 @@ func Load() (Config, error) {
 			Brokers: firstNonEmptySlice(splitEnv(os.Getenv("KAFKA_BROKERS")), splitEnv(os.Getenv("KAFKA_BROKER"))),
 			GroupID: stringOrDefault(os.Getenv("KAFKA_GROUP_ID"), "realtime-group"),
 			Topics:  parseTopics(os.Getenv("WS_ENTITY_TOPICS")),
+			Validation: KafkaValidationConfig{
+				Mode:          stringOrDefault(strings.ToLower(os.Getenv("KAFKA_SCHEMA_VALIDATION")), "off"),
+				SchemaDir:     stringOrDefault(trimQuotes(os.Getenv("KAFKA_SCHEMA_DIR")), "./docs/kafka/schemas"),
+				QuarantineDir: stringOrDefault(trimQuotes(os.Getenv("KAFKA_QUARANTINE_DIR")), "./logs/quarantine"),
+			},
+			TLS: KafkaTLSConfig{
+				Enabled:            boolOrDefault(os.Getenv("KAFKA_TLS_ENABLED"), false),
+				InsecureSkipVerify: boolOrDefault(os.Getenv("KAFKA_TLS_INSECURE_SKIP_VERIFY"), false),
+			},
+			SASL: KafkaSASLConfig{
+				Mechanism: strings.ToLower(strings.TrimSpace(os.Getenv("KAFKA_SASL_MECHANISM"))),
+			},
+			Discovery: KafkaDiscoveryConfig{
+				Pattern:  trimQuotes(os.Getenv("KAFKA_TOPIC_PATTERN")),
+				Interval: durationOrDefault(os.Getenv("KAFKA_TOPIC_DISCOVERY_INTERVAL"), time.Minute),
+			},
+			Producer: KafkaProducerConfig{
+				Enabled: boolOrDefault(os.Getenv("KAFKA_PRODUCER_ENABLED"), false),
+				Topic:   stringOrDefault(trimQuotes(os.Getenv("KAFKA_PRODUCER_TOPIC")), "mesa-ya.realtime.events"),
+				Topics:  parseActionTopics(os.Getenv("KAFKA_PRODUCER_TOPICS")),
+				Actions: firstNonEmptySlice(
+					splitEnv(os.Getenv("WS_EMIT_ACTIONS")),
+					[]string{"table_selected", "table_released", "presence", "ack"},
+				),
+				BatchSize:    intOrDefault(os.Getenv("KAFKA_PRODUCER_BATCH_SIZE"), 100),
+				BatchTimeout: durationOrDefault(os.Getenv("KAFKA_PRODUCER_BATCH_TIMEOUT"), 50*time.Millisecond),
+				MaxAttempts:  intOrDefault(os.Getenv("KAFKA_PRODUCER_MAX_ATTEMPTS"), 5),
+			},
+			HandlerTimeout:   durationOrDefault(os.Getenv("KAFKA_HANDLER_TIMEOUT"), 30*time.Second),
+			DedupeWindow:     durationOrDefault(os.Getenv("KAFKA_DEDUPE_WINDOW"), 0),
+			CatchUpThreshold: durationOrDefault(os.Getenv("KAFKA_CATCHUP_THRESHOLD"), 0),
+		},
+		PubSub: PubSubConfig{
+			Driver:      stringOrDefault(strings.ToLower(strings.TrimSpace(os.Getenv("PUBSUB_DRIVER"))), "kafka"),
+			ReplayFile:  trimQuotes(os.Getenv("PUBSUB_REPLAY_FILE")),
+			ReplaySpeed: floatOrDefault(os.Getenv("PUBSUB_REPLAY_SPEED"), 1),
+		},
+		Recording: RecordingConfig{
+			Dir:      trimQuotes(os.Getenv("RECORDING_DIR")),
+			MaxBytes: int64(intOrDefault(os.Getenv("RECORDING_MAX_SIZE_MB"), 100)) * 1024 * 1024,
+			MaxAge:   durationOrDefault(os.Getenv("RECORDING_ROTATE_INTERVAL"), time.Hour),
+			Topics:   splitEnv(os.Getenv("RECORDING_TOPICS")),
+			Entities: splitEnv(os.Getenv("RECORDING_ENTITIES")),
+		},
+		Webhooks: WebhookConfig{
+			ConfigFile:     trimQuotes(os.Getenv("WEBHOOKS_CONFIG")),
+			QueueDir:       stringOrDefault(trimQuotes(os.Getenv("WEBHOOKS_QUEUE_DIR")), "./logs/webhooks"),
+			MaxAttempts:    intOrDefault(os.Getenv("WEBHOOKS_MAX_ATTEMPTS"), 8),
+			InitialBackoff: durationOrDefault(os.Getenv("WEBHOOKS_INITIAL_BACKOFF"), time.Second),
+			MaxBackoff:     durationOrDefault(os.Getenv("WEBHOOKS_MAX_BACKOFF"), 5*time.Minute),
+			Timeout:        durationOrDefault(os.Getenv("WEBHOOKS_TIMEOUT"), 10*time.Second),
+		},
+		Scheduler: SchedulerConfig{
+			File:     stringOrDefault(trimQuotes(os.Getenv("SCHEDULER_FILE")), "./logs/scheduled-broadcasts.json"),
+			Timezone: strings.TrimSpace(os.Getenv("SCHEDULER_TIMEZONE")),
+		},
+		Inbox: InboxConfig{
+			Dir:             stringOrDefault(trimQuotes(os.Getenv("NOTIFICATIONS_INBOX_DIR")), "./logs/notifications"),
+			Limit:           intOrDefault(os.Getenv("NOTIFICATIONS_INBOX_LIMIT"), 100),
+			PreferencesFile: stringOrDefault(trimQuotes(os.Getenv("NOTIFICATIONS_PREFERENCES_FILE")), "./logs/notification-preferences.json"),
+			TemplatesFile:   trimQuotes(os.Getenv("NOTIFICATIONS_TEMPLATES_FILE")),
 		},
 		Security: SecurityConfig{
 			JWTSecret:    trimQuotes(os.Getenv("JWT_SECRET")),
 			JWTPublicKey: normalizePublicKey(os.Getenv("JWT_PUBLIC_KEY")),
+			Broadcast: BroadcastAuthConfig{
+				Mode:               stringOrDefault(strings.ToLower(strings.TrimSpace(os.Getenv("BROADCAST_AUTH"))), "required"),
+				KeysFile:           trimQuotes(os.Getenv("BROADCAST_KEYS_FILE")),
+				SignatureTolerance: durationOrDefault(os.Getenv("BROADCAST_SIGNATURE_TOLERANCE"), 5*time.Minute),
+			},
 		},
 		REST: RESTConfig{
-			BaseURL: stringOrDefault(trimQuotes(os.Getenv("REST_BASE_URL")), "http://localhost:3000"),
-			Timeout: durationOrDefault(os.Getenv("REST_TIMEOUT"), 10*time.Second),
+			BaseURL:           stringOrDefault(trimQuotes(os.Getenv("REST_BASE_URL")), "http://localhost:3000"),
+			Timeout:           durationOrDefault(os.Getenv("REST_TIMEOUT"), 10*time.Second),
+			OwnershipCacheTTL: durationOrDefault(os.Getenv("REST_OWNERSHIP_CACHE_TTL"), 5*time.Minute),
 		},
 		Logging: LoggingConfig{
 			Directory: stringOrDefault(trimQuotes(os.Getenv("LOG_DIR")), "./logs"),
@@ -96,60 +318,46 @@ func Load() (Config, error) {
 		},
 	}
 
-	// Default topics following Event-Driven Architecture pattern:
+	cfg.Entities.File = trimQuotes(os.Getenv("ENTITY_REGISTRY_FILE"))
+	cfg.Entities.Registry = entities.Default()
+	if cfg.Entities.File != "" {
+		registry, err := entities.Load(cfg.Entities.File)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Entities.Registry = registry
+	}
+
+	// Default topics come from the entity registry, following Event-Driven Architecture pattern:
 	// - One topic per domain/aggregate with event_type in payload
 	// - Ephemeral events (selecting/released) handled via WebSocket only, not Kafka
 	if len(cfg.Kafka.Topics) == 0 {
-		cfg.Kafka.Topics = map[string][]string{
-			"restaurants": {
-				"mesa-ya.restaurants.events",
-			},
-			"sections": {
-				"mesa-ya.sections.events",
-			},
-			"tables": {
-				"mesa-ya.tables.events",
-				// NOTE: selecting/released are ephemeral events handled via WebSocket only
-			},
-			"objects": {
-				"mesa-ya.objects.events",
-			},
-			"section-objects": {
-				"mesa-ya.section-objects.events",
-			},
-			"menus": {
-				"mesa-ya.menus.events",
-				// Includes dishes as sub-entity (entity_subtype: 'menu' | 'dish')
-			},
-			"reviews": {
-				"mesa-ya.reviews.events",
-			},
-			"images": {
-				"mesa-ya.images.events",
-			},
-			"reservations": {
-				"mesa-ya.reservations.events",
-			},
-			"payments": {
-				"mesa-ya.payments.events",
-			},
-			"subscriptions": {
-				"mesa-ya.subscriptions.events",
-				// Includes plans as sub-entity (entity_subtype: 'subscription' | 'plan')
-			},
-			"auth": {
-				"mesa-ya.auth.events",
-			},
-			"owner-upgrades": {
-				"mesa-ya.owner-upgrade.events",
-			},
-		}
+		cfg.Kafka.Topics = cfg.Entities.Registry.Topics()
 	}
 
 	if len(cfg.Kafka.Brokers) == 0 {
 		cfg.Kafka.Brokers = []string{"localhost:9092"}
 	}
 
+	if err := cfg.Kafka.loadSecrets(); err != nil {
+		return Config{}, err
+	}
+
+	startOffset, startTime, err := parseStartOffset(os.Getenv("KAFKA_START_OFFSET"), time.Now())
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Kafka.StartOffset = startOffset
+	cfg.Kafka.StartTime = startTime
+
+	refreshIntervals, err := parseDurationPairs(os.Getenv("ANALYTICS_REFRESH_INTERVALS"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid ANALYTICS_REFRESH_INTERVALS: %w", err)
+	}
+	cfg.Analytics.RefreshIntervals = refreshIntervals
+	cfg.Analytics.ConfigFile = strings.TrimSpace(os.Getenv("ANALYTICS_CONFIG_FILE"))
+	cfg.Analytics.SharedCacheTTL = durationOrDefault(os.Getenv("ANALYTICS_SHARED_CACHE_TTL"), 5*time.Second)
+
 	if err := cfg.validate(); err != nil {
 		return Config{}, err
 	}
@@ -164,9 +372,93 @@ func (c Config) validate() error {
 	if _, err := urlFromString(c.REST.BaseURL); err != nil {
 		return fmt.Errorf("invalid REST_BASE_URL: %w", err)
 	}
+	switch c.Kafka.SASL.Mechanism {
+	case "":
+	case "plain", "scram-sha-256", "scram-sha-512":
+		if c.Kafka.SASL.Username == "" || c.Kafka.SASL.Password == "" {
+			return errors.New("kafka sasl username and password are required")
+		}
+	default:
+		return fmt.Errorf("unsupported KAFKA_SASL_MECHANISM %q", c.Kafka.SASL.Mechanism)
+	}
+	if c.Kafka.Discovery.Pattern != "" {
+		if _, err := regexp.Compile(c.Kafka.Discovery.Pattern); err != nil {
+			return fmt.Errorf("invalid KAFKA_TOPIC_PATTERN: %w", err)
+		}
+	}
+	switch c.Kafka.Validation.Mode {
+	case "", "off", "warn", "warning", "log", "enforce", "strict", "reject":
+	default:
+		return fmt.Errorf("unsupported KAFKA_SCHEMA_VALIDATION %q", c.Kafka.Validation.Mode)
+	}
+	if (c.Kafka.TLS.CertPEM == "") != (c.Kafka.TLS.KeyPEM == "") {
+		return errors.New("kafka tls client certificate and key must be provided together")
+	}
+	switch c.Security.Broadcast.Mode {
+	case "required", "off":
+	default:
+		return fmt.Errorf("unsupported BROADCAST_AUTH %q", c.Security.Broadcast.Mode)
+	}
+	switch c.PubSub.Driver {
+	case "kafka", "memory":
+	case "file":
+		if c.PubSub.ReplayFile == "" {
+			return errors.New("PUBSUB_REPLAY_FILE is required when PUBSUB_DRIVER=file")
+		}
+	default:
+		return fmt.Errorf("unsupported PUBSUB_DRIVER %q", c.PubSub.Driver)
+	}
+	if c.Scheduler.Timezone != "" {
+		if _, err := time.LoadLocation(c.Scheduler.Timezone); err != nil {
+			return fmt.Errorf("invalid SCHEDULER_TIMEZONE: %w", err)
+		}
+	}
+	if c.PubSub.ReplaySpeed < 0 {
+		return errors.New("PUBSUB_REPLAY_SPEED must not be negative")
+	}
+	return nil
+}
+
+// loadSecrets resolves TLS material and SASL credentials from env values or *_FILE paths.
+func (k *KafkaConfig) loadSecrets() error {
+	var err error
+	if k.TLS.CAPEM, err = envOrFile("KAFKA_TLS_CA"); err != nil {
+		return err
+	}
+	if k.TLS.CertPEM, err = envOrFile("KAFKA_TLS_CERT"); err != nil {
+		return err
+	}
+	if k.TLS.KeyPEM, err = envOrFile("KAFKA_TLS_KEY"); err != nil {
+		return err
+	}
+	if k.SASL.Username, err = envOrFile("KAFKA_SASL_USERNAME"); err != nil {
+		return err
+	}
+	if k.SASL.Password, err = envOrFile("KAFKA_SASL_PASSWORD"); err != nil {
+		return err
+	}
+	if k.TLS.CAPEM != "" || k.TLS.CertPEM != "" {
+		k.TLS.Enabled = true
+	}
 	return nil
 }
 
+// envOrFile returns the value of name, or the contents of the file referenced by name_FILE.
+func envOrFile(name string) (string, error) {
+	if value := trimQuotes(os.Getenv(name)); value != "" {
+		return strings.ReplaceAll(value, "\\n", "\n"), nil
+	}
+	path := trimQuotes(os.Getenv(name + "_FILE"))
+	if path == "" {
+		return "", nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read %s_FILE: %w", name, err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
+
 func splitEnv(raw string) []string {
 	if strings.TrimSpace(raw) == "" {
 		return nil
@@ -257,6 +549,97 @@ func parseTopics(raw string) map[string][]string {
 	return result
 }
 
+// parseStartOffset accepts earliest/latest, an RFC3339 timestamp, or a duration meaning
+// "that long ago" (e.g. 2h).
+func parseStartOffset(raw string, now time.Time) (string, time.Time, error) {
+	trimmed := strings.ToLower(strings.TrimSpace(raw))
+	switch trimmed {
+	case "", "earliest", "first", "oldest":
+		return "earliest", time.Time{}, nil
+	case "latest", "last", "newest":
+		return "latest", time.Time{}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, strings.TrimSpace(raw)); err == nil {
+		return "timestamp", at.UTC(), nil
+	}
+	if ago, err := time.ParseDuration(trimmed); err == nil && ago > 0 {
+		return "timestamp", now.Add(-ago).UTC(), nil
+	}
+	return "", time.Time{}, fmt.Errorf("invalid KAFKA_START_OFFSET %q: use earliest, latest, an RFC3339 timestamp or a duration", raw)
+}
+
+// parseActionTopics parses "action:topic" pairs separated by commas.
+func parseActionTopics(raw string) map[string]string {
+	result := make(map[string]string)
+	for _, entry := range splitEnv(raw) {
+		action, topic, ok := strings.Cut(entry, ":")
+		action = strings.ToLower(strings.TrimSpace(action))
+		topic = strings.TrimSpace(topic)
+		if !ok || action == "" || topic == "" {
+			continue
+		}
+		result[action] = topic
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// parseDurationPairs reads comma separated "key:duration" entries (e.g. "a:30s,b:0").
+func parseDurationPairs(raw string) (map[string]time.Duration, error) {
+	result := make(map[string]time.Duration)
+	for _, entry := range splitEnv(raw) {
+		key, value, ok := strings.Cut(entry, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("entry %q must be key:duration", entry)
+		}
+		duration, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("entry %q: invalid duration", entry)
+		}
+		result[key] = duration
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func boolOrDefault(raw string, fallback bool) bool {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return fallback
+	}
+	if parsed, err := strconv.ParseBool(trimmed); err == nil {
+		return parsed
+	}
+	return fallback
+}
+
+func intOrDefault(raw string, fallback int) int {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return fallback
+	}
+	if parsed, err := strconv.Atoi(trimmed); err == nil && parsed >= 0 {
+		return parsed
+	}
+	return fallback
+}
+
+func floatOrDefault(raw string, fallback float64) float64 {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return fallback
+	}
+	if parsed, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return parsed
+	}
+	return fallback
+}
+
 func durationOrDefault(raw string, fallback time.Duration) time.Duration {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSecretsFromEnvAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, []byte("-----BEGIN CERTIFICATE-----\nCA\n-----END CERTIFICATE-----\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("KAFKA_TLS_CA_FILE", caFile)
+	t.Setenv("KAFKA_TLS_CERT", `"line1\nline2"`)
+	t.Setenv("KAFKA_TLS_KEY", "")
+	t.Setenv("KAFKA_SASL_USERNAME", "svc")
+	t.Setenv("KAFKA_SASL_PASSWORD", "inline")
+	t.Setenv("KAFKA_SASL_PASSWORD_FILE", passwordFile)
+
+	var kafka KafkaConfig
+	if err := kafka.loadSecrets(); err != nil {
+		t.Fatalf("load secrets: %v", err)
+	}
+	if kafka.TLS.CAPEM != "-----BEGIN CERTIFICATE-----\nCA\n-----END CERTIFICATE-----" {
+		t.Fatalf("CA not read from file: %q", kafka.TLS.CAPEM)
+	}
+	if kafka.TLS.CertPEM != "line1\nline2" {
+		t.Fatalf("inline PEM must unescape newlines: %q", kafka.TLS.CertPEM)
+	}
+	if kafka.SASL.Username != "svc" || kafka.SASL.Password != "inline" {
+		t.Fatalf("inline values take precedence over files: %+v", kafka.SASL)
+	}
+	if !kafka.TLS.Enabled {
+		t.Fatal("providing a CA must enable TLS")
+	}
+
+	t.Setenv("KAFKA_SASL_PASSWORD", "")
+	if err := kafka.loadSecrets(); err != nil || kafka.SASL.Password != "from-file" {
+		t.Fatalf("expected password from file, got %q (%v)", kafka.SASL.Password, err)
+	}
+
+	t.Setenv("KAFKA_TLS_KEY_FILE", filepath.Join(dir, "missing.pem"))
+	if err := kafka.loadSecrets(); err == nil {
+		t.Fatal("unreadable *_FILE must fail")
+	}
+}
+
+func TestValidateSASLMechanism(t *testing.T) {
+	base := Config{}
+	base.Security.JWTSecret = "secret"
+	base.REST.BaseURL = "http://localhost:3000"
+	base.Security.Broadcast.Mode = "off"
+	base.PubSub.Driver = "kafka"
+
+	cases := []struct {
+		name      string
+		mechanism string
+		username  string
+		wantErr   bool
+	}{
+		{name: "none", mechanism: ""},
+		{name: "plain", mechanism: "plain", username: "svc"},
+		{name: "scram-256", mechanism: "scram-sha-256", username: "svc"},
+		{name: "scram-512", mechanism: "scram-sha-512", username: "svc"},
+		{name: "missing credentials", mechanism: "plain", wantErr: true},
+		{name: "unknown", mechanism: "gssapi", username: "svc", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := base
+			cfg.Kafka.SASL = KafkaSASLConfig{Mechanism: tc.mechanism, Username: tc.username}
+			if tc.username != "" {
+				cfg.Kafka.SASL.Password = "secret"
+			}
+			if err := cfg.validate(); (err != nil) != tc.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaValidationMode(t *testing.T) {
+	base := Config{}
+	base.Security.JWTSecret = "secret"
+	base.REST.BaseURL = "http://localhost:3000"
+	base.Security.Broadcast.Mode = "off"
+	base.PubSub.Driver = "kafka"
+
+	for _, mode := range []string{"off", "warn", "enforce"} {
+		cfg := base
+		cfg.Kafka.Validation.Mode = mode
+		if err := cfg.validate(); err != nil {
+			t.Fatalf("mode %q: unexpected error %v", mode, err)
+		}
+	}
+	cfg := base
+	cfg.Kafka.Validation.Mode = "enforced"
+	if err := cfg.validate(); err == nil {
+		t.Fatal("expected an error for an unknown validation mode")
+	}
+}
+
+func TestParseStartOffset(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		raw     string
+		mode    string
+		at      time.Time
+		wantErr bool
+	}{
+		{raw: "", mode: "earliest"},
+		{raw: "earliest", mode: "earliest"},
+		{raw: " Oldest ", mode: "earliest"},
+		{raw: "latest", mode: "latest"},
+		{raw: "NEWEST", mode: "latest"},
+		{raw: "2026-05-09T08:30:00-05:00", mode: "timestamp", at: time.Date(2026, 5, 9, 13, 30, 0, 0, time.UTC)},
+		{raw: "2h", mode: "timestamp", at: now.Add(-2 * time.Hour)},
+		{raw: "90m", mode: "timestamp", at: now.Add(-90 * time.Minute)},
+		{raw: "-1h", wantErr: true},
+		{raw: "yesterday", wantErr: true},
+		{raw: "2026-05-09", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			mode, at, err := parseStartOffset(tc.raw, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %s %v", mode, at)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode != tc.mode || !at.Equal(tc.at) {
+				t.Fatalf("got %s %v, want %s %v", mode, at, tc.mode, tc.at)
+			}
+		})
+	}
+}
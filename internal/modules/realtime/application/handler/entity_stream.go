@@ -96,6 +96,7 @@ func (h *EntityStreamHandler) refreshAnalytics(ctx context.Context, msg *domain.
 	if entityName == "" {
 		return
 	}
+	h.analyticsUC.ApplyLiveEvent(ctx, entityName, msg, h.broadcastUC)
 	h.analyticsUC.RefreshByEntity(ctx, entityName, h.broadcastUC)
 }
 
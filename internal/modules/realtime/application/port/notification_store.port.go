@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// StoredNotification es una notificación guardada en la bandeja de un usuario.
+type StoredNotification struct {
+	ID        string          `json:"id"`
+	Message   *domain.Message `json:"message"`
+	CreatedAt time.Time       `json:"createdAt"`
+	ReadAt    *time.Time      `json:"readAt,omitempty"`
+}
+
+// NotificationStore define el contrato de la bandeja persistente de notificaciones. Cada
+// usuario conserva solo sus últimas notificaciones (las más nuevas primero en List).
+type NotificationStore interface {
+	Append(ctx context.Context, userID string, notification StoredNotification) error
+	// List devuelve hasta limit notificaciones (0 = todas), opcionalmente solo las no leídas.
+	List(ctx context.Context, userID string, limit int, unreadOnly bool) ([]StoredNotification, error)
+	// MarkRead marca como leídas las notificaciones ids (vacío = todas) y devuelve cuántas cambiaron.
+	MarkRead(ctx context.Context, userID string, ids []string, at time.Time) (int, error)
+	UnreadCount(ctx context.Context, userID string) (int, error)
+}
+
+// QuietHours es la franja diaria (HH:MM, puede cruzar la medianoche) en la que no se envían
+// notificaciones en vivo; quedan en la bandeja como no leídas.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// NotificationPreferences son las preferencias de notificación de un usuario. MutedTopics
+// acepta patrones glob (ej. "tables.*") y MutedEntities entidades completas.
+type NotificationPreferences struct {
+	MutedTopics   []string    `json:"mutedTopics,omitempty"`
+	MutedEntities []string    `json:"mutedEntities,omitempty"`
+	QuietHours    *QuietHours `json:"quietHours,omitempty"`
+}
+
+// NotificationPreferenceStore define el contrato para persistir las preferencias por usuario.
+// Get devuelve preferencias vacías para usuarios sin preferencias guardadas.
+type NotificationPreferenceStore interface {
+	Get(ctx context.Context, userID string) (NotificationPreferences, error)
+	Save(ctx context.Context, userID string, preferences NotificationPreferences) error
+}
@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// EventPublisher define el contrato para emitir eventos originados en el cliente hacia el
+// backend (Kafka). key agrupa los eventos que deben conservar el orden (misma partición).
+type EventPublisher interface {
+	Publish(ctx context.Context, topic, key string, msg *domain.Message) error
+}
@@ -6,9 +6,49 @@ import (
 	"mesaYaWs/internal/modules/realtime/domain"
 )
 
-// PubSubPort define el contrato para consumir eventos externos (Kafka).
+// PubSubPort define el contrato para consumir eventos externos (Kafka, memoria o archivos de replay).
+// Consume bloquea hasta que ctx se cancela; el contexto entregado al handler puede venir
+// marcado (p. ej. catch-up) por la implementación.
 type PubSubPort interface {
-	Consume(ctx context.Context, topic string, handler func(*domain.Message) error) error
+	Consume(ctx context.Context, topic string, handler func(context.Context, *domain.Message) error) error
+}
+
+type sourceTopicKey struct{}
+
+// WithSourceTopic marca ctx con el tópico externo (p. ej. Kafka) del que proviene el mensaje.
+func WithSourceTopic(ctx context.Context, topic string) context.Context {
+	return context.WithValue(ctx, sourceTopicKey{}, topic)
+}
+
+// SourceTopic devuelve el tópico externo marcado con WithSourceTopic, o "" si no existe.
+func SourceTopic(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	topic, _ := ctx.Value(sourceTopicKey{}).(string)
+	return topic
+}
+
+type sourcePositionKey struct{}
+
+// SourcePosition identifica un mensaje dentro del tópico externo (partición y offset de Kafka).
+type SourcePosition struct {
+	Partition int
+	Offset    int64
+}
+
+// WithSourcePosition marca ctx con la posición del mensaje en el tópico externo.
+func WithSourcePosition(ctx context.Context, position SourcePosition) context.Context {
+	return context.WithValue(ctx, sourcePositionKey{}, position)
+}
+
+// SourcePositionFrom devuelve la posición marcada con WithSourcePosition; ok es false si no existe.
+func SourcePositionFrom(ctx context.Context) (SourcePosition, bool) {
+	if ctx == nil {
+		return SourcePosition{}, false
+	}
+	position, ok := ctx.Value(sourcePositionKey{}).(SourcePosition)
+	return position, ok
 }
 
 // Broadcaster define el contrato para enviar mensajes a los clientes WebSocket.
@@ -16,6 +56,26 @@ type Broadcaster interface {
 	Broadcast(ctx context.Context, msg *domain.Message)
 }
 
+// BroadcastTarget restringe un broadcast a clientes concretos. Cada lista no vacía filtra
+// (coincidencia con cualquiera de sus valores); listas vacías no restringen.
+type BroadcastTarget struct {
+	UserIDs       []string `json:"userIds,omitempty"`
+	SessionIDs    []string `json:"sessionIds,omitempty"`
+	SectionIDs    []string `json:"sectionIds,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	RestaurantIDs []string `json:"restaurantIds,omitempty"`
+}
+
+// IsZero indica si el target no restringe a ningún cliente.
+func (t BroadcastTarget) IsZero() bool {
+	return len(t.UserIDs) == 0 && len(t.SessionIDs) == 0 && len(t.SectionIDs) == 0 && len(t.Roles) == 0 && len(t.RestaurantIDs) == 0
+}
+
+// TargetedBroadcaster envía un mensaje solo a los clientes del target y devuelve a cuántos llegó.
+type TargetedBroadcaster interface {
+	BroadcastTo(ctx context.Context, msg *domain.Message, target BroadcastTarget) int
+}
+
 // TopicHandler define la interfaz que deben implementar los handlers registrados por tópico.
 type TopicHandler interface {
 	Topic() string
@@ -0,0 +1,9 @@
+package port
+
+import "context"
+
+// RestaurantOwnershipResolver lists the restaurants owned by the user of a token. It backs the
+// ownership checks of restaurant-scoped analytics.
+type RestaurantOwnershipResolver interface {
+	OwnedRestaurants(ctx context.Context, token string) ([]string, error)
+}
@@ -0,0 +1,38 @@
+package port
+
+import (
+	"errors"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+var (
+	// ErrScheduleNotFound indica que el job no existe o ya terminó.
+	ErrScheduleNotFound = errors.New("scheduled broadcast not found")
+	// ErrInvalidSchedule indica un deliverAt en el pasado o una expresión cron inválida.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+)
+
+// ScheduledBroadcast es un broadcast diferido: se envía una vez en DeliverAt o, si Cron está
+// definido, en cada ocurrencia de la expresión (formato de 5 campos o @every <duración>).
+type ScheduledBroadcast struct {
+	ID        string          `json:"id"`
+	Message   *domain.Message `json:"message"`
+	Target    BroadcastTarget `json:"target,omitempty"`
+	DeliverAt time.Time       `json:"deliverAt,omitempty"`
+	Cron      string          `json:"cron,omitempty"`
+	NextRun   time.Time       `json:"nextRun"`
+	LastRun   time.Time       `json:"lastRun,omitempty"`
+	Runs      int             `json:"runs"`
+	CreatedBy string          `json:"createdBy,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// BroadcastScheduler define el contrato para programar, listar y cancelar broadcasts diferidos.
+type BroadcastScheduler interface {
+	Schedule(job ScheduledBroadcast) (ScheduledBroadcast, error)
+	List() []ScheduledBroadcast
+	Get(id string) (ScheduledBroadcast, bool)
+	Cancel(id string) error
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,8 +14,11 @@ import (
 	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
 	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
 )
 
+const analyticsAdminRole = "ADMIN"
+
 var (
 	// ErrAnalyticsMissingIdentifier indicates a required identifier was not provided.
 	ErrAnalyticsMissingIdentifier = errors.New("missing analytics identifier")
@@ -31,6 +35,16 @@ type AnalyticsEndpointConfig struct {
 	IdentifierAsQuery  bool
 	QueryParams        []string
 	RequireToken       bool
+	// AllowedRoles restricts the endpoint to tokens with one of these roles (empty = any).
+	AllowedRoles []string
+	// OwnershipParam names the identifier or query param holding a restaurant id that non-admin
+	// callers must own.
+	OwnershipParam string
+	// Live serves the endpoint from counters fed by Kafka events instead of the REST API.
+	Live *LiveCounterConfig
+	// RefreshInterval re-fetches active sessions periodically (time-based KPIs such as
+	// "reservations today"). Zero relies on entity events only.
+	RefreshInterval time.Duration
 }
 
 // BuildPath resolves the HTTP path for the configured endpoint.
@@ -73,6 +87,31 @@ func (cfg AnalyticsEndpointConfig) RequestFromValues(values url.Values) domain.A
 	return cfg.SanitizeRequest(req)
 }
 
+// fetchQuery returns the REST query for a sanitized request, adding the identifier when the
+// endpoint expects it as a query parameter.
+func (cfg AnalyticsEndpointConfig) fetchQuery(sanitized domain.AnalyticsRequest) map[string]string {
+	query := sanitized.Clone().Query
+	if cfg.RequiresIdentifier && cfg.IdentifierAsQuery {
+		if query == nil {
+			query = make(map[string]string)
+		}
+		query[cfg.IdentifierParam] = sanitized.Identifier
+	}
+	return query
+}
+
+// ownedValue returns the restaurant id the request is scoped to, if the endpoint checks ownership.
+func (cfg AnalyticsEndpointConfig) ownedValue(req domain.AnalyticsRequest) string {
+	switch {
+	case cfg.OwnershipParam == "":
+		return ""
+	case cfg.OwnershipParam == cfg.IdentifierParam:
+		return strings.TrimSpace(req.Identifier)
+	default:
+		return strings.TrimSpace(req.Query[cfg.OwnershipParam])
+	}
+}
+
 // SanitizeRequest filters the provided request to the allowed parameters for the endpoint.
 func (cfg AnalyticsEndpointConfig) SanitizeRequest(req domain.AnalyticsRequest) domain.AnalyticsRequest {
 	sanitized := domain.AnalyticsRequest{Identifier: strings.TrimSpace(req.Identifier)}
@@ -109,9 +148,12 @@ func (cfg AnalyticsEndpointConfig) SanitizeRequest(req domain.AnalyticsRequest)
 type AnalyticsUseCase struct {
 	validator auth.TokenValidator
 	fetcher   port.AnalyticsFetcher
-	endpoints map[string]AnalyticsEndpointConfig
+	registry  *AnalyticsRegistry
 	mu        sync.RWMutex
-	sessions  map[string]*analyticsSessionEntry
+	sessions  map[string]analyticsSessionSet
+	shared    *analyticsFetchGroup
+	ownership port.RestaurantOwnershipResolver
+	live      *liveAnalytics
 }
 
 // AnalyticsConnectOutput captures the data needed to initialise an analytics websocket session.
@@ -122,22 +164,70 @@ type AnalyticsConnectOutput struct {
 	Config  AnalyticsEndpointConfig
 }
 
-// NewAnalyticsUseCase builds a new analytics use case with the default endpoint registry.
-func NewAnalyticsUseCase(validator auth.TokenValidator, fetcher port.AnalyticsFetcher) *AnalyticsUseCase {
+// NewAnalyticsUseCase builds a new analytics use case with the default endpoint registry,
+// resolving event entities with entityRegistry.
+func NewAnalyticsUseCase(validator auth.TokenValidator, fetcher port.AnalyticsFetcher, entityRegistry *entities.Registry) *AnalyticsUseCase {
 	return &AnalyticsUseCase{
 		validator: validator,
 		fetcher:   fetcher,
-		endpoints: defaultAnalyticsEndpoints(),
-		sessions:  make(map[string]*analyticsSessionEntry),
+		registry:  DefaultAnalyticsRegistry(entityRegistry),
+		sessions:  make(map[string]analyticsSessionSet),
+		shared:    newAnalyticsFetchGroup(defaultAnalyticsSharedTTL),
+		live:      newLiveAnalytics(),
 	}
 }
 
+// SetSharedFetchTTL sets how long a fetched payload is reused by sessions with the same key,
+// request and audience. Zero only collapses concurrent fetches.
+func (uc *AnalyticsUseCase) SetSharedFetchTTL(ttl time.Duration) {
+	uc.shared.setTTL(ttl)
+}
+
+// SetOwnershipResolver sets how restaurant ownership is checked for endpoints with an
+// OwnershipParam. Without a resolver only admins may request those restaurants.
+func (uc *AnalyticsUseCase) SetOwnershipResolver(resolver port.RestaurantOwnershipResolver) {
+	uc.ownership = resolver
+}
+
+// SetRegistry replaces the endpoint registry after validating it. Call it before serving
+// sessions.
+func (uc *AnalyticsUseCase) SetRegistry(registry *AnalyticsRegistry) error {
+	if registry == nil {
+		return errors.New("analytics registry is nil")
+	}
+	if err := registry.Validate(); err != nil {
+		return err
+	}
+	uc.registry = registry.clone()
+	return nil
+}
+
+// Registry returns the resolved endpoint registry.
+func (uc *AnalyticsUseCase) Registry() *AnalyticsRegistry {
+	return uc.registry
+}
+
+// Key resolves the endpoint key for the scope and entity of /ws/analytics/:scope/:entity.
+func (uc *AnalyticsUseCase) Key(scope, entity string) string {
+	return uc.registry.Key(scope, entity)
+}
+
 // Endpoint retrieves the configuration for the given analytics key.
 func (uc *AnalyticsUseCase) Endpoint(key string) (AnalyticsEndpointConfig, bool) {
-	cfg, ok := uc.endpoints[strings.TrimSpace(key)]
+	cfg, ok := uc.registry.Endpoints[strings.TrimSpace(key)]
 	return cfg, ok
 }
 
+// Authenticate validates token for connections that pick their analytics keys later; an empty
+// token is an anonymous connection (nil claims).
+func (uc *AnalyticsUseCase) Authenticate(token string) (*auth.Claims, error) {
+	trimmed := strings.TrimSpace(token)
+	if trimmed == "" {
+		return nil, nil
+	}
+	return uc.validator.Validate(trimmed)
+}
+
 // Connect validates the token (when required) and fetches the initial analytics payload.
 func (uc *AnalyticsUseCase) Connect(ctx context.Context, key, token string, request domain.AnalyticsRequest) (*AnalyticsConnectOutput, error) {
 	cfg, ok := uc.Endpoint(key)
@@ -164,22 +254,13 @@ func (uc *AnalyticsUseCase) Connect(ctx context.Context, key, token string, requ
 		}
 	}
 
-	path, err := cfg.BuildPath(sanitized.Identifier)
-	if err != nil {
-		slog.Warn("analytics connect path build failed", slog.String("key", key), slog.Any("error", err))
+	if err := uc.authorize(ctx, cfg, trimmedToken, claims, sanitized); err != nil {
+		slog.Warn("analytics connect forbidden", slog.String("key", key), slog.Any("error", err))
 		return nil, err
 	}
 
-	query := sanitized.Query
-	if cfg.RequiresIdentifier && cfg.IdentifierAsQuery {
-		if query == nil {
-			query = make(map[string]string)
-		}
-		query[cfg.IdentifierParam] = sanitized.Identifier
-	}
-
-	slog.Info("analytics connect fetch", slog.String("key", key), slog.String("entity", cfg.Entity), slog.Any("query", query))
-	snapshot, err := uc.fetcher.Fetch(ctx, trimmedToken, path, query)
+	slog.Info("analytics connect fetch", slog.String("key", key), slog.String("entity", cfg.Entity), slog.Any("query", cfg.fetchQuery(sanitized)))
+	snapshot, err := uc.load(ctx, cfg, analyticsGroupKey(cfg.Key, analyticsAudience(claims), sanitized), trimmedToken, sanitized)
 	if err != nil {
 		slog.Warn("analytics connect fetch failed", slog.String("key", key), slog.Any("error", err))
 		return nil, err
@@ -208,23 +289,22 @@ func (uc *AnalyticsUseCase) HandleCommand(ctx context.Context, key, token string
 
 	updated := mergeAnalyticsRequest(base, command)
 	sanitized := cfg.SanitizeRequest(updated)
+	trimmedToken := strings.TrimSpace(token)
 
-	path, err := cfg.BuildPath(sanitized.Identifier)
-	if err != nil {
-		return nil, base, err
-	}
-
-	query := sanitized.Query
-	if cfg.RequiresIdentifier && cfg.IdentifierAsQuery {
-		if query == nil {
-			query = make(map[string]string)
+	// Roles were checked on connect; a different restaurant needs a new ownership check.
+	if owned := cfg.ownedValue(sanitized); owned != "" && owned != cfg.ownedValue(cfg.SanitizeRequest(base)) {
+		claims, err := uc.validator.Validate(trimmedToken)
+		if err != nil {
+			return nil, base, err
+		}
+		if err := uc.authorize(ctx, cfg, trimmedToken, claims, sanitized); err != nil {
+			slog.Warn("analytics command forbidden", slog.String("key", key), slog.Any("error", err))
+			return nil, base, err
 		}
-		query[cfg.IdentifierParam] = sanitized.Identifier
 	}
 
-	trimmedToken := strings.TrimSpace(token)
-	slog.Debug("analytics command fetch", slog.String("key", key), slog.Any("query", query))
-	snapshot, err := uc.fetcher.Fetch(ctx, trimmedToken, path, query)
+	slog.Debug("analytics command fetch", slog.String("key", key), slog.Any("query", cfg.fetchQuery(sanitized)))
+	snapshot, err := uc.load(ctx, cfg, "", trimmedToken, sanitized)
 	if err != nil {
 		return nil, base, err
 	}
@@ -237,90 +317,67 @@ func (uc *AnalyticsUseCase) HandleCommand(ctx context.Context, key, token string
 	return message, sanitized.Clone(), nil
 }
 
-// AnalyticsKey composes the canonical key for an analytics websocket based on scope and entity name.
-func AnalyticsKey(scope, entity string) string {
-	normalizedScope := normalizeAnalyticsScope(scope)
-	normalizedEntity := normalizeAnalyticsEntity(normalizedScope, entity)
-	if normalizedScope == "" || normalizedEntity == "" {
-		return ""
+// load returns the payload of cfg for a sanitized request: from the live counters for live
+// endpoints, otherwise from the REST API, shared with the sessions of groupKey when set.
+func (uc *AnalyticsUseCase) load(ctx context.Context, cfg AnalyticsEndpointConfig, groupKey, token string, request domain.AnalyticsRequest) (*domain.AnalyticsSnapshot, error) {
+	path, err := cfg.BuildPath(request.Identifier)
+	if err != nil {
+		return nil, err
 	}
-	return "analytics-" + normalizedScope + "-" + normalizedEntity
-}
-
-func normalizeAnalyticsScope(raw string) string {
-	switch strings.ToLower(strings.TrimSpace(raw)) {
-	case "public", "pub":
-		return "public"
-	case "restaurant", "rest", "owner":
-		return "restaurant"
-	case "admin", "administrator", "adm":
-		return "admin"
-	case "auth":
-		return "admin"
+	query := cfg.fetchQuery(request)
+	fetch := func(fetchCtx context.Context) (*domain.AnalyticsSnapshot, error) {
+		return uc.fetcher.Fetch(fetchCtx, token, path, query)
+	}
+	switch {
+	case cfg.Live != nil:
+		seed := func(seedCtx context.Context) ([]map[string]any, error) {
+			return fetchLiveItems(seedCtx, query, func(pageCtx context.Context, pageQuery map[string]string) (*domain.AnalyticsSnapshot, error) {
+				return uc.fetcher.Fetch(pageCtx, token, path, pageQuery)
+			})
+		}
+		return uc.live.snapshot(ctx, cfg, request, seed)
+	case groupKey != "":
+		return uc.shared.do(ctx, groupKey, fetch)
 	default:
-		return strings.TrimSpace(strings.ToLower(raw))
+		return fetch(ctx)
 	}
 }
 
-func normalizeAnalyticsEntity(scope, raw string) string {
-	trimmed := strings.ToLower(strings.TrimSpace(raw))
-	trimmed = strings.ReplaceAll(trimmed, "_", "-")
-
-	aliases := map[string]map[string]string{
-		"public": {
-			"user":         "users",
-			"users":        "users",
-			"public-user":  "users",
-			"public-users": "users",
-			"dish":         "dishes",
-			"dishes":       "dishes",
-			"menu":         "menus",
-			"menus":        "menus",
-		},
-		"restaurant": {
-			"user":             "users",
-			"users":            "users",
-			"restaurant":       "users",
-			"restaurant-user":  "users",
-			"restaurant-users": "users",
-		},
-		"admin": {
-			"auth":               "users",
-			"auth-user":          "users",
-			"auth-users":         "users",
-			"user":               "users",
-			"users":              "users",
-			"restaurant":         "restaurants",
-			"restaurants":        "restaurants",
-			"section":            "sections",
-			"sections":           "sections",
-			"table":              "tables",
-			"tables":             "tables",
-			"image":              "images",
-			"images":             "images",
-			"object":             "objects",
-			"objects":            "objects",
-			"subscription":       "subscriptions",
-			"subscriptions":      "subscriptions",
-			"subscription-plan":  "subscription-plans",
-			"subscription-plans": "subscription-plans",
-			"subscriptionplan":   "subscription-plans",
-			"subscriptionplans":  "subscription-plans",
-			"reservation":        "reservations",
-			"reservations":       "reservations",
-			"review":             "reviews",
-			"reviews":            "reviews",
-			"payment":            "payments",
-			"payments":           "payments",
-		},
+// authorize enforces the endpoint roles and, for non-admin callers, the ownership of the
+// requested restaurant.
+func (uc *AnalyticsUseCase) authorize(ctx context.Context, cfg AnalyticsEndpointConfig, token string, claims *auth.Claims, request domain.AnalyticsRequest) error {
+	if len(cfg.AllowedRoles) > 0 && !hasAnalyticsRole(claims, cfg.AllowedRoles...) {
+		return fmt.Errorf("%w: role not allowed for %s", port.ErrAnalyticsForbidden, cfg.Key)
+	}
+	owned := cfg.ownedValue(request)
+	if owned == "" || hasAnalyticsRole(claims, analyticsAdminRole) {
+		return nil
 	}
+	if claims == nil || uc.ownership == nil {
+		return fmt.Errorf("%w: restaurant %s not owned", port.ErrAnalyticsForbidden, owned)
+	}
+	restaurants, err := uc.ownership.OwnedRestaurants(ctx, token)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(restaurants, owned) {
+		return fmt.Errorf("%w: restaurant %s not owned", port.ErrAnalyticsForbidden, owned)
+	}
+	return nil
+}
 
-	if scopeAliases, ok := aliases[scope]; ok {
-		if resolved, ok := scopeAliases[trimmed]; ok {
-			return resolved
+func hasAnalyticsRole(claims *auth.Claims, roles ...string) bool {
+	if claims == nil {
+		return false
+	}
+	for _, role := range claims.Roles {
+		for _, allowed := range roles {
+			if strings.EqualFold(strings.TrimSpace(role), allowed) {
+				return true
+			}
 		}
 	}
-	return trimmed
+	return false
 }
 
 func mergeAnalyticsRequest(base domain.AnalyticsRequest, command domain.AnalyticsCommand) domain.AnalyticsRequest {
@@ -346,145 +403,54 @@ func mergeAnalyticsRequest(base domain.AnalyticsRequest, command domain.Analytic
 	return merged
 }
 
-func defaultAnalyticsEndpoints() map[string]AnalyticsEndpointConfig {
-	entries := []AnalyticsEndpointConfig{
-		{
-			Key:          "analytics-public-users",
-			Scope:        "public",
-			Entity:       "analytics-public-users",
-			PathTemplate: "/api/v1/users/analytics",
-			QueryParams:  []string{"startDate"},
-		},
-		{
-			Key:          "analytics-public-dishes",
-			Scope:        "public",
-			Entity:       "analytics-public-dishes",
-			PathTemplate: "/api/v1/dishes/analytics",
-			QueryParams:  []string{"startDate"},
-		},
-		{
-			Key:          "analytics-public-menus",
-			Scope:        "public",
-			Entity:       "analytics-public-menus",
-			PathTemplate: "/api/v1/menus/analytics",
-			QueryParams:  []string{"startDate"},
-		},
-		{
-			Key:                "analytics-restaurant-users",
-			Scope:              "restaurant",
-			Entity:             "analytics-restaurant-users",
-			PathTemplate:       "/api/v1/users/analytics",
-			RequiresIdentifier: true,
-			IdentifierParam:    "restaurantId",
-			IdentifierAsQuery:  true,
-			QueryParams:        []string{"startDate"},
-			RequireToken:       true,
-		},
-		{
-			Key:          "analytics-admin-users",
-			Scope:        "admin",
-			Entity:       "analytics-admin-users",
-			PathTemplate: "/api/v1/users/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-restaurants",
-			Scope:        "admin",
-			Entity:       "analytics-admin-restaurants",
-			PathTemplate: "/api/v1/restaurants/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-sections",
-			Scope:        "admin",
-			Entity:       "analytics-admin-sections",
-			PathTemplate: "/api/v1/sections/analytics",
-			QueryParams:  []string{"restaurantId", "startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-tables",
-			Scope:        "admin",
-			Entity:       "analytics-admin-tables",
-			PathTemplate: "/api/v1/tables/analytics",
-			QueryParams:  []string{"sectionId", "restaurantId", "startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-images",
-			Scope:        "admin",
-			Entity:       "analytics-admin-images",
-			PathTemplate: "/api/v1/images/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-objects",
-			Scope:        "admin",
-			Entity:       "analytics-admin-objects",
-			PathTemplate: "/api/v1/objects/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-subscriptions",
-			Scope:        "admin",
-			Entity:       "analytics-admin-subscriptions",
-			PathTemplate: "/api/v1/subscriptions/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-subscription-plans",
-			Scope:        "admin",
-			Entity:       "analytics-admin-subscription-plans",
-			PathTemplate: "/api/v1/subscription-plans/analytics",
-			QueryParams:  []string{"startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-reservations",
-			Scope:        "admin",
-			Entity:       "analytics-admin-reservations",
-			PathTemplate: "/api/v1/reservations/analytics",
-			QueryParams:  []string{"restaurantId", "startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-reviews",
-			Scope:        "admin",
-			Entity:       "analytics-admin-reviews",
-			PathTemplate: "/api/v1/reviews/analytics/stats",
-			QueryParams:  []string{"restaurantId", "startDate"},
-			RequireToken: true,
-		},
-		{
-			Key:          "analytics-admin-payments",
-			Scope:        "admin",
-			Entity:       "analytics-admin-payments",
-			PathTemplate: "/api/v1/payments/analytics",
-			QueryParams:  []string{"restaurantId", "startDate"},
-			RequireToken: true,
-		},
+// analyticsSessionSet holds the analytics keys watched by one websocket session, by key.
+type analyticsSessionSet map[string]*analyticsSessionEntry
+
+type analyticsSessionEntry struct {
+	sessionID string
+	key       string
+	token     string
+	request   domain.AnalyticsRequest
+	// audience groups sessions allowed to share a payload (see analyticsAudience).
+	audience string
+	// refreshedAt is the last time the session payload was fetched (connect, command, event
+	// or periodic refresh).
+	refreshedAt time.Time
+}
+
+func (e *analyticsSessionEntry) clone() *analyticsSessionEntry {
+	return &analyticsSessionEntry{
+		sessionID:   e.sessionID,
+		key:         e.key,
+		token:       e.token,
+		request:     e.request.Clone(),
+		audience:    e.audience,
+		refreshedAt: e.refreshedAt,
 	}
+}
 
-	registry := make(map[string]AnalyticsEndpointConfig, len(entries))
-	for _, entry := range entries {
-		registry[entry.Key] = entry
+// analyticsAudience identifies who may share an analytics payload: every admin sees the same
+// dashboards, other users only share with their own sessions.
+func analyticsAudience(claims *auth.Claims) string {
+	if claims == nil {
+		return "public"
+	}
+	if hasAnalyticsRole(claims, analyticsAdminRole) {
+		return "admin"
 	}
-	return registry
+	return "user:" + strings.TrimSpace(claims.Subject)
 }
 
-type analyticsSessionEntry struct {
-	key     string
-	token   string
-	request domain.AnalyticsRequest
+func analyticsGroupKey(key, audience string, request domain.AnalyticsRequest) string {
+	return key + "|" + audience + "|" + request.CanonicalKey()
 }
 
+// analyticsRefreshTick is how often sessions are checked against their RefreshInterval.
+const analyticsRefreshTick = time.Second
+
 // RegisterSession stores the analytics request associated with an active websocket session.
-func (uc *AnalyticsUseCase) RegisterSession(sessionID, key, token string, request domain.AnalyticsRequest) {
+// claims (nil for anonymous sessions) decide which sessions may share fetched payloads.
+func (uc *AnalyticsUseCase) RegisterSession(sessionID, key, token string, claims *auth.Claims, request domain.AnalyticsRequest) {
 	sessionID = strings.TrimSpace(sessionID)
 	if sessionID == "" {
 		return
@@ -495,10 +461,22 @@ func (uc *AnalyticsUseCase) RegisterSession(sessionID, key, token string, reques
 	}
 	sanitized := cfg.SanitizeRequest(request).Clone()
 	uc.mu.Lock()
-	uc.sessions[sessionID] = &analyticsSessionEntry{
-		key:     cfg.Key,
-		token:   strings.TrimSpace(token),
-		request: sanitized,
+	set, ok := uc.sessions[sessionID]
+	if !ok {
+		set = make(analyticsSessionSet)
+		uc.sessions[sessionID] = set
+	}
+	previous := set[cfg.Key]
+	set[cfg.Key] = &analyticsSessionEntry{
+		sessionID:   sessionID,
+		key:         cfg.Key,
+		token:       strings.TrimSpace(token),
+		request:     sanitized,
+		audience:    analyticsAudience(claims),
+		refreshedAt: time.Now(),
+	}
+	if previous != nil {
+		uc.releaseLiveLocked(previous)
 	}
 	uc.mu.Unlock()
 }
@@ -515,131 +493,303 @@ func (uc *AnalyticsUseCase) UpdateSession(sessionID, key, token string, request
 	}
 	sanitized := cfg.SanitizeRequest(request).Clone()
 	uc.mu.Lock()
-	if entry, ok := uc.sessions[sessionID]; ok {
-		entry.key = cfg.Key
+	set, ok := uc.sessions[sessionID]
+	if !ok {
+		set = make(analyticsSessionSet)
+		uc.sessions[sessionID] = set
+	}
+	if entry, ok := set[cfg.Key]; ok {
+		previous := entry.clone()
 		entry.token = strings.TrimSpace(token)
 		entry.request = sanitized
+		entry.refreshedAt = time.Now()
+		uc.releaseLiveLocked(previous)
 	} else {
-		uc.sessions[sessionID] = &analyticsSessionEntry{
-			key:     cfg.Key,
-			token:   strings.TrimSpace(token),
-			request: sanitized,
+		set[cfg.Key] = &analyticsSessionEntry{
+			sessionID:   sessionID,
+			key:         cfg.Key,
+			token:       strings.TrimSpace(token),
+			request:     sanitized,
+			audience:    "session:" + sessionID,
+			refreshedAt: time.Now(),
 		}
 	}
 	uc.mu.Unlock()
 }
 
-// UnregisterSession removes the stored state for a websocket session.
+// UnregisterSession removes the stored state of every key watched by a websocket session.
 func (uc *AnalyticsUseCase) UnregisterSession(sessionID string) {
 	sessionID = strings.TrimSpace(sessionID)
 	if sessionID == "" {
 		return
 	}
 	uc.mu.Lock()
-	delete(uc.sessions, sessionID)
+	if set, ok := uc.sessions[sessionID]; ok {
+		delete(uc.sessions, sessionID)
+		for _, entry := range set {
+			uc.releaseLiveLocked(entry)
+		}
+	}
 	uc.mu.Unlock()
 }
 
-// RefreshByEntity refreshes analytics dashboards that depend on the provided entity changes.
-func (uc *AnalyticsUseCase) RefreshByEntity(ctx context.Context, entity string, broadcaster *BroadcastUseCase) {
+// UnwatchSession stops refreshing one analytics key of a websocket session.
+func (uc *AnalyticsUseCase) UnwatchSession(sessionID, key string) bool {
+	sessionID = strings.TrimSpace(sessionID)
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	set, ok := uc.sessions[sessionID]
+	if !ok {
+		return false
+	}
+	entry, ok := set[strings.TrimSpace(key)]
+	if !ok {
+		return false
+	}
+	delete(set, entry.key)
+	if len(set) == 0 {
+		delete(uc.sessions, sessionID)
+	}
+	uc.releaseLiveLocked(entry)
+	return true
+}
+
+// collectSessions returns copies of the session entries matching keep.
+func (uc *AnalyticsUseCase) collectSessions(keep func(*analyticsSessionEntry) bool) []*analyticsSessionEntry {
+	uc.mu.RLock()
+	defer uc.mu.RUnlock()
+	var entries []*analyticsSessionEntry
+	for _, set := range uc.sessions {
+		for _, entry := range set {
+			if keep(entry) {
+				entries = append(entries, entry.clone())
+			}
+		}
+	}
+	return entries
+}
+
+// releaseLiveLocked drops the live counters entry was watching when no other session watches
+// them. uc.mu must be held.
+func (uc *AnalyticsUseCase) releaseLiveLocked(entry *analyticsSessionEntry) {
+	cfg, ok := uc.Endpoint(entry.key)
+	if !ok || cfg.Live == nil {
+		return
+	}
+	restaurant := cfg.liveRestaurant(entry.request)
+	for _, set := range uc.sessions {
+		if other, ok := set[cfg.Key]; ok && cfg.liveRestaurant(other.request) == restaurant {
+			return
+		}
+	}
+	uc.live.release(cfg.Key, restaurant)
+}
+
+// ApplyLiveEvent feeds an entity event to the live counters and pushes the updated snapshot
+// to the sessions watching them, without calling the REST API. During catch-up only the
+// counters are updated.
+func (uc *AnalyticsUseCase) ApplyLiveEvent(ctx context.Context, entity string, msg *domain.Message, broadcaster *BroadcastUseCase) {
 	if broadcaster == nil {
 		return
 	}
-	canonical := normalizeAnalyticsDependencyEntity(entity)
+	canonical := uc.registry.EventEntity(entity)
 	if canonical == "" {
 		return
 	}
-	keys := analyticsDependencies[canonical]
-	if len(keys) == 0 {
+	changed := uc.live.apply(canonical, msg)
+	if len(changed) == 0 || IsCatchUp(ctx) {
 		return
 	}
-	for _, key := range keys {
-		uc.refreshByKey(ctx, key, broadcaster)
+	sessions := uc.collectSessions(func(entry *analyticsSessionEntry) bool {
+		cfg, ok := uc.registry.Endpoints[entry.key]
+		return ok && cfg.Live != nil && slices.Contains(changed, livePartitionKey(cfg.Key, cfg.liveRestaurant(entry.request)))
+	})
+	uc.refreshSessions(ctx, sessions, broadcaster)
+}
+
+// SetRefreshInterval overrides the periodic refresh of an endpoint. Call it before serving
+// sessions; it reports false for unknown keys.
+func (uc *AnalyticsUseCase) SetRefreshInterval(key string, interval time.Duration) bool {
+	cfg, ok := uc.Endpoint(key)
+	if !ok {
+		return false
+	}
+	if interval < 0 {
+		interval = 0
 	}
+	cfg.RefreshInterval = interval
+	uc.registry.Endpoints[cfg.Key] = cfg
+	return true
 }
 
-// RefreshAll refreshes all tracked analytics sessions.
-func (uc *AnalyticsUseCase) RefreshAll(ctx context.Context, broadcaster *BroadcastUseCase) {
+// StartPeriodicRefresh refreshes the sessions of endpoints with a RefreshInterval until ctx is
+// cancelled. Sessions refreshed by events (or commands) within the interval are skipped.
+func (uc *AnalyticsUseCase) StartPeriodicRefresh(ctx context.Context, broadcaster *BroadcastUseCase) {
 	if broadcaster == nil {
 		return
 	}
-	uc.mu.RLock()
-	snapshot := make(map[string]*analyticsSessionEntry, len(uc.sessions))
-	for sessionID, entry := range uc.sessions {
-		snapshot[sessionID] = &analyticsSessionEntry{
-			key:     entry.key,
-			token:   entry.token,
-			request: entry.request.Clone(),
+	periodic := 0
+	for _, cfg := range uc.registry.Endpoints {
+		if cfg.RefreshInterval > 0 {
+			periodic++
 		}
 	}
-	uc.mu.RUnlock()
-
-	for sessionID, entry := range snapshot {
-		uc.refreshSession(ctx, sessionID, entry, broadcaster)
+	if periodic == 0 {
+		return
+	}
+	slog.Info("analytics periodic refresh started", slog.Int("endpoints", periodic))
+	ticker := time.NewTicker(analyticsRefreshTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			uc.refreshDue(ctx, now, broadcaster)
+		}
 	}
 }
 
-func (uc *AnalyticsUseCase) refreshByKey(ctx context.Context, key string, broadcaster *BroadcastUseCase) {
-	uc.mu.RLock()
-	snapshot := make(map[string]*analyticsSessionEntry)
-	for sessionID, entry := range uc.sessions {
-		if strings.EqualFold(entry.key, key) {
-			snapshot[sessionID] = &analyticsSessionEntry{
-				key:     entry.key,
-				token:   entry.token,
-				request: entry.request.Clone(),
+// refreshDue refreshes the sessions whose last fetch is older than their endpoint interval.
+// The attempt is recorded up front so a failing REST call is retried one interval later.
+func (uc *AnalyticsUseCase) refreshDue(ctx context.Context, now time.Time, broadcaster *BroadcastUseCase) {
+	uc.mu.Lock()
+	var due []*analyticsSessionEntry
+	for _, set := range uc.sessions {
+		for _, entry := range set {
+			cfg, ok := uc.registry.Endpoints[entry.key]
+			if !ok || cfg.RefreshInterval <= 0 || now.Sub(entry.refreshedAt) < cfg.RefreshInterval {
+				continue
 			}
+			entry.refreshedAt = now
+			due = append(due, entry.clone())
 		}
 	}
-	uc.mu.RUnlock()
+	uc.mu.Unlock()
 
-	if len(snapshot) == 0 {
+	uc.refreshSessions(ctx, due, broadcaster)
+}
+
+// RefreshByEntity refreshes analytics dashboards that depend on the provided entity changes.
+// During catch-up the shared cache is only invalidated: sessions are refreshed by the next
+// live event or periodic refresh.
+func (uc *AnalyticsUseCase) RefreshByEntity(ctx context.Context, entity string, broadcaster *BroadcastUseCase) {
+	if broadcaster == nil {
 		return
 	}
-
-	for sessionID, entry := range snapshot {
-		uc.refreshSession(ctx, sessionID, entry, broadcaster)
+	canonical := uc.registry.EventEntity(entity)
+	if canonical == "" {
+		return
+	}
+	keys := uc.registry.Dependencies[canonical]
+	if len(keys) == 0 {
+		return
+	}
+	for _, key := range keys {
+		// The entity changed: cached payloads of dependent dashboards are stale.
+		uc.shared.invalidate(key + "|")
+		if IsCatchUp(ctx) {
+			continue
+		}
+		uc.refreshByKey(ctx, key, broadcaster)
 	}
 }
 
-func (uc *AnalyticsUseCase) refreshSession(ctx context.Context, sessionID string, entry *analyticsSessionEntry, broadcaster *BroadcastUseCase) {
-	cfg, ok := uc.Endpoint(entry.key)
-	if !ok {
+// RefreshAll refreshes all tracked analytics sessions.
+func (uc *AnalyticsUseCase) RefreshAll(ctx context.Context, broadcaster *BroadcastUseCase) {
+	if broadcaster == nil {
 		return
 	}
+	sessions := uc.collectSessions(func(*analyticsSessionEntry) bool { return true })
+	uc.refreshSessions(ctx, sessions, broadcaster)
+}
 
-	sanitized := cfg.SanitizeRequest(entry.request)
-	path, err := cfg.BuildPath(sanitized.Identifier)
-	if err != nil {
-		slog.Warn("analytics refresh path build failed", slog.String("key", cfg.Key), slog.String("sessionId", sessionID), slog.Any("error", err))
+func (uc *AnalyticsUseCase) refreshByKey(ctx context.Context, key string, broadcaster *BroadcastUseCase) {
+	sessions := uc.collectSessions(func(entry *analyticsSessionEntry) bool {
+		return strings.EqualFold(entry.key, key)
+	})
+	if len(sessions) == 0 {
 		return
 	}
 
-	snapshot, err := uc.fetcher.Fetch(ctx, entry.token, path, sanitized.Query)
-	if err != nil {
-		slog.Warn("analytics refresh fetch failed", slog.String("key", cfg.Key), slog.String("sessionId", sessionID), slog.Any("error", err))
-		uc.emitAnalyticsError(ctx, broadcaster, cfg, sessionID, sanitized, err)
-		return
+	uc.refreshSessions(ctx, sessions, broadcaster)
+}
+
+// analyticsSessionGroup is a set of sessions with the same key, sanitized request and audience.
+type analyticsSessionGroup struct {
+	cfg        AnalyticsEndpointConfig
+	request    domain.AnalyticsRequest
+	token      string
+	groupKey   string
+	sessionIDs []string
+}
+
+// refreshSessions fetches every group of identical sessions once and fans the payload out to
+// each session of the group.
+func (uc *AnalyticsUseCase) refreshSessions(ctx context.Context, sessions []*analyticsSessionEntry, broadcaster *BroadcastUseCase) {
+	groups := make(map[string]*analyticsSessionGroup)
+	for _, entry := range sessions {
+		cfg, ok := uc.Endpoint(entry.key)
+		if !ok {
+			continue
+		}
+		sanitized := cfg.SanitizeRequest(entry.request)
+		groupKey := analyticsGroupKey(cfg.Key, entry.audience, sanitized)
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &analyticsSessionGroup{cfg: cfg, request: sanitized, groupKey: groupKey}
+			groups[groupKey] = group
+		}
+		if group.token == "" {
+			group.token = entry.token
+		}
+		group.sessionIDs = append(group.sessionIDs, entry.sessionID)
 	}
 
-	message := domain.BuildAnalyticsMessage(cfg.Entity, cfg.Scope, snapshot, sanitized.Clone(), time.Now().UTC())
-	if message == nil {
+	for _, group := range groups {
+		uc.refreshGroup(ctx, group, broadcaster)
+	}
+}
+
+func (uc *AnalyticsUseCase) refreshGroup(ctx context.Context, group *analyticsSessionGroup, broadcaster *BroadcastUseCase) {
+	cfg := group.cfg
+	snapshot, err := uc.load(ctx, cfg, group.groupKey, group.token, group.request)
+	if errors.Is(err, ErrAnalyticsMissingIdentifier) {
+		slog.Warn("analytics refresh path build failed", slog.String("key", cfg.Key), slog.Int("sessions", len(group.sessionIDs)), slog.Any("error", err))
 		return
 	}
-	if message.Metadata == nil {
-		message.Metadata = map[string]string{}
+	if err != nil {
+		slog.Warn("analytics refresh fetch failed", slog.String("key", cfg.Key), slog.Int("sessions", len(group.sessionIDs)), slog.Any("error", err))
+		for _, sessionID := range group.sessionIDs {
+			uc.emitAnalyticsError(ctx, broadcaster, cfg, sessionID, group.request, err)
+		}
+		return
 	}
-	message.Metadata["sessionId"] = sessionID
-	message.Metadata["analyticsKey"] = cfg.Key
 
-	broadcaster.Execute(ctx, message)
+	now := time.Now()
+	for _, sessionID := range group.sessionIDs {
+		message := domain.BuildAnalyticsMessage(cfg.Entity, cfg.Scope, snapshot, group.request.Clone(), now.UTC())
+		if message == nil {
+			return
+		}
+		if message.Metadata == nil {
+			message.Metadata = map[string]string{}
+		}
+		message.Metadata["sessionId"] = sessionID
+		message.Metadata["analyticsKey"] = cfg.Key
+
+		broadcaster.Execute(ctx, message)
+	}
 
 	uc.mu.Lock()
-	if stored, ok := uc.sessions[sessionID]; ok {
-		stored.request = sanitized.Clone()
-		stored.key = cfg.Key
+	for _, sessionID := range group.sessionIDs {
+		if stored, ok := uc.sessions[sessionID][cfg.Key]; ok {
+			stored.request = group.request.Clone()
+			stored.refreshedAt = now
+		}
 	}
 	uc.mu.Unlock()
+	slog.Debug("analytics refresh fanned out", slog.String("key", cfg.Key), slog.Int("sessions", len(group.sessionIDs)))
 }
 
 func (uc *AnalyticsUseCase) emitAnalyticsError(ctx context.Context, broadcaster *BroadcastUseCase, cfg AnalyticsEndpointConfig, sessionID string, request domain.AnalyticsRequest, err error) {
@@ -670,100 +820,3 @@ func (uc *AnalyticsUseCase) emitAnalyticsError(ctx context.Context, broadcaster
 	}
 	broadcaster.Execute(ctx, message)
 }
-
-var analyticsDependencies = map[string][]string{
-	"restaurants": {
-		"analytics-admin-restaurants",
-		"analytics-admin-sections",
-		"analytics-admin-tables",
-		"analytics-admin-payments",
-	},
-	"sections": {
-		"analytics-admin-sections",
-		"analytics-admin-tables",
-	},
-	"tables": {
-		"analytics-admin-tables",
-		"analytics-admin-payments",
-	},
-	"images": {
-		"analytics-admin-images",
-	},
-	"objects": {
-		"analytics-admin-objects",
-		"analytics-admin-sections",
-	},
-	"section-objects": {
-		"analytics-admin-objects",
-		"analytics-admin-sections",
-	},
-	"subscriptions": {
-		"analytics-admin-subscriptions",
-		"analytics-admin-subscription-plans",
-		"analytics-admin-payments",
-	},
-	"subscription-plans": {
-		"analytics-admin-subscription-plans",
-	},
-	"reservations": {
-		"analytics-admin-reservations",
-		"analytics-restaurant-users",
-	},
-	"reviews": {
-		"analytics-admin-reviews",
-	},
-	"payments": {
-		"analytics-admin-payments",
-	},
-	"auth-users": {
-		"analytics-admin-auth",
-		"analytics-public-users",
-		"analytics-restaurant-users",
-	},
-	"menus": {
-		"analytics-public-menus",
-	},
-	"dishes": {
-		"analytics-public-dishes",
-	},
-}
-
-func normalizeAnalyticsDependencyEntity(raw string) string {
-	trimmed := strings.ToLower(strings.TrimSpace(raw))
-	if trimmed == "" {
-		return ""
-	}
-	replaced := strings.ReplaceAll(trimmed, "_", "-")
-	switch replaced {
-	case "restaurant", "restaurants":
-		return "restaurants"
-	case "section", "sections":
-		return "sections"
-	case "table", "tables":
-		return "tables"
-	case "image", "images":
-		return "images"
-	case "object", "objects":
-		return "objects"
-	case "section-object", "section-objects", "sectionobject", "sectionobjects":
-		return "section-objects"
-	case "subscription", "subscriptions":
-		return "subscriptions"
-	case "subscription-plan", "subscription-plans", "subscriptionplan", "subscriptionplans":
-		return "subscription-plans"
-	case "reservation", "reservations":
-		return "reservations"
-	case "review", "reviews":
-		return "reviews"
-	case "payment", "payments":
-		return "payments"
-	case "auth", "auth-user", "auth-users", "auth_user", "auth_users", "user", "users", "authuser", "authusers":
-		return "users"
-	case "menu", "menus":
-		return "menus"
-	case "dish", "dishes":
-		return "dishes"
-	default:
-		return replaced
-	}
-}
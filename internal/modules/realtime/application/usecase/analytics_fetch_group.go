@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+const (
+	defaultAnalyticsSharedTTL = 5 * time.Second
+	analyticsSharedTimeout    = 15 * time.Second
+)
+
+// analyticsFetchGroup collapses concurrent fetches of the same analytics group into one REST
+// call and keeps successful results for ttl, so sessions watching the same dashboard share
+// a single request.
+type analyticsFetchGroup struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	calls map[string]*analyticsFetchCall
+}
+
+type analyticsFetchCall struct {
+	done      chan struct{}
+	snapshot  *domain.AnalyticsSnapshot
+	err       error
+	fetchedAt time.Time
+}
+
+func newAnalyticsFetchGroup(ttl time.Duration) *analyticsFetchGroup {
+	return &analyticsFetchGroup{ttl: ttl, calls: make(map[string]*analyticsFetchCall)}
+}
+
+func (g *analyticsFetchGroup) setTTL(ttl time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ttl < 0 {
+		ttl = 0
+	}
+	g.ttl = ttl
+}
+
+// do returns the cached or in-flight result for key, or runs fetch. fetch runs detached from
+// the caller cancellation because other sessions may be waiting on it.
+func (g *analyticsFetchGroup) do(ctx context.Context, key string, fetch func(context.Context) (*domain.AnalyticsSnapshot, error)) (*domain.AnalyticsSnapshot, error) {
+	now := time.Now()
+	g.mu.Lock()
+	g.sweepLocked(now)
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return call.snapshot, call.err
+	}
+	call := &analyticsFetchCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), analyticsSharedTimeout)
+	call.snapshot, call.err = fetch(fetchCtx)
+	cancel()
+
+	g.mu.Lock()
+	call.fetchedAt = time.Now()
+	if (call.err != nil || g.ttl == 0) && g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	close(call.done)
+	g.mu.Unlock()
+	return call.snapshot, call.err
+}
+
+// invalidate drops cached and in-flight results under keyPrefix so the next fetch reaches the
+// REST API with post-event data. Callers already waiting on a dropped call still get its result.
+func (g *analyticsFetchGroup) invalidate(keyPrefix string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key := range g.calls {
+		if strings.HasPrefix(key, keyPrefix) {
+			delete(g.calls, key)
+		}
+	}
+}
+
+func (g *analyticsFetchGroup) sweepLocked(now time.Time) {
+	for key, call := range g.calls {
+		select {
+		case <-call.done:
+			if now.Sub(call.fetchedAt) >= g.ttl {
+				delete(g.calls, key)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/normalization"
+)
+
+// LiveCounterConfig turns an analytics endpoint into counters kept in process from Kafka events.
+// The endpoint path is paged through once per restaurant to seed the counters; afterwards every
+// event of Source pushes a new snapshot without calling the REST API.
+type LiveCounterConfig struct {
+	// Source is the canonical entity whose events feed the counters (e.g. "reservations").
+	Source string `json:"source"`
+	// GroupBy is the data field counted (e.g. "status"); empty only counts the total.
+	GroupBy string `json:"groupBy,omitempty"`
+	// RestaurantField is the data field with the restaurant id. The request param with the same
+	// name selects the restaurant; without it the counters cover every restaurant.
+	RestaurantField string `json:"restaurantField,omitempty"`
+	// DateField places an entity in a day (date or RFC3339); empty uses the event time.
+	DateField string `json:"dateField,omitempty"`
+	// Daily restricts the counters to entities of the current (local) day.
+	Daily bool `json:"daily,omitempty"`
+	// Actions that start tracking an entity (empty = every action but deleted). Entities
+	// already tracked are updated by any action.
+	Actions []string `json:"actions,omitempty"`
+}
+
+const (
+	liveTotalValue = "total"
+	// liveSeedPageSize is the page requested while seeding (the REST lists cap limit at 100).
+	liveSeedPageSize = 100
+	// liveSeedMaxPages bounds a seed against lists that ignore the page param.
+	liveSeedMaxPages = 1000
+)
+
+// liveRestaurant returns the restaurant the request is scoped to, "" for every restaurant.
+func (cfg AnalyticsEndpointConfig) liveRestaurant(req domain.AnalyticsRequest) string {
+	if cfg.Live == nil || cfg.Live.RestaurantField == "" {
+		return ""
+	}
+	if cfg.IdentifierParam == cfg.Live.RestaurantField {
+		return strings.TrimSpace(req.Identifier)
+	}
+	return strings.TrimSpace(req.Query[cfg.Live.RestaurantField])
+}
+
+// liveAnalytics keeps the counters of the live endpoints, one partition per endpoint and
+// restaurant being watched.
+type liveAnalytics struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	partitions map[string]*livePartition
+}
+
+type livePartition struct {
+	cfg        AnalyticsEndpointConfig
+	restaurant string
+	ready      chan struct{}
+	err        error
+	entities   map[string]liveEntity
+	// removed keeps the entities deleted while seeding so the seed does not restore them.
+	removed map[string]struct{}
+}
+
+type liveEntity struct {
+	value string
+	day   string
+}
+
+func newLiveAnalytics() *liveAnalytics {
+	return &liveAnalytics{now: time.Now, partitions: make(map[string]*livePartition)}
+}
+
+func livePartitionKey(key, restaurant string) string {
+	return key + "|" + restaurant
+}
+
+// snapshot returns the counters of cfg for request, seeding them with the items returned by
+// fetch the first time the restaurant is watched.
+func (l *liveAnalytics) snapshot(ctx context.Context, cfg AnalyticsEndpointConfig, request domain.AnalyticsRequest, fetch func(context.Context) ([]map[string]any, error)) (*domain.AnalyticsSnapshot, error) {
+	restaurant := cfg.liveRestaurant(request)
+	partitionKey := livePartitionKey(cfg.Key, restaurant)
+
+	l.mu.Lock()
+	partition, ok := l.partitions[partitionKey]
+	if !ok {
+		partition = &livePartition{
+			cfg:        cfg,
+			restaurant: restaurant,
+			ready:      make(chan struct{}),
+			entities:   make(map[string]liveEntity),
+			removed:    make(map[string]struct{}),
+		}
+		l.partitions[partitionKey] = partition
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		// Other sessions may be waiting on the seed: it runs detached from the caller.
+		seedCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), analyticsSharedTimeout)
+		seed, err := fetch(seedCtx)
+		cancel()
+		l.mu.Lock()
+		if err != nil {
+			partition.err = err
+			if l.partitions[partitionKey] == partition {
+				delete(l.partitions, partitionKey)
+			}
+		} else {
+			partition.seed(seed, l.now())
+		}
+		close(partition.ready)
+		l.mu.Unlock()
+	}
+
+	select {
+	case <-partition.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if partition.err != nil {
+		return nil, partition.err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return partition.snapshot(l.now()), nil
+}
+
+// apply updates the partitions fed by entity and returns the keys of the partitions changed.
+func (l *liveAnalytics) apply(entity string, msg *domain.Message) []string {
+	if msg == nil {
+		return nil
+	}
+	data := normalization.MapFromPayload(msg.Data)
+	at := msg.Timestamp
+	if at.IsZero() {
+		at = l.now()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := liveEntityID(msg, data)
+	if id == "" {
+		return nil
+	}
+	var changed []string
+	for partitionKey, partition := range l.partitions {
+		live := partition.cfg.Live
+		if live.Source != entity {
+			continue
+		}
+		if !partition.matches(id, msg, data) {
+			continue
+		}
+		if partition.update(id, msg, data, at, l.now()) {
+			changed = append(changed, partitionKey)
+		}
+	}
+	return changed
+}
+
+// release drops the partition once no session watches it; the next session seeds it again.
+func (l *liveAnalytics) release(key, restaurant string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	partitionKey := livePartitionKey(key, restaurant)
+	if partition, ok := l.partitions[partitionKey]; ok {
+		select {
+		case <-partition.ready:
+			delete(l.partitions, partitionKey)
+		default:
+		}
+	}
+}
+
+// fetchLiveItems pages through a list endpoint with query until a short page, the reported
+// total or a page without new items, and returns every item with an id.
+func fetchLiveItems(ctx context.Context, query map[string]string, fetch func(context.Context, map[string]string) (*domain.AnalyticsSnapshot, error)) ([]map[string]any, error) {
+	var items []map[string]any
+	seen := make(map[string]struct{})
+	for page := 1; page <= liveSeedMaxPages; page++ {
+		pageQuery := make(map[string]string, len(query)+2)
+		maps.Copy(pageQuery, query)
+		pageQuery["page"] = strconv.Itoa(page)
+		pageQuery["limit"] = strconv.Itoa(liveSeedPageSize)
+		snapshot, err := fetch(ctx, pageQuery)
+		if err != nil {
+			return nil, err
+		}
+		pageItems, total := liveListPage(snapshot)
+		fresh := 0
+		for _, item := range pageItems {
+			entry, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			id := normalization.AsString(entry["id"])
+			if id == "" {
+				continue
+			}
+			if _, duplicate := seen[id]; duplicate {
+				continue
+			}
+			seen[id] = struct{}{}
+			items = append(items, entry)
+			fresh++
+		}
+		if len(pageItems) < liveSeedPageSize || fresh == 0 || (total > 0 && len(seen) >= total) {
+			break
+		}
+	}
+	return items, nil
+}
+
+// liveListPage returns the items of a list response and the total it reports, 0 if unknown.
+func liveListPage(snapshot *domain.AnalyticsSnapshot) ([]any, int) {
+	if snapshot == nil {
+		return nil, 0
+	}
+	payload := normalization.MapFromPayload(snapshot.Payload)
+	items := normalization.AsInterfaceSlice(payload["items"])
+	if items == nil {
+		items = normalization.AsInterfaceSlice(payload["data"])
+	}
+	total := normalization.AsInt(payload["total"])
+	if meta := normalization.MapFromPayload(payload["meta"]); total == 0 && meta != nil {
+		total = normalization.AsInt(meta["total"])
+	}
+	return items, total
+}
+
+func (p *livePartition) seed(items []map[string]any, now time.Time) {
+	live := p.cfg.Live
+	for _, entry := range items {
+		id := normalization.AsString(entry["id"])
+		if id == "" {
+			continue
+		}
+		if _, tracked := p.entities[id]; tracked {
+			continue // an event received while seeding is newer
+		}
+		if _, deleted := p.removed[id]; deleted {
+			continue
+		}
+		if p.restaurant != "" && live.RestaurantField != "" && normalization.AsString(entry[live.RestaurantField]) != p.restaurant {
+			continue
+		}
+		day := liveDay(normalization.AsString(entry[live.DateField]))
+		if live.Daily && day == "" {
+			continue
+		}
+		p.entities[id] = liveEntity{value: liveValue(live.GroupBy, entry), day: day}
+	}
+	p.removed = nil
+	p.prune(now)
+}
+
+// matches reports whether the event belongs to the partition restaurant. Events without the
+// restaurant (deletes usually only carry the id) match the partitions tracking the entity, and
+// deletes also reach the partitions still seeding so the seed does not restore the entity.
+func (p *livePartition) matches(id string, msg *domain.Message, data map[string]any) bool {
+	if p.restaurant == "" {
+		return true
+	}
+	restaurant := liveField(p.cfg.Live.RestaurantField, data, msg.Metadata)
+	if restaurant != "" {
+		return restaurant == p.restaurant
+	}
+	if _, tracked := p.entities[id]; tracked {
+		return true
+	}
+	return p.removed != nil && strings.EqualFold(strings.TrimSpace(msg.Action), domain.ActionDeleted)
+}
+
+func (p *livePartition) update(id string, msg *domain.Message, data map[string]any, at, now time.Time) bool {
+	live := p.cfg.Live
+	action := strings.ToLower(strings.TrimSpace(msg.Action))
+	current, tracked := p.entities[id]
+	if action == domain.ActionDeleted {
+		delete(p.entities, id)
+		if p.removed != nil {
+			p.removed[id] = struct{}{}
+		}
+		return tracked
+	}
+	if !tracked && len(live.Actions) > 0 && !slices.Contains(live.Actions, action) {
+		return false
+	}
+
+	next := current
+	if value := liveValue(live.GroupBy, data); value != "" || !tracked {
+		next.value = value
+	}
+	if day := liveDay(normalization.AsString(data[live.DateField])); day != "" {
+		next.day = day
+	} else if !tracked {
+		next.day = at.In(time.Local).Format(time.DateOnly)
+	}
+	if next.value == "" {
+		next.value = "unknown"
+	}
+	p.entities[id] = next
+	p.prune(now)
+	return !tracked || next != current
+}
+
+// prune forgets entities of past days once the day rolls over.
+func (p *livePartition) prune(now time.Time) {
+	if !p.cfg.Live.Daily {
+		return
+	}
+	today := now.In(time.Local).Format(time.DateOnly)
+	for id, entity := range p.entities {
+		if entity.day != today {
+			delete(p.entities, id)
+		}
+	}
+}
+
+func (p *livePartition) snapshot(now time.Time) *domain.AnalyticsSnapshot {
+	p.prune(now)
+	counts := make(map[string]int)
+	for _, entity := range p.entities {
+		counts[entity.value]++
+	}
+	payload := map[string]any{
+		"counts": counts,
+		"total":  len(p.entities),
+	}
+	if p.restaurant != "" {
+		payload[p.cfg.Live.RestaurantField] = p.restaurant
+	}
+	if p.cfg.Live.Daily {
+		payload["date"] = now.In(time.Local).Format(time.DateOnly)
+	}
+	return &domain.AnalyticsSnapshot{Payload: payload, Metadata: domain.Metadata{"source": "live"}}
+}
+
+func liveEntityID(msg *domain.Message, data map[string]any) string {
+	if id := strings.TrimSpace(msg.ResourceID); id != "" {
+		return id
+	}
+	return normalization.AsString(data["id"])
+}
+
+func liveField(field string, data map[string]any, metadata map[string]string) string {
+	if field == "" {
+		return ""
+	}
+	if value := normalization.AsString(data[field]); value != "" {
+		return value
+	}
+	return strings.TrimSpace(metadata[field])
+}
+
+func liveValue(groupBy string, data map[string]any) string {
+	if groupBy == "" {
+		return liveTotalValue
+	}
+	return strings.ToUpper(normalization.AsString(data[groupBy]))
+}
+
+// liveDay returns the local day of a date ("2026-03-02") or RFC3339 timestamp.
+func liveDay(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at.In(time.Local).Format(time.DateOnly)
+	}
+	if len(raw) >= len(time.DateOnly) {
+		if _, err := time.Parse(time.DateOnly, raw[:len(time.DateOnly)]); err == nil {
+			return raw[:len(time.DateOnly)]
+		}
+	}
+	return ""
+}
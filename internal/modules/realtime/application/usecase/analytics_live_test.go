@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+)
+
+type listAnalyticsFetcher struct {
+	mu    sync.Mutex
+	items []any
+	calls int
+}
+
+func (f *listAnalyticsFetcher) Fetch(_ context.Context, _, _ string, _ map[string]string) (*domain.AnalyticsSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return &domain.AnalyticsSnapshot{Payload: map[string]any{"items": f.items}}, nil
+}
+
+func TestLiveAnalytics_SeedsOnceAndCountsEvents(t *testing.T) {
+	today := time.Now().Format(time.DateOnly)
+	fetcher := &listAnalyticsFetcher{items: []any{
+		map[string]any{"id": "r-1", "status": "PENDING", "date": today, "restaurantId": "rest-1"},
+		map[string]any{"id": "r-2", "status": "CONFIRMED", "date": today, "restaurantId": "rest-1"},
+		map[string]any{"id": "r-3", "status": "CONFIRMED", "date": "2020-01-01", "restaurantId": "rest-1"},
+	}}
+	uc := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	broadcaster := &collectingBroadcaster{}
+	broadcastUC := NewBroadcastUseCase(broadcaster)
+	ctx := context.Background()
+	cfg, _ := uc.Endpoint("analytics-restaurant-reservations")
+	request := domain.AnalyticsRequest{Identifier: "rest-1"}
+
+	snapshot, err := uc.load(ctx, cfg, "", "token", request)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if counts := snapshot.Payload.(map[string]any)["counts"].(map[string]int); counts["PENDING"] != 1 || counts["CONFIRMED"] != 1 {
+		t.Fatalf("unexpected seeded counts: %v", counts)
+	}
+	uc.RegisterSession("owner-tablet", cfg.Key, "token", nil, request)
+
+	events := []*domain.Message{
+		{Entity: "reservations", Action: "status_changed", ResourceID: "r-1", Data: map[string]any{"status": "CONFIRMED", "restaurantId": "rest-1"}},
+		{Entity: "reservations", Action: "created", ResourceID: "r-4", Data: map[string]any{"status": "PENDING", "date": today, "restaurantId": "rest-1"}},
+		{Entity: "reservations", Action: "created", ResourceID: "r-5", Data: map[string]any{"status": "PENDING", "date": today, "restaurantId": "rest-2"}},
+		// Deletes only carry the id: the partition tracking r-2 resolves the restaurant.
+		{Entity: "reservations", Action: "deleted", ResourceID: "r-2"},
+	}
+	for _, event := range events {
+		uc.ApplyLiveEvent(ctx, "Reservation", event, broadcastUC)
+	}
+
+	if fetcher.calls != 1 {
+		t.Fatalf("events must not reach the REST API, got %d fetches", fetcher.calls)
+	}
+	if len(broadcaster.messages) != 3 {
+		t.Fatalf("expected one push per event of rest-1, got %d", len(broadcaster.messages))
+	}
+	last := broadcaster.messages[len(broadcaster.messages)-1]
+	if last.Topic != "analytics-restaurant-reservations.snapshot" || last.Metadata["sessionId"] != "owner-tablet" || last.Metadata["source"] != "live" {
+		t.Fatalf("unexpected message: %s %v", last.Topic, last.Metadata)
+	}
+	payload := last.Data.(map[string]any)
+	if counts := payload["counts"].(map[string]int); counts["CONFIRMED"] != 1 || counts["PENDING"] != 1 || payload["total"] != 2 {
+		t.Fatalf("unexpected live counts: %v", payload)
+	}
+
+	// The last session leaving drops the counters; the next one seeds again.
+	uc.UnregisterSession("owner-tablet")
+	if _, err := uc.load(ctx, cfg, "", "token", request); err != nil || fetcher.calls != 2 {
+		t.Fatalf("expected a new seed after release, got %d fetches (%v)", fetcher.calls, err)
+	}
+}
+
+type pagedAnalyticsFetcher struct {
+	total   int
+	queries []map[string]string
+}
+
+func (f *pagedAnalyticsFetcher) Fetch(_ context.Context, _, _ string, query map[string]string) (*domain.AnalyticsSnapshot, error) {
+	f.queries = append(f.queries, query)
+	page, _ := strconv.Atoi(query["page"])
+	limit, _ := strconv.Atoi(query["limit"])
+	var items []any
+	for index := (page - 1) * limit; index < page*limit && index < f.total; index++ {
+		items = append(items, map[string]any{"id": fmt.Sprintf("t-%d", index), "state": "FREE", "restaurantId": "rest-1"})
+	}
+	return &domain.AnalyticsSnapshot{Payload: map[string]any{"items": items, "meta": map[string]any{"total": f.total}}}, nil
+}
+
+func TestLiveAnalytics_SeedPagesThroughTheList(t *testing.T) {
+	fetcher := &pagedAnalyticsFetcher{total: 2*liveSeedPageSize + 5}
+	uc := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	cfg, _ := uc.Endpoint("analytics-restaurant-tables")
+
+	snapshot, err := uc.load(context.Background(), cfg, "", "token", domain.AnalyticsRequest{Identifier: "rest-1"})
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	if total := snapshot.Payload.(map[string]any)["total"]; total != fetcher.total {
+		t.Fatalf("expected %d seeded tables, got %v", fetcher.total, total)
+	}
+	if len(fetcher.queries) != 3 {
+		t.Fatalf("expected 3 pages, got %d", len(fetcher.queries))
+	}
+	if last := fetcher.queries[2]; last["page"] != "3" || last["restaurantId"] != "rest-1" {
+		t.Fatalf("unexpected page query: %v", last)
+	}
+}
@@ -0,0 +1,615 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"mesaYaWs/internal/shared/entities"
+)
+
+// AnalyticsRegistry is the resolved analytics configuration: the REST endpoints, the aliases
+// that map /ws/analytics/:scope/:entity to an endpoint key, and the entity→dashboard
+// dependencies refreshed when a Kafka event for that entity arrives.
+type AnalyticsRegistry struct {
+	Endpoints map[string]AnalyticsEndpointConfig
+	// ScopeAliases maps URL scopes (e.g. "owner") to canonical scopes ("restaurant").
+	ScopeAliases map[string]string
+	// EntityAliases maps, per canonical scope, URL entities to endpoint entities.
+	EntityAliases map[string]map[string]string
+	// EventAliases maps Kafka entity names to the canonical entities of Dependencies when the
+	// entity registry does not already resolve them.
+	EventAliases map[string]string
+	// Dependencies lists the endpoint keys refreshed for each canonical entity.
+	Dependencies map[string][]string
+	// Entities resolves the Kafka entity names missing from EventAliases.
+	Entities *entities.Registry
+}
+
+// analyticsRegistryFile is the JSON shape of ANALYTICS_CONFIG_FILE and of the admin endpoint.
+type analyticsRegistryFile struct {
+	Endpoints     []analyticsEndpointFile      `json:"endpoints"`
+	ScopeAliases  map[string]string            `json:"scopeAliases,omitempty"`
+	EntityAliases map[string]map[string]string `json:"entityAliases,omitempty"`
+	EventAliases  map[string]string            `json:"eventAliases,omitempty"`
+	Dependencies  map[string][]string          `json:"dependencies"`
+}
+
+type analyticsEndpointFile struct {
+	Key                string             `json:"key"`
+	Scope              string             `json:"scope"`
+	Entity             string             `json:"entity"`
+	Path               string             `json:"path"`
+	RequiresIdentifier bool               `json:"requiresIdentifier,omitempty"`
+	IdentifierParam    string             `json:"identifierParam,omitempty"`
+	IdentifierAsQuery  bool               `json:"identifierAsQuery,omitempty"`
+	QueryParams        []string           `json:"queryParams,omitempty"`
+	RequireToken       bool               `json:"requireToken,omitempty"`
+	AllowedRoles       []string           `json:"allowedRoles,omitempty"`
+	OwnershipParam     string             `json:"ownershipParam,omitempty"`
+	Live               *LiveCounterConfig `json:"live,omitempty"`
+	RefreshInterval    string             `json:"refreshInterval,omitempty"`
+}
+
+// LoadAnalyticsRegistry reads the registry from a JSON file and validates it against the
+// entities of entityRegistry.
+func LoadAnalyticsRegistry(file string, entityRegistry *entities.Registry) (*AnalyticsRegistry, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read analytics config: %w", err)
+	}
+	var parsed analyticsRegistryFile
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("parse analytics config: %w", err)
+	}
+	registry := &AnalyticsRegistry{
+		Endpoints:     make(map[string]AnalyticsEndpointConfig, len(parsed.Endpoints)),
+		ScopeAliases:  lowerKeys(parsed.ScopeAliases),
+		EntityAliases: make(map[string]map[string]string, len(parsed.EntityAliases)),
+		EventAliases:  lowerKeys(parsed.EventAliases),
+		Dependencies:  make(map[string][]string, len(parsed.Dependencies)),
+		Entities:      entityRegistry,
+	}
+	for scope, aliases := range parsed.EntityAliases {
+		registry.EntityAliases[strings.ToLower(strings.TrimSpace(scope))] = lowerKeys(aliases)
+	}
+	for entity, keys := range parsed.Dependencies {
+		registry.Dependencies[strings.ToLower(strings.TrimSpace(entity))] = keys
+	}
+	var errs []error
+	for i, endpoint := range parsed.Endpoints {
+		key := strings.TrimSpace(endpoint.Key)
+		if key == "" {
+			errs = append(errs, fmt.Errorf("endpoint #%d: key is required", i+1))
+			continue
+		}
+		if _, dup := registry.Endpoints[key]; dup {
+			errs = append(errs, fmt.Errorf("endpoint %s: duplicated key", key))
+			continue
+		}
+		var interval time.Duration
+		if raw := strings.TrimSpace(endpoint.RefreshInterval); raw != "" {
+			if interval, err = time.ParseDuration(raw); err != nil {
+				errs = append(errs, fmt.Errorf("endpoint %s: invalid refreshInterval %q", key, raw))
+			}
+		}
+		registry.Endpoints[key] = AnalyticsEndpointConfig{
+			Key:                key,
+			Scope:              strings.TrimSpace(endpoint.Scope),
+			Entity:             strings.TrimSpace(endpoint.Entity),
+			PathTemplate:       strings.TrimSpace(endpoint.Path),
+			RequiresIdentifier: endpoint.RequiresIdentifier,
+			IdentifierParam:    strings.TrimSpace(endpoint.IdentifierParam),
+			IdentifierAsQuery:  endpoint.IdentifierAsQuery,
+			QueryParams:        endpoint.QueryParams,
+			RequireToken:       endpoint.RequireToken,
+			AllowedRoles:       endpoint.AllowedRoles,
+			OwnershipParam:     strings.TrimSpace(endpoint.OwnershipParam),
+			Live:               normalizeLiveCounter(endpoint.Live),
+			RefreshInterval:    interval,
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("analytics config %s: %w", file, err)
+	}
+	if err := registry.Validate(); err != nil {
+		return nil, fmt.Errorf("analytics config %s: %w", file, err)
+	}
+	return registry, nil
+}
+
+// Validate checks the cross-references between endpoints, aliases and dependencies.
+func (r *AnalyticsRegistry) Validate() error {
+	if r.Entities == nil {
+		return errors.New("analytics registry needs an entity registry")
+	}
+	var errs []error
+	scopes := make(map[string]struct{})
+	for key, cfg := range r.Endpoints {
+		if key != cfg.Key {
+			errs = append(errs, fmt.Errorf("endpoint %s: registered under %q", cfg.Key, key))
+		}
+		if cfg.Scope == "" || cfg.Entity == "" || cfg.PathTemplate == "" {
+			errs = append(errs, fmt.Errorf("endpoint %s: scope, entity and path are required", key))
+			continue
+		}
+		scopes[cfg.Scope] = struct{}{}
+		if !strings.HasPrefix(key, "analytics-"+cfg.Scope+"-") {
+			errs = append(errs, fmt.Errorf("endpoint %s: key must start with analytics-%s-", key, cfg.Scope))
+		}
+		if cfg.RequiresIdentifier {
+			if cfg.IdentifierParam == "" {
+				errs = append(errs, fmt.Errorf("endpoint %s: identifierParam is required when requiresIdentifier is set", key))
+			}
+			if !cfg.IdentifierAsQuery && !strings.Contains(cfg.PathTemplate, "%s") {
+				errs = append(errs, fmt.Errorf("endpoint %s: path must contain %%s for the identifier", key))
+			}
+		}
+		if len(cfg.AllowedRoles) > 0 && !cfg.RequireToken {
+			errs = append(errs, fmt.Errorf("endpoint %s: allowedRoles requires requireToken", key))
+		}
+		if cfg.OwnershipParam != "" && cfg.OwnershipParam != cfg.IdentifierParam && !slices.Contains(cfg.QueryParams, cfg.OwnershipParam) {
+			errs = append(errs, fmt.Errorf("endpoint %s: ownershipParam %q is neither the identifier nor a query param", key, cfg.OwnershipParam))
+		}
+		if cfg.Live != nil {
+			if cfg.Live.Source == "" || r.EventEntity(cfg.Live.Source) != cfg.Live.Source {
+				errs = append(errs, fmt.Errorf("endpoint %s: live source %q must be a canonical entity", key, cfg.Live.Source))
+			}
+			if cfg.RefreshInterval != 0 {
+				errs = append(errs, fmt.Errorf("endpoint %s: live endpoints are updated by events, refreshInterval is not allowed", key))
+			}
+		}
+		if cfg.RefreshInterval < 0 {
+			errs = append(errs, fmt.Errorf("endpoint %s: refreshInterval must not be negative", key))
+		}
+	}
+	for alias, scope := range r.ScopeAliases {
+		if _, ok := scopes[scope]; !ok {
+			errs = append(errs, fmt.Errorf("scope alias %s: unknown scope %q", alias, scope))
+		}
+	}
+	for scope, aliases := range r.EntityAliases {
+		if _, ok := scopes[scope]; !ok {
+			errs = append(errs, fmt.Errorf("entity aliases: unknown scope %q", scope))
+			continue
+		}
+		for alias, entity := range aliases {
+			if _, ok := r.Endpoints["analytics-"+scope+"-"+entity]; !ok {
+				errs = append(errs, fmt.Errorf("entity alias %s/%s: no endpoint analytics-%s-%s", scope, alias, scope, entity))
+			}
+		}
+	}
+	for entity, keys := range r.Dependencies {
+		if canonical := r.EventEntity(entity); canonical != entity {
+			errs = append(errs, fmt.Errorf("dependency %s: unreachable, events normalize to %q", entity, canonical))
+		}
+		for _, key := range keys {
+			cfg, ok := r.Endpoints[key]
+			switch {
+			case !ok:
+				errs = append(errs, fmt.Errorf("dependency %s: unknown endpoint %q", entity, key))
+			case cfg.Live != nil:
+				errs = append(errs, fmt.Errorf("dependency %s: live endpoint %q is updated by events", entity, key))
+			}
+		}
+	}
+	for alias, entity := range r.EventAliases {
+		if _, ok := r.Dependencies[entity]; !ok {
+			errs = append(errs, fmt.Errorf("event alias %s: entity %q has no dependency entry", alias, entity))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Key composes the endpoint key for a /ws/analytics/:scope/:entity request.
+func (r *AnalyticsRegistry) Key(scope, entity string) string {
+	normalizedScope := strings.ToLower(strings.TrimSpace(scope))
+	if resolved, ok := r.ScopeAliases[normalizedScope]; ok {
+		normalizedScope = resolved
+	}
+	normalizedEntity := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(entity)), "_", "-")
+	if resolved, ok := r.EntityAliases[normalizedScope][normalizedEntity]; ok {
+		normalizedEntity = resolved
+	}
+	if normalizedScope == "" || normalizedEntity == "" {
+		return ""
+	}
+	return "analytics-" + normalizedScope + "-" + normalizedEntity
+}
+
+// EventEntity returns the canonical dependency entity for a Kafka entity name: the EventAliases
+// of the registry first, then the aliases of the entity registry.
+func (r *AnalyticsRegistry) EventEntity(raw string) string {
+	replaced := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), "_", "-")
+	if replaced == "" {
+		return ""
+	}
+	if canonical, ok := r.EventAliases[replaced]; ok {
+		return canonical
+	}
+	return r.Entities.Normalize(replaced)
+}
+
+// MarshalJSON renders the registry in the ANALYTICS_CONFIG_FILE format.
+func (r *AnalyticsRegistry) MarshalJSON() ([]byte, error) {
+	out := analyticsRegistryFile{
+		Endpoints:     make([]analyticsEndpointFile, 0, len(r.Endpoints)),
+		ScopeAliases:  r.ScopeAliases,
+		EntityAliases: r.EntityAliases,
+		EventAliases:  r.EventAliases,
+		Dependencies:  r.Dependencies,
+	}
+	for _, cfg := range r.Endpoints {
+		endpoint := analyticsEndpointFile{
+			Key:                cfg.Key,
+			Scope:              cfg.Scope,
+			Entity:             cfg.Entity,
+			Path:               cfg.PathTemplate,
+			RequiresIdentifier: cfg.RequiresIdentifier,
+			IdentifierParam:    cfg.IdentifierParam,
+			IdentifierAsQuery:  cfg.IdentifierAsQuery,
+			QueryParams:        cfg.QueryParams,
+			RequireToken:       cfg.RequireToken,
+			AllowedRoles:       cfg.AllowedRoles,
+			OwnershipParam:     cfg.OwnershipParam,
+			Live:               cfg.Live,
+		}
+		if cfg.RefreshInterval > 0 {
+			endpoint.RefreshInterval = cfg.RefreshInterval.String()
+		}
+		out.Endpoints = append(out.Endpoints, endpoint)
+	}
+	sort.Slice(out.Endpoints, func(i, j int) bool { return out.Endpoints[i].Key < out.Endpoints[j].Key })
+	return json.Marshal(out)
+}
+
+func (r *AnalyticsRegistry) clone() *AnalyticsRegistry {
+	cloned := &AnalyticsRegistry{
+		Endpoints:     make(map[string]AnalyticsEndpointConfig, len(r.Endpoints)),
+		ScopeAliases:  r.ScopeAliases,
+		EntityAliases: r.EntityAliases,
+		EventAliases:  r.EventAliases,
+		Dependencies:  r.Dependencies,
+		Entities:      r.Entities,
+	}
+	for key, cfg := range r.Endpoints {
+		cloned.Endpoints[key] = cfg
+	}
+	return cloned
+}
+
+func normalizeLiveCounter(live *LiveCounterConfig) *LiveCounterConfig {
+	if live == nil {
+		return nil
+	}
+	normalized := &LiveCounterConfig{
+		Source:          strings.ToLower(strings.TrimSpace(live.Source)),
+		GroupBy:         strings.TrimSpace(live.GroupBy),
+		RestaurantField: strings.TrimSpace(live.RestaurantField),
+		DateField:       strings.TrimSpace(live.DateField),
+		Daily:           live.Daily,
+	}
+	for _, action := range live.Actions {
+		if trimmed := strings.ToLower(strings.TrimSpace(action)); trimmed != "" {
+			normalized.Actions = append(normalized.Actions, trimmed)
+		}
+	}
+	return normalized
+}
+
+func lowerKeys(values map[string]string) map[string]string {
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+// DefaultAnalyticsRegistry returns the built-in registry used when ANALYTICS_CONFIG_FILE is
+// not set (docs/analytics/analytics.example.json holds the same content), resolving event
+// entities with entityRegistry.
+func DefaultAnalyticsRegistry(entityRegistry *entities.Registry) *AnalyticsRegistry {
+	endpoints := []AnalyticsEndpointConfig{
+		{
+			Key:          "analytics-public-users",
+			Scope:        "public",
+			Entity:       "analytics-public-users",
+			PathTemplate: "/api/v1/users/analytics",
+			QueryParams:  []string{"startDate"},
+		},
+		{
+			Key:          "analytics-public-dishes",
+			Scope:        "public",
+			Entity:       "analytics-public-dishes",
+			PathTemplate: "/api/v1/dishes/analytics",
+			QueryParams:  []string{"startDate"},
+		},
+		{
+			Key:          "analytics-public-menus",
+			Scope:        "public",
+			Entity:       "analytics-public-menus",
+			PathTemplate: "/api/v1/menus/analytics",
+			QueryParams:  []string{"startDate"},
+		},
+		{
+			Key:                "analytics-restaurant-users",
+			Scope:              "restaurant",
+			Entity:             "analytics-restaurant-users",
+			PathTemplate:       "/api/v1/users/analytics",
+			RequiresIdentifier: true,
+			IdentifierParam:    "restaurantId",
+			IdentifierAsQuery:  true,
+			QueryParams:        []string{"startDate"},
+			RequireToken:       true,
+			AllowedRoles:       []string{"ADMIN", "OWNER"},
+			OwnershipParam:     "restaurantId",
+		},
+		{
+			Key:          "analytics-admin-users",
+			Scope:        "admin",
+			Entity:       "analytics-admin-users",
+			PathTemplate: "/api/v1/users/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-restaurants",
+			Scope:        "admin",
+			Entity:       "analytics-admin-restaurants",
+			PathTemplate: "/api/v1/restaurants/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-sections",
+			Scope:        "admin",
+			Entity:       "analytics-admin-sections",
+			PathTemplate: "/api/v1/sections/analytics",
+			QueryParams:  []string{"restaurantId", "startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-tables",
+			Scope:        "admin",
+			Entity:       "analytics-admin-tables",
+			PathTemplate: "/api/v1/tables/analytics",
+			QueryParams:  []string{"sectionId", "restaurantId", "startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-images",
+			Scope:        "admin",
+			Entity:       "analytics-admin-images",
+			PathTemplate: "/api/v1/images/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-objects",
+			Scope:        "admin",
+			Entity:       "analytics-admin-objects",
+			PathTemplate: "/api/v1/objects/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-subscriptions",
+			Scope:        "admin",
+			Entity:       "analytics-admin-subscriptions",
+			PathTemplate: "/api/v1/subscriptions/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:          "analytics-admin-subscription-plans",
+			Scope:        "admin",
+			Entity:       "analytics-admin-subscription-plans",
+			PathTemplate: "/api/v1/subscription-plans/analytics",
+			QueryParams:  []string{"startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:             "analytics-admin-reservations",
+			Scope:           "admin",
+			Entity:          "analytics-admin-reservations",
+			PathTemplate:    "/api/v1/reservations/analytics",
+			QueryParams:     []string{"restaurantId", "startDate"},
+			RequireToken:    true,
+			AllowedRoles:    []string{"ADMIN"},
+			RefreshInterval: time.Minute,
+		},
+		{
+			Key:          "analytics-admin-reviews",
+			Scope:        "admin",
+			Entity:       "analytics-admin-reviews",
+			PathTemplate: "/api/v1/reviews/analytics/stats",
+			QueryParams:  []string{"restaurantId", "startDate"},
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+		},
+		{
+			Key:             "analytics-admin-payments",
+			Scope:           "admin",
+			Entity:          "analytics-admin-payments",
+			PathTemplate:    "/api/v1/payments/analytics",
+			QueryParams:     []string{"restaurantId", "startDate"},
+			RequireToken:    true,
+			AllowedRoles:    []string{"ADMIN"},
+			RefreshInterval: time.Minute,
+		},
+		{
+			Key:                "analytics-restaurant-reservations",
+			Scope:              "restaurant",
+			Entity:             "analytics-restaurant-reservations",
+			PathTemplate:       "/api/v1/reservations",
+			RequiresIdentifier: true,
+			IdentifierParam:    "restaurantId",
+			IdentifierAsQuery:  true,
+			RequireToken:       true,
+			AllowedRoles:       []string{"ADMIN", "OWNER"},
+			OwnershipParam:     "restaurantId",
+			Live: &LiveCounterConfig{
+				Source:          "reservations",
+				GroupBy:         "status",
+				RestaurantField: "restaurantId",
+				DateField:       "date",
+				Daily:           true,
+			},
+		},
+		{
+			Key:                "analytics-restaurant-tables",
+			Scope:              "restaurant",
+			Entity:             "analytics-restaurant-tables",
+			PathTemplate:       "/api/v1/tables",
+			RequiresIdentifier: true,
+			IdentifierParam:    "restaurantId",
+			IdentifierAsQuery:  true,
+			RequireToken:       true,
+			AllowedRoles:       []string{"ADMIN", "OWNER"},
+			OwnershipParam:     "restaurantId",
+			Live: &LiveCounterConfig{
+				Source:          "tables",
+				GroupBy:         "state",
+				RestaurantField: "restaurantId",
+			},
+		},
+		{
+			Key:          "analytics-admin-new-users",
+			Scope:        "admin",
+			Entity:       "analytics-admin-new-users",
+			PathTemplate: "/api/v1/users",
+			RequireToken: true,
+			AllowedRoles: []string{"ADMIN"},
+			Live: &LiveCounterConfig{
+				Source:    "users",
+				DateField: "createdAt",
+				Daily:     true,
+				Actions:   []string{"created", "user_signed_up"},
+			},
+		},
+	}
+
+	registry := &AnalyticsRegistry{
+		Endpoints: make(map[string]AnalyticsEndpointConfig, len(endpoints)),
+		Entities:  entityRegistry,
+		ScopeAliases: map[string]string{
+			"public":        "public",
+			"pub":           "public",
+			"restaurant":    "restaurant",
+			"rest":          "restaurant",
+			"owner":         "restaurant",
+			"admin":         "admin",
+			"administrator": "admin",
+			"adm":           "admin",
+			"auth":          "admin",
+		},
+		EntityAliases: map[string]map[string]string{
+			"public": {
+				"user":         "users",
+				"public-user":  "users",
+				"public-users": "users",
+				"dish":         "dishes",
+				"menu":         "menus",
+			},
+			"restaurant": {
+				"user":             "users",
+				"restaurant":       "users",
+				"restaurant-user":  "users",
+				"restaurant-users": "users",
+				"reservation":      "reservations",
+				"table":            "tables",
+			},
+			"admin": {
+				"auth":              "users",
+				"auth-user":         "users",
+				"auth-users":        "users",
+				"user":              "users",
+				"restaurant":        "restaurants",
+				"section":           "sections",
+				"table":             "tables",
+				"image":             "images",
+				"object":            "objects",
+				"subscription":      "subscriptions",
+				"subscription-plan": "subscription-plans",
+				"subscriptionplan":  "subscription-plans",
+				"subscriptionplans": "subscription-plans",
+				"reservation":       "reservations",
+				"review":            "reviews",
+				"payment":           "payments",
+			},
+		},
+		Dependencies: map[string][]string{
+			"restaurants": {
+				"analytics-admin-restaurants",
+				"analytics-admin-sections",
+				"analytics-admin-tables",
+				"analytics-admin-payments",
+			},
+			"sections": {
+				"analytics-admin-sections",
+				"analytics-admin-tables",
+			},
+			"tables": {
+				"analytics-admin-tables",
+				"analytics-admin-payments",
+			},
+			"images": {
+				"analytics-admin-images",
+			},
+			"objects": {
+				"analytics-admin-objects",
+				"analytics-admin-sections",
+			},
+			"section-objects": {
+				"analytics-admin-objects",
+				"analytics-admin-sections",
+			},
+			"subscriptions": {
+				"analytics-admin-subscriptions",
+				"analytics-admin-subscription-plans",
+				"analytics-admin-payments",
+			},
+			"subscription-plans": {
+				"analytics-admin-subscription-plans",
+			},
+			"reservations": {
+				"analytics-admin-reservations",
+				"analytics-restaurant-users",
+			},
+			"reviews": {
+				"analytics-admin-reviews",
+			},
+			"payments": {
+				"analytics-admin-payments",
+			},
+			"users": {
+				"analytics-admin-users",
+				"analytics-public-users",
+				"analytics-restaurant-users",
+			},
+			"menus": {
+				"analytics-public-menus",
+			},
+			"dishes": {
+				"analytics-public-dishes",
+			},
+		},
+	}
+	for _, endpoint := range endpoints {
+		registry.Endpoints[endpoint.Key] = endpoint
+	}
+	return registry
+}
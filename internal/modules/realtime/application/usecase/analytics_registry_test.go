@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mesaYaWs/internal/shared/entities"
+)
+
+func TestAnalyticsRegistry_DefaultsAndExampleAreValid(t *testing.T) {
+	defaults := DefaultAnalyticsRegistry(entities.Default())
+	if err := defaults.Validate(); err != nil {
+		t.Fatalf("default registry: %v", err)
+	}
+	if got := defaults.Key("owner", "restaurant-users"); got != "analytics-restaurant-users" {
+		t.Fatalf("Key(owner, restaurant-users) = %q", got)
+	}
+	if got := defaults.Key("admin", "Subscription_Plan"); got != "analytics-admin-subscription-plans" {
+		t.Fatalf("Key(admin, Subscription_Plan) = %q", got)
+	}
+
+	example, err := LoadAnalyticsRegistry(filepath.Join("..", "..", "..", "..", "..", "docs", "analytics", "analytics.example.json"), entities.Default())
+	if err != nil {
+		t.Fatalf("example file: %v", err)
+	}
+	if len(example.Endpoints) != len(defaults.Endpoints) || len(example.Dependencies) != len(defaults.Dependencies) {
+		t.Fatalf("example file drifted from the defaults: %d endpoints, %d dependencies", len(example.Endpoints), len(example.Dependencies))
+	}
+	if got := example.Endpoints["analytics-admin-payments"].RefreshInterval; got != defaults.Endpoints["analytics-admin-payments"].RefreshInterval {
+		t.Fatalf("example refresh interval = %s", got)
+	}
+}
+
+func TestLoadAnalyticsRegistry_RejectsBrokenReferences(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "analytics.json")
+	raw := `{
+		"endpoints": [
+			{"key": "analytics-admin-payments", "scope": "admin", "entity": "analytics-admin-payments", "path": "/api/v1/payments/analytics"},
+			{"key": "analytics-restaurant-users", "scope": "restaurant", "entity": "analytics-restaurant-users", "path": "/api/v1/users/analytics", "requiresIdentifier": true}
+		],
+		"entityAliases": {"admin": {"payment": "payments", "auth": "auth"}},
+		"eventAliases": {"payment": "payments", "auth": "users"},
+		"dependencies": {
+			"payments": ["analytics-admin-payments"],
+			"auth-users": ["analytics-admin-auth"]
+		}
+	}`
+	if err := os.WriteFile(file, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadAnalyticsRegistry(file, entities.Default())
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`analytics-restaurant-users: identifierParam is required`,
+		`entity alias admin/auth: no endpoint analytics-admin-auth`,
+		`dependency auth-users: unknown endpoint "analytics-admin-auth"`,
+		`event alias auth: entity "users" has no dependency entry`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestAnalyticsRegistry_ValidatesAgainstTheConfiguredEntities(t *testing.T) {
+	custom, err := entities.New([]entities.Definition{{Name: "mesas", Aliases: []string{"tables"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = DefaultAnalyticsRegistry(custom).Validate()
+	if err == nil || !strings.Contains(err.Error(), `dependency tables: unreachable, events normalize to "mesas"`) {
+		t.Fatalf("expected the configured alias to shadow tables, got %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "analytics.json")
+	raw := `{
+		"endpoints": [{"key": "analytics-admin-tables", "scope": "admin", "entity": "analytics-admin-tables", "path": "/api/v1/tables/analytics"}],
+		"dependencies": {"mesas": ["analytics-admin-tables"]}
+	}`
+	if err := os.WriteFile(file, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadAnalyticsRegistry(file, custom)
+	if err != nil {
+		t.Fatalf("file-defined entity rejected: %v", err)
+	}
+	if got := registry.EventEntity("TABLES"); got != "mesas" {
+		t.Fatalf("EventEntity(TABLES) = %q, want mesas", got)
+	}
+}
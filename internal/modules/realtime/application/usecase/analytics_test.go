@@ -1,8 +1,22 @@
 package usecase
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
 
-func TestNormalizeAnalyticsDependencyEntity(t *testing.T) {
+	"github.com/golang-jwt/jwt/v5"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
+)
+
+func TestAnalyticsRegistryEventEntity(t *testing.T) {
+	registry := DefaultAnalyticsRegistry(entities.Default())
 	cases := map[string]string{
 		"Restaurant":       "restaurants",
 		"section":          "sections",
@@ -23,8 +37,212 @@ func TestNormalizeAnalyticsDependencyEntity(t *testing.T) {
 	}
 
 	for input, expected := range cases {
-		if got := normalizeAnalyticsDependencyEntity(input); got != expected {
-			t.Fatalf("normalizeAnalyticsDependencyEntity(%q) = %q, expected %q", input, got, expected)
+		if got := registry.EventEntity(input); got != expected {
+			t.Fatalf("EventEntity(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+type countingAnalyticsFetcher struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *countingAnalyticsFetcher) Fetch(_ context.Context, _, _ string, _ map[string]string) (*domain.AnalyticsSnapshot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return &domain.AnalyticsSnapshot{Payload: map[string]int{"calls": f.calls}}, nil
+}
+
+func (f *countingAnalyticsFetcher) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+type collectingBroadcaster struct {
+	mu       sync.Mutex
+	messages []*domain.Message
+}
+
+func (b *collectingBroadcaster) Broadcast(_ context.Context, msg *domain.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.messages = append(b.messages, msg)
+}
+
+func TestAnalyticsRefreshDue_SkipsRecentlyRefreshedSessions(t *testing.T) {
+	fetcher := &countingAnalyticsFetcher{}
+	uc := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	broadcaster := &collectingBroadcaster{}
+	broadcastUC := NewBroadcastUseCase(broadcaster)
+
+	if !uc.SetRefreshInterval("analytics-admin-restaurants", time.Minute) {
+		t.Fatalf("expected known endpoint")
+	}
+	uc.RegisterSession("periodic", "analytics-admin-restaurants", "token", nil, domain.AnalyticsRequest{})
+	uc.RegisterSession("event-only", "analytics-admin-images", "token", nil, domain.AnalyticsRequest{})
+	now := time.Now()
+
+	uc.refreshDue(context.Background(), now.Add(30*time.Second), broadcastUC)
+	if fetcher.Calls() != 0 {
+		t.Fatalf("sessions fetched on connect must wait for the interval, got %d calls", fetcher.Calls())
+	}
+
+	uc.refreshDue(context.Background(), now.Add(61*time.Second), broadcastUC)
+	if fetcher.Calls() != 1 || len(broadcaster.messages) != 1 || broadcaster.messages[0].Metadata["sessionId"] != "periodic" {
+		t.Fatalf("expected one periodic refresh, got %d calls / %d messages", fetcher.Calls(), len(broadcaster.messages))
+	}
+
+	// An event refresh resets the interval.
+	uc.RefreshByEntity(context.Background(), "restaurants", broadcastUC)
+	calls := fetcher.Calls()
+	uc.refreshDue(context.Background(), time.Now().Add(30*time.Second), broadcastUC)
+	if fetcher.Calls() != calls {
+		t.Fatalf("session refreshed by an event must be skipped")
+	}
+}
+
+func TestAnalyticsRefresh_SharesFetchAcrossIdenticalSessions(t *testing.T) {
+	fetcher := &countingAnalyticsFetcher{}
+	uc := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	broadcaster := &collectingBroadcaster{}
+	broadcastUC := NewBroadcastUseCase(broadcaster)
+
+	admin := func(subject string) *auth.Claims {
+		return &auth.Claims{Roles: []string{"ADMIN"}, RegisteredClaims: jwt.RegisteredClaims{Subject: subject}}
+	}
+	owner := &auth.Claims{Roles: []string{"OWNER"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "owner-1"}}
+	today := domain.AnalyticsRequest{Query: map[string]string{"startDate": "2026-03-01"}}
+	uc.RegisterSession("admin-1", "analytics-admin-restaurants", "t1", admin("a-1"), today)
+	uc.RegisterSession("admin-2", "analytics-admin-restaurants", "t2", admin("a-2"), today)
+	uc.RegisterSession("admin-3", "analytics-admin-restaurants", "t3", admin("a-3"), domain.AnalyticsRequest{Query: map[string]string{"startDate": "2026-02-01"}})
+	uc.RegisterSession("owner-1", "analytics-admin-restaurants", "t4", owner, today)
+
+	uc.RefreshByEntity(context.Background(), "restaurants", broadcastUC)
+	if fetcher.Calls() != 3 {
+		t.Fatalf("expected one fetch per (request, audience) group, got %d", fetcher.Calls())
+	}
+	sessions := map[string]bool{}
+	for _, msg := range broadcaster.messages {
+		if msg.Metadata["analyticsKey"] == "analytics-admin-restaurants" {
+			sessions[msg.Metadata["sessionId"]] = true
+		}
+	}
+	if len(sessions) != 4 {
+		t.Fatalf("expected every session to receive the payload, got %v", sessions)
+	}
+
+	// Within the TTL a periodic refresh reuses the payload; an entity event does not.
+	uc.RefreshAll(context.Background(), broadcastUC)
+	if fetcher.Calls() != 3 {
+		t.Fatalf("expected cached payloads within the TTL, got %d fetches", fetcher.Calls())
+	}
+	uc.RefreshByEntity(context.Background(), "restaurants", broadcastUC)
+	if fetcher.Calls() != 6 {
+		t.Fatalf("entity events must bypass the cache, got %d fetches", fetcher.Calls())
+	}
+}
+
+type staticTokenValidator map[string]*auth.Claims
+
+func (v staticTokenValidator) Validate(token string) (*auth.Claims, error) {
+	if claims, ok := v[token]; ok {
+		return claims, nil
+	}
+	return nil, auth.ErrInvalidToken
+}
+
+type staticOwnership struct {
+	owned map[string][]string
+	calls int
+}
+
+func (o *staticOwnership) OwnedRestaurants(_ context.Context, token string) ([]string, error) {
+	o.calls++
+	return o.owned[token], nil
+}
+
+func TestAnalyticsAuthorization_RolesAndOwnership(t *testing.T) {
+	validator := staticTokenValidator{
+		"user":  {Roles: []string{"USER"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "u-1"}},
+		"owner": {Roles: []string{"OWNER"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "o-1"}},
+		"admin": {Roles: []string{"admin"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "a-1"}},
+	}
+	ownership := &staticOwnership{owned: map[string][]string{"owner": {"rest-1"}}}
+	uc := NewAnalyticsUseCase(validator, &countingAnalyticsFetcher{}, entities.Default())
+	uc.SetOwnershipResolver(ownership)
+	ctx := context.Background()
+	restaurant := func(id string) domain.AnalyticsRequest { return domain.AnalyticsRequest{Identifier: id} }
+
+	if _, err := uc.Connect(ctx, "analytics-admin-payments", "user", domain.AnalyticsRequest{}); !errors.Is(err, port.ErrAnalyticsForbidden) {
+		t.Fatalf("USER on an admin dashboard: expected forbidden, got %v", err)
+	}
+	if _, err := uc.Connect(ctx, "analytics-admin-payments", "admin", domain.AnalyticsRequest{}); err != nil {
+		t.Fatalf("ADMIN on an admin dashboard: %v", err)
+	}
+	if _, err := uc.Connect(ctx, "analytics-restaurant-users", "user", restaurant("rest-1")); !errors.Is(err, port.ErrAnalyticsForbidden) {
+		t.Fatalf("USER on a restaurant dashboard: expected forbidden, got %v", err)
+	}
+	if _, err := uc.Connect(ctx, "analytics-restaurant-users", "owner", restaurant("rest-2")); !errors.Is(err, port.ErrAnalyticsForbidden) {
+		t.Fatalf("OWNER on a foreign restaurant: expected forbidden, got %v", err)
+	}
+	output, err := uc.Connect(ctx, "analytics-restaurant-users", "owner", restaurant("rest-1"))
+	if err != nil {
+		t.Fatalf("OWNER on an owned restaurant: %v", err)
+	}
+	if _, err := uc.Connect(ctx, "analytics-restaurant-users", "admin", restaurant("rest-2")); err != nil {
+		t.Fatalf("ADMIN on any restaurant: %v", err)
+	}
+
+	calls := ownership.calls
+	if _, _, err := uc.HandleCommand(ctx, "analytics-restaurant-users", "owner", output.Request, domain.AnalyticsCommand{}); err != nil {
+		t.Fatalf("refresh of the same restaurant: %v", err)
+	}
+	if ownership.calls != calls {
+		t.Fatalf("commands keeping the restaurant must not re-check ownership")
+	}
+	if _, _, err := uc.HandleCommand(ctx, "analytics-restaurant-users", "owner", output.Request, domain.AnalyticsCommand{Identifier: "rest-2"}); !errors.Is(err, port.ErrAnalyticsForbidden) {
+		t.Fatalf("command switching to a foreign restaurant: expected forbidden, got %v", err)
+	}
+}
+
+func TestAnalyticsSession_WatchesSeveralKeys(t *testing.T) {
+	fetcher := &countingAnalyticsFetcher{}
+	uc := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	uc.SetSharedFetchTTL(0)
+	broadcaster := &collectingBroadcaster{}
+	broadcastUC := NewBroadcastUseCase(broadcaster)
+
+	uc.RegisterSession("overview", "analytics-admin-restaurants", "token", nil, domain.AnalyticsRequest{})
+	uc.RegisterSession("overview", "analytics-admin-payments", "token", nil, domain.AnalyticsRequest{Query: map[string]string{"restaurantId": "rest-1"}})
+
+	uc.RefreshAll(context.Background(), broadcastUC)
+	keys := map[string]bool{}
+	for _, msg := range broadcaster.messages {
+		if msg.Metadata["sessionId"] != "overview" {
+			t.Fatalf("unexpected session metadata: %v", msg.Metadata)
 		}
+		keys[msg.Metadata["analyticsKey"]] = true
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected both watched keys refreshed, got %v", keys)
+	}
+
+	if !uc.UnwatchSession("overview", "analytics-admin-payments") || uc.UnwatchSession("overview", "analytics-admin-payments") {
+		t.Fatalf("unwatch must remove the key once")
+	}
+	calls := fetcher.Calls()
+	uc.RefreshAll(context.Background(), broadcastUC)
+	if fetcher.Calls() != calls+1 {
+		t.Fatalf("expected only the remaining key refreshed, got %d fetches", fetcher.Calls()-calls)
+	}
+
+	uc.UnregisterSession("overview")
+	calls = fetcher.Calls()
+	uc.RefreshAll(context.Background(), broadcastUC)
+	if fetcher.Calls() != calls {
+		t.Fatalf("closing the connection must drop every watched key")
 	}
 }
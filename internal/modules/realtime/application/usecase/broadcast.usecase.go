@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"log/slog"
 
 	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
@@ -9,12 +10,46 @@ import (
 
 type BroadcastUseCase struct {
 	broadcaster port.Broadcaster
+	observers   []port.Broadcaster
 }
 
-func NewBroadcastUseCase(b port.Broadcaster) *BroadcastUseCase {
-	return &BroadcastUseCase{broadcaster: b}
+// NewBroadcastUseCase sends messages through b. Observers (e.g. outbound webhooks) receive
+// every message that reaches the clients.
+func NewBroadcastUseCase(b port.Broadcaster, observers ...port.Broadcaster) *BroadcastUseCase {
+	return &BroadcastUseCase{broadcaster: b, observers: observers}
 }
 
 func (uc *BroadcastUseCase) Execute(ctx context.Context, msg *domain.Message) {
+	if IsCatchUp(ctx) {
+		slog.Debug("broadcast suppressed during catch-up", slog.String("topic", msg.Topic), slog.String("resourceId", msg.ResourceID))
+		return
+	}
 	uc.broadcaster.Broadcast(ctx, msg)
+	for _, observer := range uc.observers {
+		if observer != nil {
+			observer.Broadcast(ctx, msg)
+		}
+	}
+}
+
+// ExecuteTargeted sends msg only to the clients matching target and returns how many received
+// it. Observers still receive the message. When the broadcaster cannot target clients, the
+// message is broadcast as usual and the count is unknown (-1).
+func (uc *BroadcastUseCase) ExecuteTargeted(ctx context.Context, msg *domain.Message, target port.BroadcastTarget) int {
+	if IsCatchUp(ctx) {
+		slog.Debug("broadcast suppressed during catch-up", slog.String("topic", msg.Topic), slog.String("resourceId", msg.ResourceID))
+		return 0
+	}
+	delivered := -1
+	if targeted, ok := uc.broadcaster.(port.TargetedBroadcaster); ok {
+		delivered = targeted.BroadcastTo(ctx, msg, target)
+	} else {
+		uc.broadcaster.Broadcast(ctx, msg)
+	}
+	for _, observer := range uc.observers {
+		if observer != nil {
+			observer.Broadcast(ctx, msg)
+		}
+	}
+	return delivered
 }
@@ -0,0 +1,24 @@
+package usecase
+
+import "context"
+
+type catchUpKey struct{}
+
+// WithCatchUp marks ctx as processing a stale event replayed after downtime. Such events keep
+// state consistent without side effects:
+//   - BroadcastUseCase sends nothing, so neither clients nor its observers (inbox) receive
+//     the event; outbound webhooks are a topic handler and still receive it;
+//   - ConnectSectionUseCase drops the affected cached snapshots instead of refetching them;
+//   - AnalyticsUseCase updates live counters and invalidates its shared cache, without REST calls.
+func WithCatchUp(ctx context.Context) context.Context {
+	return context.WithValue(ctx, catchUpKey{}, true)
+}
+
+// IsCatchUp reports whether ctx was marked by WithCatchUp.
+func IsCatchUp(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	flag, _ := ctx.Value(catchUpKey{}).(bool)
+	return flag
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+)
+
+func TestCatchUp_UpdatesStateWithoutSideEffects(t *testing.T) {
+	ctx := WithCatchUp(context.Background())
+	broadcaster := &collectingBroadcaster{}
+	observer := &collectingBroadcaster{}
+	broadcastUC := NewBroadcastUseCase(broadcaster, observer)
+
+	broadcastUC.Execute(ctx, &domain.Message{Topic: "tables.updated"})
+	broadcastUC.ExecuteTargeted(ctx, &domain.Message{Topic: "tables.updated"}, port.BroadcastTarget{UserIDs: []string{"u-1"}})
+
+	// Cached snapshots are dropped instead of refetched.
+	fetches := 0
+	connectUC := &ConnectSectionUseCase{SnapshotFetcher: &mockSnapshotFetcher{listFn: func(context.Context, string, string, port.SnapshotContext, domain.PagedQuery) (*domain.SectionSnapshot, error) {
+		fetches++
+		return &domain.SectionSnapshot{}, nil
+	}}, cache: newSnapshotCache()}
+	options := newPagedQuery(0, 0, "", "", "", nil).Normalize("")
+	connectUC.cache.set("section-1", "tables", cacheKindList, options, "", "token", port.SnapshotAudienceAdmin, &domain.SectionSnapshot{})
+	connectUC.RefreshSectionSnapshots(ctx, "tables", "section-1", broadcastUC)
+	if fetches != 0 {
+		t.Fatalf("catch-up must not refetch snapshots, got %d fetches", fetches)
+	}
+	if _, ok := connectUC.cache.get("section-1", "tables", cacheKindList, options, "", port.SnapshotAudienceAdmin); ok {
+		t.Fatal("stale snapshot must be dropped from the cache")
+	}
+
+	// Analytics sessions are not refreshed through the REST API.
+	fetcher := &countingAnalyticsFetcher{}
+	analyticsUC := NewAnalyticsUseCase(nil, fetcher, entities.Default())
+	analyticsUC.RegisterSession("s-1", "analytics-admin-restaurants", "token", nil, domain.AnalyticsRequest{})
+	analyticsUC.RefreshByEntity(ctx, "restaurants", broadcastUC)
+	if fetcher.Calls() != 0 {
+		t.Fatalf("catch-up must not refresh analytics, got %d fetches", fetcher.Calls())
+	}
+
+	if len(broadcaster.messages) != 0 || len(observer.messages) != 0 {
+		t.Fatalf("catch-up must not push anything, got %d messages / %d observed", len(broadcaster.messages), len(observer.messages))
+	}
+
+	// Outside catch-up the same event refreshes the dashboard.
+	analyticsUC.RefreshByEntity(context.Background(), "restaurants", broadcastUC)
+	if fetcher.Calls() != 1 || len(broadcaster.messages) != 1 {
+		t.Fatalf("expected a refresh outside catch-up, got %d fetches / %d messages", fetcher.Calls(), len(broadcaster.messages))
+	}
+}
@@ -61,6 +61,22 @@ func (uc *ConnectSectionUseCase) Execute(ctx context.Context, input ConnectSecti
 	return &ConnectSectionOutput{Claims: claims, Snapshot: nil}, nil
 }
 
+// Authenticate validates the token of a connection that joins its sections later on (/ws).
+func (uc *ConnectSectionUseCase) Authenticate(token string) (*auth.Claims, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, ErrMissingToken
+	}
+	claims, err := uc.Validator.Validate(token)
+	if err != nil {
+		slog.Warn("connect token validation failed", slog.Any("error", err))
+		return nil, err
+	}
+	return claims, nil
+}
+
+// RefreshSectionSnapshots refetches the cached snapshots of sectionID and pushes them. During
+// catch-up the entries are dropped instead, so the next command reads the REST API again
+// without refetching once per replayed event.
 func (uc *ConnectSectionUseCase) RefreshSectionSnapshots(ctx context.Context, entity, sectionID string, broadcaster *BroadcastUseCase) {
 	entries := uc.cache.entriesForSection(sectionID)
 	if len(entries) == 0 {
@@ -70,6 +86,10 @@ func (uc *ConnectSectionUseCase) RefreshSectionSnapshots(ctx context.Context, en
 		if entity != "" && !strings.EqualFold(entry.scope, entity) {
 			continue
 		}
+		if IsCatchUp(ctx) {
+			uc.cache.delete(sectionID, entry.scope, entry.kind, entry.listOptions, entry.resourceID, entry.audience)
+			continue
+		}
 		switch entry.kind {
 		case cacheKindItem:
 			uc.refreshItem(ctx, entry.scope, sectionID, entry, broadcaster)
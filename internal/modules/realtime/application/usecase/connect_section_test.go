@@ -167,7 +167,7 @@ func TestConnectSectionUseCase_HandleListCommandServesCache(t *testing.T) {
 func TestConnectSectionUseCase_HandleDetailCommandSuccess(t *testing.T) {
 	t.Parallel()
 
-	snapshot := &domain.SectionSnapshot{Payload: map[string]string{"key": "value"}}
+	snapshot := &domain.SectionSnapshot{Payload: map[string]string{"key": "value"}, ResourceKey: "tableId"}
 	snapshotCtx := newSnapshotCtx("section-42")
 
 	fetcher := &mockSnapshotFetcher{detailFn: func(ctx context.Context, token, entity string, ctxSnapshot port.SnapshotContext, resourceID string) (*domain.SectionSnapshot, error) {
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+)
+
+var (
+	// ErrEmitUnsupported is returned for websocket actions that are not configured to be emitted.
+	ErrEmitUnsupported = errors.New("emit action unsupported")
+	// ErrEmitInvalidPayload is returned when the command payload is not a JSON object.
+	ErrEmitInvalidPayload = errors.New("emit payload must be a JSON object")
+)
+
+// EmitEventInput describes a client-originated action received over the websocket.
+type EmitEventInput struct {
+	Entity    string
+	Action    string
+	SectionID string
+	Claims    *auth.Claims
+	Payload   json.RawMessage
+}
+
+// EmitEventUseCase turns websocket commands (table selection, presence, acknowledgements)
+// into domain events published to Kafka so the backend can react to them.
+type EmitEventUseCase struct {
+	publisher    port.EventPublisher
+	defaultTopic string
+	topics       map[string]string
+	actions      map[string]struct{}
+}
+
+// NewEmitEventUseCase publishes the listed actions to defaultTopic unless topics maps the
+// action to a dedicated topic.
+func NewEmitEventUseCase(publisher port.EventPublisher, defaultTopic string, topics map[string]string, actions []string) *EmitEventUseCase {
+	actionSet := make(map[string]struct{}, len(actions))
+	for _, action := range actions {
+		if normalized := strings.ToLower(strings.TrimSpace(action)); normalized != "" {
+			actionSet[normalized] = struct{}{}
+		}
+	}
+	topicMap := make(map[string]string, len(topics))
+	for action, topic := range topics {
+		action = strings.ToLower(strings.TrimSpace(action))
+		topic = strings.TrimSpace(topic)
+		if action != "" && topic != "" {
+			topicMap[action] = topic
+		}
+	}
+	return &EmitEventUseCase{
+		publisher:    publisher,
+		defaultTopic: strings.TrimSpace(defaultTopic),
+		topics:       topicMap,
+		actions:      actionSet,
+	}
+}
+
+// Accepts reports whether the websocket action is emitted as an event.
+func (uc *EmitEventUseCase) Accepts(action string) bool {
+	if uc == nil || uc.publisher == nil {
+		return false
+	}
+	_, ok := uc.actions[strings.ToLower(strings.TrimSpace(action))]
+	return ok
+}
+
+// Execute publishes the action. Events are keyed by resource (or section) so every event about
+// the same table/reservation lands on the same partition and keeps its order.
+func (uc *EmitEventUseCase) Execute(ctx context.Context, in EmitEventInput) (*domain.Message, error) {
+	action := strings.ToLower(strings.TrimSpace(in.Action))
+	if !uc.Accepts(action) {
+		return nil, ErrEmitUnsupported
+	}
+
+	data := map[string]any{}
+	if len(in.Payload) > 0 {
+		if err := json.Unmarshal(in.Payload, &data); err != nil {
+			return nil, ErrEmitInvalidPayload
+		}
+	}
+	resourceID := firstPayloadString(data, "resourceId", "id")
+	sectionID := strings.TrimSpace(in.SectionID)
+
+	metadata := map[string]string{"source": "websocket"}
+	if sectionID != "" {
+		metadata["sectionId"] = sectionID
+	}
+	if in.Claims != nil {
+		metadata["userId"] = in.Claims.RegisteredClaims.Subject
+		metadata["sessionId"] = in.Claims.SessionID
+	}
+
+	entity := strings.TrimSpace(in.Entity)
+	msg := &domain.Message{
+		Topic:      domain.CustomTopic(entity, action),
+		Entity:     entity,
+		Action:     action,
+		ResourceID: resourceID,
+		Data:       data,
+		Metadata:   metadata,
+		Timestamp:  time.Now().UTC(),
+	}
+
+	topic := uc.defaultTopic
+	if override, ok := uc.topics[action]; ok {
+		topic = override
+	}
+	key := entity + ":" + firstNonEmptyString(resourceID, sectionID)
+	if err := uc.publisher.Publish(ctx, topic, key, msg); err != nil {
+		slog.Warn("emit event publish failed", slog.String("topic", topic), slog.String("entity", entity), slog.String("action", action), slog.String("resourceId", resourceID), slog.Any("error", err))
+		return nil, err
+	}
+	slog.Debug("emit event published", slog.String("topic", topic), slog.String("entity", entity), slog.String("action", action), slog.String("key", key))
+	return msg, nil
+}
+
+func firstPayloadString(data map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := data[key].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+)
+
+type capturedPublish struct {
+	topic string
+	key   string
+	msg   *domain.Message
+}
+
+type fakePublisher struct {
+	published []capturedPublish
+}
+
+func (p *fakePublisher) Publish(_ context.Context, topic, key string, msg *domain.Message) error {
+	p.published = append(p.published, capturedPublish{topic: topic, key: key, msg: msg})
+	return nil
+}
+
+func TestEmitEventUseCase_PublishesKeyedEventToConfiguredTopic(t *testing.T) {
+	publisher := &fakePublisher{}
+	uc := NewEmitEventUseCase(publisher, "mesa-ya.realtime.events", map[string]string{"ack": "mesa-ya.realtime.acks"}, []string{"table_selected", "ack"})
+	claims := &auth.Claims{SessionID: "s-1", RegisteredClaims: jwt.RegisteredClaims{Subject: "u-1"}}
+
+	if uc.Accepts("list") {
+		t.Fatalf("list must not be emitted")
+	}
+	msg, err := uc.Execute(context.Background(), EmitEventInput{Entity: "tables", Action: "TABLE_SELECTED", SectionID: "sec-1", Claims: claims, Payload: json.RawMessage(`{"id":"t-7"}`)})
+	if err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if msg.Topic != "tables.table_selected" || msg.ResourceID != "t-7" || msg.Metadata["userId"] != "u-1" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if _, err := uc.Execute(context.Background(), EmitEventInput{Entity: "tables", Action: "ack", SectionID: "sec-1"}); err != nil {
+		t.Fatalf("ack emit failed: %v", err)
+	}
+	if len(publisher.published) != 2 {
+		t.Fatalf("expected 2 published events, got %d", len(publisher.published))
+	}
+	if got := publisher.published[0]; got.topic != "mesa-ya.realtime.events" || got.key != "tables:t-7" {
+		t.Fatalf("unexpected first publish: %s %s", got.topic, got.key)
+	}
+	if got := publisher.published[1]; got.topic != "mesa-ya.realtime.acks" || got.key != "tables:sec-1" {
+		t.Fatalf("unexpected ack publish: %s %s", got.topic, got.key)
+	}
+	if _, err := uc.Execute(context.Background(), EmitEventInput{Entity: "tables", Action: "ack", Payload: json.RawMessage(`[1]`)}); !errors.Is(err, ErrEmitInvalidPayload) {
+		t.Fatalf("expected invalid payload error, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
+	"mesaYaWs/internal/shared/normalization"
+)
+
+// notificationScope says who may receive the notifications of an entity.
+type notificationScope int
+
+const (
+	// notificationRestricted events only reach admins. It is the scope of the entities missing
+	// from notificationScopes, so a new entity is never broadcast to everyone by omission.
+	notificationRestricted notificationScope = iota
+	// notificationPublic events reach every subscriber (e.g. a restaurant was published).
+	notificationPublic
+	// notificationPersonal events reach the user they belong to and the restaurant owner.
+	notificationPersonal
+	// notificationRestaurant events only reach the restaurant owner.
+	notificationRestaurant
+)
+
+var notificationScopes = map[string]notificationScope{
+	"restaurants":   notificationPublic,
+	"reservations":  notificationPersonal,
+	"reviews":       notificationPersonal,
+	"payments":      notificationPersonal,
+	"subscriptions": notificationPersonal,
+	"tables":        notificationRestaurant,
+	"sections":      notificationRestaurant,
+	"menus":         notificationRestaurant,
+	"dishes":        notificationRestaurant,
+}
+
+// NotificationAudience filters /ws/notifications per recipient: guests only receive the events
+// of their own reservations and reviews, and owners those of the restaurants they own. Event
+// ownership comes from the userId, ownerId and restaurantId of the event metadata or data.
+type NotificationAudience struct {
+	ownership port.RestaurantOwnershipResolver
+	entities  *entities.Registry
+}
+
+// NewNotificationAudience creates the filter; ownership resolves the restaurants of OWNER
+// recipients (nil only matches events carrying their ownerId) and registry the event entities.
+func NewNotificationAudience(ownership port.RestaurantOwnershipResolver, registry *entities.Registry) *NotificationAudience {
+	return &NotificationAudience{ownership: ownership, entities: registry}
+}
+
+// NotificationRecipient is the audience of one notifications connection.
+type NotificationRecipient struct {
+	userID   string
+	admin    bool
+	owner    bool
+	entities *entities.Registry
+
+	mu          sync.RWMutex
+	restaurants map[string]struct{}
+}
+
+// Recipient resolves the audience of a connection once, looking up the restaurants owned by
+// OWNER users. A failed lookup is logged and leaves the owner with the events carrying its ownerId.
+func (a *NotificationAudience) Recipient(ctx context.Context, token string, claims *auth.Claims) *NotificationRecipient {
+	recipient := &NotificationRecipient{restaurants: make(map[string]struct{})}
+	if a != nil {
+		recipient.entities = a.entities
+	}
+	if claims == nil {
+		return recipient
+	}
+	recipient.userID = strings.TrimSpace(claims.RegisteredClaims.Subject)
+	recipient.admin = hasAnalyticsRole(claims, analyticsAdminRole)
+	recipient.owner = hasAnalyticsRole(claims, "OWNER")
+	if !recipient.owner || recipient.admin || a == nil || a.ownership == nil {
+		return recipient
+	}
+	owned, err := a.ownership.OwnedRestaurants(ctx, token)
+	if err != nil {
+		slog.Warn("notifications owned restaurants lookup failed", slog.String("userId", recipient.userID), slog.Any("error", err))
+		return recipient
+	}
+	for _, id := range owned {
+		if id = strings.TrimSpace(id); id != "" {
+			recipient.restaurants[id] = struct{}{}
+		}
+	}
+	return recipient
+}
+
+// Allows reports whether msg may be delivered to the recipient.
+func (r *NotificationRecipient) Allows(msg *domain.Message) bool {
+	if msg == nil {
+		return false
+	}
+	if r.admin {
+		return true
+	}
+	data := normalization.MapFromPayload(msg.Data)
+	userID := notificationField("userId", msg.Metadata, data)
+	ownerID := notificationField("ownerId", msg.Metadata, data)
+	restaurantID := notificationField("restaurantId", msg.Metadata, data)
+
+	entity := notificationEntity(r.entities, msg)
+	if entity == "restaurants" && r.owner && ownerID != "" && ownerID == r.userID {
+		// Restaurants created after connecting join the owned set.
+		if id := strings.TrimSpace(msg.ResourceID); id != "" {
+			r.addRestaurant(id)
+		} else if id := normalization.AsString(data["id"]); id != "" {
+			r.addRestaurant(id)
+		}
+	}
+
+	switch notificationScopes[entity] {
+	case notificationPersonal:
+		if userID != "" && userID == r.userID {
+			return true
+		}
+		return r.ownsEvent(ownerID, restaurantID)
+	case notificationRestaurant:
+		return r.ownsEvent(ownerID, restaurantID)
+	case notificationPublic:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *NotificationRecipient) ownsEvent(ownerID, restaurantID string) bool {
+	if !r.owner {
+		return false
+	}
+	if ownerID != "" && ownerID == r.userID {
+		return true
+	}
+	if restaurantID == "" {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.restaurants[restaurantID]
+	return ok
+}
+
+func (r *NotificationRecipient) addRestaurant(id string) {
+	r.mu.Lock()
+	r.restaurants[id] = struct{}{}
+	r.mu.Unlock()
+}
+
+// notificationEntity returns the canonical entity of msg in registry, falling back to the topic
+// prefix (e.g. "payment.approved" from n8n workflows).
+func notificationEntity(registry *entities.Registry, msg *domain.Message) string {
+	entity := strings.TrimSpace(msg.Entity)
+	if entity == "" {
+		entity, _, _ = strings.Cut(msg.Topic, ".")
+	}
+	return registry.Normalize(entity)
+}
+
+func notificationField(field string, metadata map[string]string, data map[string]any) string {
+	if value := strings.TrimSpace(metadata[field]); value != "" {
+		return value
+	}
+	return normalization.AsString(data[field])
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
+)
+
+func TestNotificationAudience_FiltersPerRecipient(t *testing.T) {
+	audience := NewNotificationAudience(&staticOwnership{owned: map[string][]string{"owner-token": {"rest-1"}}}, entities.Default())
+	ctx := context.Background()
+	guest := audience.Recipient(ctx, "guest-token", &auth.Claims{Roles: []string{"USER"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "guest-1"}})
+	owner := audience.Recipient(ctx, "owner-token", &auth.Claims{Roles: []string{"OWNER"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "owner-1"}})
+	admin := audience.Recipient(ctx, "admin-token", &auth.Claims{Roles: []string{"ADMIN"}, RegisteredClaims: jwt.RegisteredClaims{Subject: "admin-1"}})
+
+	own := &domain.Message{Topic: "reservations.created", Entity: "reservations", Data: map[string]any{"userId": "guest-1", "restaurantId": "rest-1"}}
+	other := &domain.Message{Topic: "reservations.created", Entity: "reservations", Data: map[string]any{"userId": "guest-2", "restaurantId": "rest-2"}}
+	review := &domain.Message{Topic: "reviews.created", Entity: "reviews", Metadata: map[string]string{"ownerId": "owner-1"}, Data: map[string]any{"userId": "guest-2"}}
+	published := &domain.Message{Topic: "restaurants.created", Entity: "restaurants", ResourceID: "rest-3", Data: map[string]any{"ownerId": "owner-1"}}
+	later := &domain.Message{Topic: "payment.approved", Data: map[string]any{"userId": "guest-3", "restaurantId": "rest-3"}}
+	unlisted := &domain.Message{Topic: "users.created", Entity: "users", Data: map[string]any{"userId": "guest-1"}}
+
+	cases := []struct {
+		name      string
+		recipient *NotificationRecipient
+		msg       *domain.Message
+		want      bool
+	}{
+		{"guest own reservation", guest, own, true},
+		{"guest other reservation", guest, other, false},
+		{"guest other review", guest, review, false},
+		{"guest public restaurant", guest, published, true},
+		{"owner restaurant reservation", owner, own, true},
+		{"owner foreign reservation", owner, other, false},
+		{"owner review by ownerId", owner, review, true},
+		{"owner restaurant created after connecting", owner, published, true},
+		{"owner payment of the new restaurant", owner, later, true},
+		{"admin foreign reservation", admin, other, true},
+		{"guest entity without scope", guest, unlisted, false},
+		{"owner entity without scope", owner, unlisted, false},
+		{"admin entity without scope", admin, unlisted, true},
+	}
+	for _, tc := range cases {
+		if got := tc.recipient.Allows(tc.msg); got != tc.want {
+			t.Errorf("%s: Allows = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
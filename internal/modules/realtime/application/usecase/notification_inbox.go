@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+	"mesaYaWs/internal/shared/normalization"
+)
+
+// NotificationsEntity is the entity of the inbox messages sent on /ws/notifications.
+const NotificationsEntity = "notifications"
+
+// Inbox message actions.
+const (
+	NotificationActionUnread      = "unread"
+	NotificationActionList        = "list"
+	NotificationActionUnreadCount = "unread_count"
+)
+
+// NotificationInbox stores the notifications of every user so the ones sent while offline are
+// delivered on the next connection. It observes BroadcastUseCase: personal events are kept for
+// their userId and ownerId, restaurant events for their ownerId, and the connected recipients
+// get their new unread count. Notifications muted by a recipient are not kept for it.
+type NotificationInbox struct {
+	store       port.NotificationStore
+	broadcaster port.TargetedBroadcaster
+	preferences port.NotificationPreferenceStore
+	entities    *entities.Registry
+	now         func() time.Time
+}
+
+// NewNotificationInbox creates the inbox; broadcaster (optional) pushes the unread counts and
+// registry resolves the entity of every notification.
+func NewNotificationInbox(store port.NotificationStore, broadcaster port.TargetedBroadcaster, registry *entities.Registry) *NotificationInbox {
+	return &NotificationInbox{store: store, broadcaster: broadcaster, entities: registry, now: time.Now}
+}
+
+// SetPreferences skips the recipients that muted a notification.
+func (i *NotificationInbox) SetPreferences(preferences port.NotificationPreferenceStore) {
+	i.preferences = preferences
+}
+
+// Broadcast stores msg in the inbox of its recipients.
+func (i *NotificationInbox) Broadcast(ctx context.Context, msg *domain.Message) {
+	recipients := notificationRecipients(i.entities, msg)
+	if len(recipients) == 0 {
+		return
+	}
+	notification := port.StoredNotification{ID: notificationID(msg), Message: msg, CreatedAt: i.now().UTC()}
+	for _, userID := range recipients {
+		if i.muted(ctx, userID, msg) {
+			continue
+		}
+		if err := i.store.Append(ctx, userID, notification); err != nil {
+			slog.Error("notification inbox append failed", slog.String("userId", userID), slog.String("topic", msg.Topic), slog.Any("error", err))
+			continue
+		}
+		if i.broadcaster == nil {
+			continue
+		}
+		count, err := i.UnreadCount(ctx, userID)
+		if err != nil {
+			slog.Warn("notification inbox unread count failed", slog.String("userId", userID), slog.Any("error", err))
+			continue
+		}
+		i.broadcaster.BroadcastTo(ctx, count, port.BroadcastTarget{UserIDs: []string{userID}})
+	}
+}
+
+func (i *NotificationInbox) muted(ctx context.Context, userID string, msg *domain.Message) bool {
+	if i.preferences == nil {
+		return false
+	}
+	prefs, err := i.preferences.Get(ctx, userID)
+	if err != nil {
+		slog.Warn("notification inbox preferences unavailable", slog.String("userId", userID), slog.Any("error", err))
+		return false
+	}
+	return notificationMuted(i.entities, prefs, msg.Topic, notificationEntity(i.entities, msg))
+}
+
+// Unread returns the message delivered on connect: the unread notifications of userID (newest
+// first, at most limit) and the unread count.
+func (i *NotificationInbox) Unread(ctx context.Context, userID string, limit int) (*domain.Message, error) {
+	return i.List(ctx, userID, limit, true, NotificationActionUnread)
+}
+
+// List returns the notifications of userID as an action message (list or unread).
+func (i *NotificationInbox) List(ctx context.Context, userID string, limit int, unreadOnly bool, action string) (*domain.Message, error) {
+	items, err := i.store.List(ctx, userID, limit, unreadOnly)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := i.store.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return i.message(userID, action, map[string]any{"items": items, "unreadCount": unread}), nil
+}
+
+// MarkRead marks ids (every notification when empty) as read and returns the new unread count.
+func (i *NotificationInbox) MarkRead(ctx context.Context, userID string, ids []string) (*domain.Message, error) {
+	if _, err := i.store.MarkRead(ctx, userID, ids, i.now().UTC()); err != nil {
+		return nil, err
+	}
+	return i.UnreadCount(ctx, userID)
+}
+
+// UnreadCount returns the unread-count message of userID.
+func (i *NotificationInbox) UnreadCount(ctx context.Context, userID string) (*domain.Message, error) {
+	unread, err := i.store.UnreadCount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return i.message(userID, NotificationActionUnreadCount, map[string]any{"unreadCount": unread}), nil
+}
+
+func (i *NotificationInbox) message(userID, action string, data map[string]any) *domain.Message {
+	return &domain.Message{
+		Topic:     domain.CustomTopic(NotificationsEntity, action),
+		Entity:    NotificationsEntity,
+		Action:    action,
+		Metadata:  map[string]string{"userId": userID},
+		Data:      data,
+		Timestamp: i.now().UTC(),
+	}
+}
+
+// notificationRecipients returns the users whose inbox keeps msg. Restaurant owners are only
+// known through the ownerId of the event: the REST ownership lookup needs their token.
+func notificationRecipients(registry *entities.Registry, msg *domain.Message) []string {
+	if msg == nil {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(msg.Action)) {
+	case domain.ActionSnapshot, domain.ActionList, domain.ActionDetail, domain.ActionError:
+		return nil
+	}
+	data := normalization.MapFromPayload(msg.Data)
+	var recipients []string
+	switch notificationScopes[notificationEntity(registry, msg)] {
+	case notificationPersonal:
+		recipients = append(recipients, notificationField("userId", msg.Metadata, data), notificationField("ownerId", msg.Metadata, data))
+	case notificationRestaurant:
+		recipients = append(recipients, notificationField("ownerId", msg.Metadata, data))
+	default:
+		return nil
+	}
+	unique := recipients[:0]
+	for _, userID := range recipients {
+		if userID != "" && (len(unique) == 0 || unique[0] != userID) {
+			unique = append(unique, userID)
+		}
+	}
+	return unique
+}
+
+// notificationID reuses the event id so a redelivered event is stored once.
+func notificationID(msg *domain.Message) string {
+	if id := strings.TrimSpace(msg.Metadata["eventId"]); id != "" {
+		return id
+	}
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "ntf-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "ntf-" + hex.EncodeToString(raw[:])
+}
+
+var _ port.Broadcaster = (*NotificationInbox)(nil)
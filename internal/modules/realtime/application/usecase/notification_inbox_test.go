@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+)
+
+// memoryNotificationStore is a port.NotificationStore fake, newest notification first.
+type memoryNotificationStore struct {
+	mu    sync.Mutex
+	users map[string][]port.StoredNotification
+}
+
+func newMemoryNotificationStore() *memoryNotificationStore {
+	return &memoryNotificationStore{users: make(map[string][]port.StoredNotification)}
+}
+
+func (s *memoryNotificationStore) Append(_ context.Context, userID string, notification port.StoredNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, item := range s.users[userID] {
+		if item.ID == notification.ID {
+			return nil
+		}
+	}
+	s.users[userID] = append([]port.StoredNotification{notification}, s.users[userID]...)
+	return nil
+}
+
+func (s *memoryNotificationStore) List(_ context.Context, userID string, limit int, unreadOnly bool) ([]port.StoredNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var listed []port.StoredNotification
+	for _, item := range s.users[userID] {
+		if unreadOnly && item.ReadAt != nil {
+			continue
+		}
+		if listed = append(listed, item); limit > 0 && len(listed) == limit {
+			break
+		}
+	}
+	return listed, nil
+}
+
+func (s *memoryNotificationStore) MarkRead(_ context.Context, userID string, ids []string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := 0
+	for i, item := range s.users[userID] {
+		if item.ReadAt == nil && (len(ids) == 0 || slices.Contains(ids, item.ID)) {
+			s.users[userID][i].ReadAt = &at
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (s *memoryNotificationStore) UnreadCount(ctx context.Context, userID string) (int, error) {
+	unread, err := s.List(ctx, userID, 0, true)
+	return len(unread), err
+}
+
+type countingTargetedBroadcaster struct {
+	targets []port.BroadcastTarget
+}
+
+func (b *countingTargetedBroadcaster) BroadcastTo(_ context.Context, _ *domain.Message, target port.BroadcastTarget) int {
+	b.targets = append(b.targets, target)
+	return 1
+}
+
+func TestNotificationInbox_StoresForEventOwners(t *testing.T) {
+	pushed := &countingTargetedBroadcaster{}
+	notifications := NewNotificationInbox(newMemoryNotificationStore(), pushed, entities.Default())
+	ctx := context.Background()
+
+	notifications.Broadcast(ctx, &domain.Message{Topic: "reservations.created", Entity: "reservations", Metadata: map[string]string{"eventId": "evt-1"}, Data: map[string]any{"userId": "guest-1", "ownerId": "owner-1"}})
+	notifications.Broadcast(ctx, &domain.Message{Topic: "reservations.created", Entity: "reservations", Metadata: map[string]string{"eventId": "evt-1"}, Data: map[string]any{"userId": "guest-1", "ownerId": "owner-1"}})
+	notifications.Broadcast(ctx, &domain.Message{Topic: "restaurants.updated", Entity: "restaurants", Data: map[string]any{"ownerId": "owner-1"}})
+	notifications.Broadcast(ctx, &domain.Message{Topic: "tables.snapshot", Entity: "tables", Action: "snapshot", Data: map[string]any{"ownerId": "owner-1"}})
+
+	if len(pushed.targets) != 4 || pushed.targets[0].UserIDs[0] != "guest-1" || pushed.targets[1].UserIDs[0] != "owner-1" {
+		t.Fatalf("expected unread counts pushed to guest and owner, got %+v", pushed.targets)
+	}
+	unread, err := notifications.Unread(ctx, "owner-1", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := unread.Data.(map[string]any)
+	if unread.Topic != "notifications.unread" || data["unreadCount"] != 1 {
+		t.Fatalf("unexpected unread message: %s %v", unread.Topic, data)
+	}
+	count, err := notifications.MarkRead(ctx, "owner-1", []string{"evt-1"})
+	if err != nil || count.Data.(map[string]any)["unreadCount"] != 0 {
+		t.Fatalf("mark_read: %v %v", count, err)
+	}
+}
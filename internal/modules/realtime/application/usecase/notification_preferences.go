@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+)
+
+// NotificationActionPreferences is the action of the preferences message on /ws/notifications.
+const NotificationActionPreferences = "preferences"
+
+// ErrInvalidNotificationPreferences wraps the validation errors of preference commands.
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
+
+const quietHoursLayout = "15:04"
+
+// NotificationPreferencesUseCase mutes topic patterns or entities and sets the quiet hours of
+// every user. Muted notifications are neither subscribed nor kept in the inbox; during quiet
+// hours live delivery pauses and the notifications wait in the inbox.
+type NotificationPreferencesUseCase struct {
+	store    port.NotificationPreferenceStore
+	entities *entities.Registry
+	now      func() time.Time
+}
+
+// NewNotificationPreferencesUseCase creates the use case; muted entities are canonical names of
+// registry.
+func NewNotificationPreferencesUseCase(store port.NotificationPreferenceStore, registry *entities.Registry) *NotificationPreferencesUseCase {
+	return &NotificationPreferencesUseCase{store: store, entities: registry, now: time.Now}
+}
+
+func (uc *NotificationPreferencesUseCase) Get(ctx context.Context, userID string) (port.NotificationPreferences, error) {
+	return uc.store.Get(ctx, userID)
+}
+
+// Mute adds topic patterns (e.g. "tables.*") and entities to the muted lists of userID.
+func (uc *NotificationPreferencesUseCase) Mute(ctx context.Context, userID string, topics, entities []string) (port.NotificationPreferences, error) {
+	return uc.update(ctx, userID, func(prefs *port.NotificationPreferences) error {
+		for _, pattern := range topics {
+			pattern = strings.ToLower(strings.TrimSpace(pattern))
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("%w: topic pattern %q", ErrInvalidNotificationPreferences, pattern)
+			}
+			if !slices.Contains(prefs.MutedTopics, pattern) {
+				prefs.MutedTopics = append(prefs.MutedTopics, pattern)
+			}
+		}
+		for _, entity := range entities {
+			entity = uc.entities.Normalize(entity)
+			if entity == "" {
+				return fmt.Errorf("%w: empty entity", ErrInvalidNotificationPreferences)
+			}
+			if !slices.Contains(prefs.MutedEntities, entity) {
+				prefs.MutedEntities = append(prefs.MutedEntities, entity)
+			}
+		}
+		return nil
+	})
+}
+
+// Unmute removes topic patterns and entities from the muted lists of userID.
+func (uc *NotificationPreferencesUseCase) Unmute(ctx context.Context, userID string, topics, entities []string) (port.NotificationPreferences, error) {
+	return uc.update(ctx, userID, func(prefs *port.NotificationPreferences) error {
+		prefs.MutedTopics = slices.DeleteFunc(prefs.MutedTopics, func(pattern string) bool {
+			return slices.ContainsFunc(topics, func(candidate string) bool {
+				return strings.ToLower(strings.TrimSpace(candidate)) == pattern
+			})
+		})
+		prefs.MutedEntities = slices.DeleteFunc(prefs.MutedEntities, func(entity string) bool {
+			return slices.ContainsFunc(entities, func(candidate string) bool {
+				return uc.entities.Normalize(candidate) == entity
+			})
+		})
+		return nil
+	})
+}
+
+// SetQuietHours replaces the quiet hours of userID; nil clears them.
+func (uc *NotificationPreferencesUseCase) SetQuietHours(ctx context.Context, userID string, hours *port.QuietHours) (port.NotificationPreferences, error) {
+	return uc.update(ctx, userID, func(prefs *port.NotificationPreferences) error {
+		if hours == nil {
+			prefs.QuietHours = nil
+			return nil
+		}
+		normalized := port.QuietHours{
+			Start:    strings.TrimSpace(hours.Start),
+			End:      strings.TrimSpace(hours.End),
+			Timezone: strings.TrimSpace(hours.Timezone),
+		}
+		start, startErr := time.Parse(quietHoursLayout, normalized.Start)
+		end, endErr := time.Parse(quietHoursLayout, normalized.End)
+		if startErr != nil || endErr != nil || start.Equal(end) {
+			return fmt.Errorf("%w: quiet hours need distinct start and end as HH:MM", ErrInvalidNotificationPreferences)
+		}
+		if normalized.Timezone != "" {
+			if _, err := time.LoadLocation(normalized.Timezone); err != nil {
+				return fmt.Errorf("%w: timezone %q", ErrInvalidNotificationPreferences, normalized.Timezone)
+			}
+		}
+		prefs.QuietHours = &normalized
+		return nil
+	})
+}
+
+func (uc *NotificationPreferencesUseCase) update(ctx context.Context, userID string, apply func(*port.NotificationPreferences) error) (port.NotificationPreferences, error) {
+	prefs, err := uc.store.Get(ctx, userID)
+	if err != nil {
+		return port.NotificationPreferences{}, err
+	}
+	prefs.MutedTopics = slices.Clone(prefs.MutedTopics)
+	prefs.MutedEntities = slices.Clone(prefs.MutedEntities)
+	if err := apply(&prefs); err != nil {
+		return port.NotificationPreferences{}, err
+	}
+	if err := uc.store.Save(ctx, userID, prefs); err != nil {
+		return port.NotificationPreferences{}, err
+	}
+	return prefs, nil
+}
+
+// Message returns the preferences message sent after every preference command.
+func (uc *NotificationPreferencesUseCase) Message(userID string, prefs port.NotificationPreferences) *domain.Message {
+	return &domain.Message{
+		Topic:     domain.CustomTopic(NotificationsEntity, NotificationActionPreferences),
+		Entity:    NotificationsEntity,
+		Action:    NotificationActionPreferences,
+		Metadata:  map[string]string{"userId": userID},
+		Data:      prefs,
+		Timestamp: uc.now().UTC(),
+	}
+}
+
+// Rules compiles prefs for delivery. An unknown timezone falls back to local time.
+func (uc *NotificationPreferencesUseCase) Rules(prefs port.NotificationPreferences) *NotificationRules {
+	rules := &NotificationRules{prefs: prefs, entities: uc.entities, now: uc.now, location: time.Local}
+	if hours := prefs.QuietHours; hours != nil {
+		start, _ := time.Parse(quietHoursLayout, hours.Start)
+		end, _ := time.Parse(quietHoursLayout, hours.End)
+		rules.quietStart = start.Hour()*60 + start.Minute()
+		rules.quietEnd = end.Hour()*60 + end.Minute()
+		if location, err := time.LoadLocation(hours.Timezone); err == nil && hours.Timezone != "" {
+			rules.location = location
+		}
+	}
+	return rules
+}
+
+// NotificationRules applies the preferences of one user to subscriptions and deliveries.
+type NotificationRules struct {
+	prefs      port.NotificationPreferences
+	entities   *entities.Registry
+	now        func() time.Time
+	location   *time.Location
+	quietStart int
+	quietEnd   int
+}
+
+// Topics drops the muted topics from the role-filtered subscriptions.
+func (r *NotificationRules) Topics(topics []string) []string {
+	kept := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		entity, _, _ := strings.Cut(topic, ".")
+		if !notificationMuted(r.entities, r.prefs, topic, entity) {
+			kept = append(kept, topic)
+		}
+	}
+	return kept
+}
+
+// Allows reports whether msg is delivered live: not muted and outside the quiet hours. Inbox
+// messages (unread counts, preferences) are always delivered.
+func (r *NotificationRules) Allows(msg *domain.Message) bool {
+	if msg == nil || msg.Entity == NotificationsEntity {
+		return true
+	}
+	return !notificationMuted(r.entities, r.prefs, msg.Topic, notificationEntity(r.entities, msg)) && !r.quiet()
+}
+
+func (r *NotificationRules) quiet() bool {
+	if r.prefs.QuietHours == nil {
+		return false
+	}
+	now := r.now().In(r.location)
+	minute := now.Hour()*60 + now.Minute()
+	if r.quietStart < r.quietEnd {
+		return minute >= r.quietStart && minute < r.quietEnd
+	}
+	return minute >= r.quietStart || minute < r.quietEnd
+}
+
+// notificationMuted reports whether topic (or its entity in registry) is muted by prefs.
+func notificationMuted(registry *entities.Registry, prefs port.NotificationPreferences, topic, entity string) bool {
+	if slices.Contains(prefs.MutedEntities, registry.Normalize(entity)) {
+		return true
+	}
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	for _, pattern := range prefs.MutedTopics {
+		if matched, _ := path.Match(pattern, topic); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
+)
+
+// memoryPreferenceStore is a port.NotificationPreferenceStore fake.
+type memoryPreferenceStore struct {
+	mu    sync.Mutex
+	users map[string]port.NotificationPreferences
+}
+
+func (s *memoryPreferenceStore) Get(_ context.Context, userID string) (port.NotificationPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[userID], nil
+}
+
+func (s *memoryPreferenceStore) Save(_ context.Context, userID string, preferences port.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[userID] = preferences
+	return nil
+}
+
+func TestNotificationPreferences_MuteAndQuietHours(t *testing.T) {
+	store := &memoryPreferenceStore{users: make(map[string]port.NotificationPreferences)}
+	uc := NewNotificationPreferencesUseCase(store, entities.Default())
+	ctx := context.Background()
+
+	if _, err := uc.Mute(ctx, "owner-1", []string{"tables.*", "payment.*"}, []string{"section"}); err != nil {
+		t.Fatal(err)
+	}
+	prefs, err := uc.Unmute(ctx, "owner-1", []string{"payment.*"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := uc.Rules(prefs)
+	topics := rules.Topics([]string{"tables.updated", "sections.created", "payment.failed", "reservations.created"})
+	if !slices.Equal(topics, []string{"payment.failed", "reservations.created"}) {
+		t.Fatalf("unexpected subscriptions: %v", topics)
+	}
+
+	if _, err := uc.SetQuietHours(ctx, "owner-1", &port.QuietHours{Start: "22:00", End: "22:00"}); !errors.Is(err, ErrInvalidNotificationPreferences) {
+		t.Fatalf("expected invalid quiet hours, got %v", err)
+	}
+	prefs, err = uc.SetQuietHours(ctx, "owner-1", &port.QuietHours{Start: "22:00", End: "07:00", Timezone: "UTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules = uc.Rules(prefs)
+	reservation := &domain.Message{Topic: "reservations.created", Entity: "reservations"}
+	for at, want := range map[string]bool{"23:30": false, "06:59": false, "07:00": true, "12:00": true} {
+		clock, _ := time.Parse("15:04", at)
+		rules.now = func() time.Time { return clock }
+		if got := rules.Allows(reservation); got != want {
+			t.Errorf("Allows at %s = %v, want %v", at, got, want)
+		}
+	}
+	if !rules.Allows(&domain.Message{Topic: "notifications.unread_count", Entity: NotificationsEntity}) {
+		t.Fatal("inbox messages must ignore quiet hours")
+	}
+
+	notifications := NewNotificationInbox(newMemoryNotificationStore(), nil, entities.Default())
+	notifications.SetPreferences(store)
+	notifications.Broadcast(ctx, &domain.Message{Topic: "tables.updated", Entity: "tables", Data: map[string]any{"ownerId": "owner-1"}})
+	notifications.Broadcast(ctx, &domain.Message{Topic: "payment.failed", Data: map[string]any{"ownerId": "owner-1"}})
+	unread, err := notifications.Unread(ctx, "owner-1", 10)
+	if err != nil || unread.Data.(map[string]any)["unreadCount"] != 1 {
+		t.Fatalf("muted notifications must not reach the inbox: %v %v", unread, err)
+	}
+}
+
+func TestNotificationPreferences_UseTheConfiguredEntityAliases(t *testing.T) {
+	registry, err := entities.New([]entities.Definition{{Name: "tables", Aliases: []string{"mesas"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memoryPreferenceStore{users: make(map[string]port.NotificationPreferences)}
+	uc := NewNotificationPreferencesUseCase(store, registry)
+	prefs, err := uc.Mute(context.Background(), "owner-1", nil, []string{"mesas"})
+	if err != nil || !slices.Equal(prefs.MutedEntities, []string{"tables"}) {
+		t.Fatalf("expected the alias to mute tables, got %v (%v)", prefs.MutedEntities, err)
+	}
+	if uc.Rules(prefs).Allows(&domain.Message{Topic: "mesas.updated"}) {
+		t.Fatal("events of the configured alias must be muted")
+	}
+}
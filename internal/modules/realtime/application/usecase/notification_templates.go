@@ -0,0 +1,267 @@
+package usecase
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/normalization"
+)
+
+// NotificationTemplate is the wording of one notification. Title and Body accept {field}
+// placeholders resolved from the event data (dot paths walk nested objects, e.g.
+// {restaurant.name}), then the metadata, then {resourceId}, {entity} and {action}. Unknown
+// fields render empty.
+type NotificationTemplate struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// NotificationTemplates renders notification messages in the locale of each recipient. The
+// templates are keyed by topic ("reservations.created") or entity wildcard ("reservations.*"),
+// then by locale; a missing locale falls back to DefaultLocale.
+type NotificationTemplates struct {
+	DefaultLocale string                                     `json:"defaultLocale"`
+	Templates     map[string]map[string]NotificationTemplate `json:"templates"`
+}
+
+var notificationPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9_.]+)\}`)
+
+// LoadNotificationTemplates reads the templates from a JSON file and validates them.
+func LoadNotificationTemplates(file string) (*NotificationTemplates, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read notification templates: %w", err)
+	}
+	var templates NotificationTemplates
+	if err := json.Unmarshal(raw, &templates); err != nil {
+		return nil, fmt.Errorf("parse notification templates: %w", err)
+	}
+	templates.normalize()
+	if err := templates.Validate(); err != nil {
+		return nil, err
+	}
+	return &templates, nil
+}
+
+func (t *NotificationTemplates) normalize() {
+	t.DefaultLocale = normalizeLocale(t.DefaultLocale)
+	normalized := make(map[string]map[string]NotificationTemplate, len(t.Templates))
+	for topic, locales := range t.Templates {
+		byLocale := make(map[string]NotificationTemplate, len(locales))
+		for locale, template := range locales {
+			byLocale[normalizeLocale(locale)] = template
+		}
+		normalized[strings.ToLower(strings.TrimSpace(topic))] = byLocale
+	}
+	t.Templates = normalized
+}
+
+// Validate checks that every topic has a title in the default locale and that no template
+// leaves a placeholder unclosed.
+func (t *NotificationTemplates) Validate() error {
+	var errs []error
+	if t.DefaultLocale == "" {
+		errs = append(errs, errors.New("defaultLocale is required"))
+	}
+	topics := make([]string, 0, len(t.Templates))
+	for topic := range t.Templates {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		locales := t.Templates[topic]
+		if _, ok := locales[t.DefaultLocale]; !ok && t.DefaultLocale != "" {
+			errs = append(errs, fmt.Errorf("template %s: missing default locale %q", topic, t.DefaultLocale))
+		}
+		for locale, template := range locales {
+			if strings.TrimSpace(template.Title) == "" {
+				errs = append(errs, fmt.Errorf("template %s/%s: title is required", topic, locale))
+			}
+			for _, text := range []string{template.Title, template.Body} {
+				if strings.Count(text, "{") != strings.Count(text, "}") {
+					errs = append(errs, fmt.Errorf("template %s/%s: unbalanced placeholder in %q", topic, locale, text))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Locale returns the supported locale closest to raw ("en-US" → "en"), or DefaultLocale.
+func (t *NotificationTemplates) Locale(raw string) string {
+	locale := normalizeLocale(raw)
+	if locale == "" {
+		return t.DefaultLocale
+	}
+	for _, locales := range t.Templates {
+		if _, ok := locales[locale]; ok {
+			return locale
+		}
+	}
+	return t.DefaultLocale
+}
+
+// Render returns a copy of msg with its notification text in locale, or msg itself when no
+// template matches. The data, metadata and topic are left untouched. Inbox lists render each
+// stored notification instead.
+func (t *NotificationTemplates) Render(msg *domain.Message, locale string) *domain.Message {
+	if t == nil || msg == nil || msg.Notification != nil {
+		return msg
+	}
+	if msg.Entity == NotificationsEntity {
+		return t.renderInbox(msg, locale)
+	}
+	locales, ok := t.Templates[strings.ToLower(msg.Topic)]
+	if !ok {
+		entity, _, _ := strings.Cut(strings.ToLower(msg.Topic), ".")
+		locales, ok = t.Templates[entity+".*"]
+	}
+	if !ok {
+		return msg
+	}
+	template, ok := locales[locale]
+	if !ok {
+		locale = t.DefaultLocale
+		if template, ok = locales[locale]; !ok {
+			return msg
+		}
+	}
+	data := normalization.MapFromPayload(msg.Data)
+	rendered := *msg
+	rendered.Notification = &domain.RenderedNotification{
+		Title:  renderNotificationText(template.Title, msg, data),
+		Body:   renderNotificationText(template.Body, msg, data),
+		Locale: locale,
+	}
+	return &rendered
+}
+
+// renderInbox renders the items of an inbox list without touching the stored notifications.
+func (t *NotificationTemplates) renderInbox(msg *domain.Message, locale string) *domain.Message {
+	data, ok := msg.Data.(map[string]any)
+	if !ok {
+		return msg
+	}
+	items, ok := data["items"].([]port.StoredNotification)
+	if !ok || len(items) == 0 {
+		return msg
+	}
+	renderedItems := make([]port.StoredNotification, len(items))
+	for idx, item := range items {
+		item.Message = t.Render(item.Message, locale)
+		renderedItems[idx] = item
+	}
+	renderedData := make(map[string]any, len(data))
+	for key, value := range data {
+		renderedData[key] = value
+	}
+	renderedData["items"] = renderedItems
+	rendered := *msg
+	rendered.Data = renderedData
+	return &rendered
+}
+
+func renderNotificationText(text string, msg *domain.Message, data map[string]any) string {
+	if text == "" {
+		return ""
+	}
+	rendered := notificationPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		field := placeholder[1 : len(placeholder)-1]
+		if value := notificationPath(data, field); value != "" {
+			return value
+		}
+		if value := strings.TrimSpace(msg.Metadata[field]); value != "" {
+			return value
+		}
+		switch field {
+		case "resourceId":
+			return msg.ResourceID
+		case "entity":
+			return msg.Entity
+		case "action":
+			return msg.Action
+		}
+		return ""
+	})
+	return strings.Join(strings.Fields(rendered), " ")
+}
+
+func notificationPath(data map[string]any, field string) string {
+	current := any(data)
+	for _, part := range strings.Split(field, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return ""
+		}
+		current = object[part]
+	}
+	return normalization.AsString(current)
+}
+
+func normalizeLocale(raw string) string {
+	locale, _, _ := strings.Cut(strings.TrimSpace(raw), ",")
+	locale, _, _ = strings.Cut(locale, ";")
+	locale, _, _ = strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return strings.ToLower(strings.TrimSpace(locale))
+}
+
+// DefaultNotificationTemplates returns the built-in Spanish and English wording.
+func DefaultNotificationTemplates() *NotificationTemplates {
+	templates := &NotificationTemplates{
+		DefaultLocale: "es",
+		Templates: map[string]map[string]NotificationTemplate{
+			"reservations.created": {
+				"es": {Title: "Nueva reserva", Body: "Reserva {resourceId} para el {date} a las {time}."},
+				"en": {Title: "New reservation", Body: "Reservation {resourceId} for {date} at {time}."},
+			},
+			"reservations.updated": {
+				"es": {Title: "Reserva actualizada", Body: "La reserva {resourceId} cambió."},
+				"en": {Title: "Reservation updated", Body: "Reservation {resourceId} was updated."},
+			},
+			"reservations.status-changed": {
+				"es": {Title: "Estado de reserva", Body: "La reserva {resourceId} ahora está {status}."},
+				"en": {Title: "Reservation status", Body: "Reservation {resourceId} is now {status}."},
+			},
+			"reservations.deleted": {
+				"es": {Title: "Reserva cancelada", Body: "La reserva {resourceId} fue cancelada."},
+				"en": {Title: "Reservation cancelled", Body: "Reservation {resourceId} was cancelled."},
+			},
+			"reviews.created": {
+				"es": {Title: "Nueva reseña", Body: "Recibiste una reseña de {rating} estrellas."},
+				"en": {Title: "New review", Body: "You received a {rating}-star review."},
+			},
+			"payment.approved": {
+				"es": {Title: "Pago aprobado", Body: "Tu pago de {amount} {currency} fue aprobado."},
+				"en": {Title: "Payment approved", Body: "Your payment of {amount} {currency} was approved."},
+			},
+			"payment.failed": {
+				"es": {Title: "Pago fallido", Body: "No pudimos procesar el pago de {amount} {currency}."},
+				"en": {Title: "Payment failed", Body: "We could not process the payment of {amount} {currency}."},
+			},
+			"payment.refunded": {
+				"es": {Title: "Pago reembolsado", Body: "Se reembolsaron {amount} {currency}."},
+				"en": {Title: "Payment refunded", Body: "{amount} {currency} were refunded."},
+			},
+			"subscriptions.status-changed": {
+				"es": {Title: "Suscripción actualizada", Body: "Tu suscripción ahora está {status}."},
+				"en": {Title: "Subscription updated", Body: "Your subscription is now {status}."},
+			},
+			"restaurants.created": {
+				"es": {Title: "Nuevo restaurante", Body: "{name} ya está en MesaYA."},
+				"en": {Title: "New restaurant", Body: "{name} is now on MesaYA."},
+			},
+			"tables.*": {
+				"es": {Title: "Mesas actualizadas", Body: "La mesa {resourceId} cambió ({action})."},
+				"en": {Title: "Tables updated", Body: "Table {resourceId} changed ({action})."},
+			},
+		},
+	}
+	return templates
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+func TestNotificationTemplates_RenderPerLocale(t *testing.T) {
+	defaults := DefaultNotificationTemplates()
+	if err := defaults.Validate(); err != nil {
+		t.Fatalf("default templates: %v", err)
+	}
+	example, err := LoadNotificationTemplates(filepath.Join("..", "..", "..", "..", "..", "docs", "notifications", "templates.example.json"))
+	if err != nil {
+		t.Fatalf("example file: %v", err)
+	}
+
+	if got := defaults.Locale("en-US,en;q=0.9"); got != "en" {
+		t.Fatalf("expected en, got %q", got)
+	}
+	if got := defaults.Locale("fr"); got != "es" {
+		t.Fatalf("expected fallback to es, got %q", got)
+	}
+
+	data := map[string]any{"status": "CONFIRMED", "restaurant": map[string]any{"name": "La Mesa"}}
+	msg := &domain.Message{Topic: "reservations.status-changed", Entity: "reservations", Action: "status-changed", ResourceID: "r-1", Data: data}
+	rendered := example.Render(msg, "en")
+	if rendered.Notification == nil || rendered.Notification.Body != "Your reservation at La Mesa is now CONFIRMED." {
+		t.Fatalf("unexpected rendering: %+v", rendered.Notification)
+	}
+	if msg.Notification != nil || !reflect.DeepEqual(rendered.Data, msg.Data) {
+		t.Fatal("rendering must not change the original message")
+	}
+	if got := example.Render(&domain.Message{Topic: "payment.failed", Data: map[string]any{"status": "rechazado"}}, "es"); got.Notification.Title != "Pago rechazado" || got.Notification.Body != "" {
+		t.Fatalf("wildcard template not applied: %+v", got.Notification)
+	}
+	if got := defaults.Render(&domain.Message{Topic: "users.created"}, "es"); got.Notification != nil {
+		t.Fatal("topics without template stay untouched")
+	}
+
+	inboxMsg := &domain.Message{Entity: NotificationsEntity, Data: map[string]any{
+		"items":       []port.StoredNotification{{ID: "n-1", Message: msg}},
+		"unreadCount": 1,
+	}}
+	items := defaults.Render(inboxMsg, "es").Data.(map[string]any)["items"].([]port.StoredNotification)
+	if items[0].Message.Notification == nil || items[0].Message.Notification.Body != "La reserva r-1 ahora está CONFIRMED." {
+		t.Fatalf("inbox item not rendered: %+v", items[0].Message.Notification)
+	}
+	if inboxMsg.Data.(map[string]any)["items"].([]port.StoredNotification)[0].Message.Notification != nil {
+		t.Fatal("stored notifications must stay raw")
+	}
+}
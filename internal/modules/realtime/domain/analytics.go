@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"net/url"
 	"strings"
 	"time"
 )
@@ -29,6 +30,18 @@ func (r AnalyticsRequest) Clone() AnalyticsRequest {
 	return cloned
 }
 
+// CanonicalKey returns a stable representation of the request, suitable for cache keys.
+func (r AnalyticsRequest) CanonicalKey() string {
+	values := url.Values{}
+	for key, value := range r.Clone().Query {
+		values.Set("q."+key, value)
+	}
+	if identifier := strings.TrimSpace(r.Identifier); identifier != "" {
+		values.Set("id", identifier)
+	}
+	return values.Encode()
+}
+
 // AnalyticsSnapshot represents the decoded payload returned by the analytics REST endpoints.
 type AnalyticsSnapshot struct {
 	Payload  any
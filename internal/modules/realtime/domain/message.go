@@ -5,12 +5,21 @@ import "time"
 // Message representa el mensaje de dominio que se transmite entre Kafka y WebSocket.
 // Topic corresponde al canal final de WebSocket (entity.action) mientras que Entity y Action
 // describen el evento del dominio. Metadata permite incluir información adicional (ej. userId destino).
+// Notification, cuando existe, es el texto legible del evento en el idioma del destinatario.
 type Message struct {
-	Topic      string            `json:"topic"`
-	Entity     string            `json:"entity"`
-	Action     string            `json:"action"`
-	ResourceID string            `json:"resourceId,omitempty"`
-	Data       interface{}       `json:"data,omitempty"`
-	Metadata   map[string]string `json:"metadata,omitempty"`
-	Timestamp  time.Time         `json:"timestamp"`
+	Topic        string                `json:"topic"`
+	Entity       string                `json:"entity"`
+	Action       string                `json:"action"`
+	ResourceID   string                `json:"resourceId,omitempty"`
+	Data         interface{}           `json:"data,omitempty"`
+	Metadata     map[string]string     `json:"metadata,omitempty"`
+	Notification *RenderedNotification `json:"notification,omitempty"`
+	Timestamp    time.Time             `json:"timestamp"`
+}
+
+// RenderedNotification es el título y cuerpo de una notificación renderizados en Locale.
+type RenderedNotification struct {
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	Locale string `json:"locale"`
 }
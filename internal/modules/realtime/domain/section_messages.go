@@ -39,8 +39,11 @@ func BuildDetailMessage(entity, sectionID, restaurantID string, snapshot *Sectio
 		"sectionId": trimmedSection,
 	}
 	entityName := strings.TrimSpace(entity)
-	resourceKey := detailResourceKey(entityName)
-	if trimmedResource != "" && resourceKey != "" {
+	resourceKey := strings.TrimSpace(snapshot.ResourceKey)
+	if resourceKey == "" {
+		resourceKey = "restaurantId"
+	}
+	if trimmedResource != "" {
 		if resourceKey == "sectionId" {
 			metadata["resourceSectionId"] = trimmedResource
 		} else {
@@ -62,39 +65,6 @@ func BuildDetailMessage(entity, sectionID, restaurantID string, snapshot *Sectio
 	}
 }
 
-func detailResourceKey(entity string) string {
-	switch strings.ToLower(strings.TrimSpace(entity)) {
-	case "tables":
-		return "tableId"
-	case "reservations":
-		return "reservationId"
-	case "reviews":
-		return "reviewId"
-	case "sections":
-		return "sectionId"
-	case "objects":
-		return "objectId"
-	case "menus":
-		return "menuId"
-	case "dishes":
-		return "dishId"
-	case "images":
-		return "imageId"
-	case "section-objects":
-		return "sectionObjectId"
-	case "payments":
-		return "paymentId"
-	case "subscriptions":
-		return "subscriptionId"
-	case "subscription-plans":
-		return "subscriptionPlanId"
-	case "auth-users":
-		return "userId"
-	default:
-		return "restaurantId"
-	}
-}
-
 func mergeInto(target map[string]string, extras Metadata) map[string]string {
 	if len(extras) == 0 {
 		return target
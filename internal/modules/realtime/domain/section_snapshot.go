@@ -32,6 +32,9 @@ type SectionSnapshot struct {
 	Payload        any
 	ListMetadata   Metadata
 	DetailMetadata Metadata
+	// ResourceKey is the metadata key of the resource id in detail messages (e.g. "tableId"),
+	// set by fetchers from their entity registry; empty uses "restaurantId".
+	ResourceKey string
 }
 
 // MergeListMetadata enriches the snapshot with additional information for list broadcasts.
@@ -0,0 +1,225 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+const recorderQueueSize = 4096
+
+// RecorderOptions configures a JSONLRecorder.
+type RecorderOptions struct {
+	// Dir receives the recording files (recording-<timestamp>.jsonl).
+	Dir string
+	// MaxBytes rotates the current file once it grows past this size. Zero disables it.
+	MaxBytes int64
+	// MaxAge rotates the current file once it has been open this long. Zero disables it.
+	MaxAge time.Duration
+	// Topics keeps only messages whose topic (or Kafka source topic) matches one of these
+	// glob patterns. Empty records every topic.
+	Topics []string
+	// Entities keeps only messages for these entities. Empty records every entity.
+	Entities []string
+}
+
+// recordedDelivery is the JSONL line written per delivery. Its topic/message/time fields
+// follow the replay record shape (broker.RecordedEvent) so recordings can be replayed.
+type recordedDelivery struct {
+	Topic     string            `json:"topic"`
+	Kind      string            `json:"kind"`
+	Message   *domain.Message   `json:"message"`
+	Time      time.Time         `json:"time"`
+	Delivered []string          `json:"delivered"`
+	Dropped   []DroppedDelivery `json:"dropped,omitempty"`
+}
+
+// JSONLRecorder writes every outbound message, with the clients that received it and the
+// ones that were skipped, to size/time rotated JSONL files. Writes happen on a background
+// goroutine; when the queue is full records are dropped rather than slowing broadcasts.
+// Deliveries recorded after Close are ignored.
+type JSONLRecorder struct {
+	opts     RecorderOptions
+	entities map[string]struct{}
+	queue    chan recordedDelivery
+	done     chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	file     *os.File
+	written  int64
+	openedAt time.Time
+}
+
+// NewJSONLRecorder creates the recording directory and starts the writer goroutine.
+func NewJSONLRecorder(opts RecorderOptions) (*JSONLRecorder, error) {
+	opts.Dir = strings.TrimSpace(opts.Dir)
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("recording directory is required")
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create recording dir: %w", err)
+	}
+	entities := make(map[string]struct{}, len(opts.Entities))
+	for _, entity := range opts.Entities {
+		if trimmed := strings.ToLower(strings.TrimSpace(entity)); trimmed != "" {
+			entities[trimmed] = struct{}{}
+		}
+	}
+	r := &JSONLRecorder{
+		opts:     opts,
+		entities: entities,
+		queue:    make(chan recordedDelivery, recorderQueueSize),
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	slog.Info("broadcast recorder ready", slog.String("dir", opts.Dir), slog.Int64("maxBytes", opts.MaxBytes), slog.Duration("maxAge", opts.MaxAge), slog.Any("topics", opts.Topics), slog.Any("entities", opts.Entities))
+	return r, nil
+}
+
+// RecordDelivery queues the delivery when it passes the topic and entity filters.
+func (r *JSONLRecorder) RecordDelivery(_ context.Context, delivery Delivery) {
+	msg := delivery.Message
+	if msg == nil || !r.accepts(msg, delivery.SourceTopic) {
+		return
+	}
+	record := recordedDelivery{
+		Topic:     firstNonBlank(delivery.SourceTopic, msg.Topic),
+		Kind:      delivery.Kind,
+		Message:   msg,
+		Time:      time.Now().UTC(),
+		Delivered: delivery.Delivered,
+		Dropped:   delivery.Dropped,
+	}
+	if record.Delivered == nil {
+		record.Delivered = []string{}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+	select {
+	case r.queue <- record:
+	default:
+		metrics.Default.Counter("recorder_records_dropped_total").Inc()
+	}
+}
+
+// Close flushes the queued records and closes the current file. It is safe to call while
+// messages are still being broadcast and more than once.
+func (r *JSONLRecorder) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+func (r *JSONLRecorder) accepts(msg *domain.Message, sourceTopic string) bool {
+	if len(r.entities) > 0 {
+		if _, ok := r.entities[strings.ToLower(strings.TrimSpace(msg.Entity))]; !ok {
+			return false
+		}
+	}
+	if len(r.opts.Topics) == 0 {
+		return true
+	}
+	for _, pattern := range r.opts.Topics {
+		pattern = strings.TrimSpace(pattern)
+		for _, topic := range []string{msg.Topic, sourceTopic} {
+			if topic == "" {
+				continue
+			}
+			if ok, err := path.Match(pattern, topic); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *JSONLRecorder) run() {
+	defer close(r.done)
+	defer r.closeFile()
+	for record := range r.queue {
+		if err := r.write(record); err != nil {
+			slog.Error("broadcast recorder write failed", slog.Any("error", err))
+		}
+	}
+}
+
+func (r *JSONLRecorder) write(record recordedDelivery) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if r.shouldRotate(int64(len(line))) {
+		r.closeFile()
+	}
+	if r.file == nil {
+		if err := r.openFile(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(line)
+	r.written += int64(n)
+	return err
+}
+
+func (r *JSONLRecorder) shouldRotate(next int64) bool {
+	if r.file == nil {
+		return false
+	}
+	if r.opts.MaxBytes > 0 && r.written > 0 && r.written+next > r.opts.MaxBytes {
+		return true
+	}
+	return r.opts.MaxAge > 0 && time.Since(r.openedAt) >= r.opts.MaxAge
+}
+
+func (r *JSONLRecorder) openFile() error {
+	now := time.Now().UTC()
+	name := filepath.Join(r.opts.Dir, "recording-"+now.Format("20060102T150405.000000000")+".jsonl")
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open recording file: %w", err)
+	}
+	r.file = file
+	r.written = 0
+	r.openedAt = now
+	slog.Debug("broadcast recording file opened", slog.String("file", name))
+	return nil
+}
+
+func (r *JSONLRecorder) closeFile() {
+	if r.file == nil {
+		return
+	}
+	if err := r.file.Close(); err != nil {
+		slog.Warn("broadcast recording close failed", slog.Any("error", err))
+	}
+	r.file = nil
+}
+
+func firstNonBlank(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
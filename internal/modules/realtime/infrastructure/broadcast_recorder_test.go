@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+func TestJSONLRecorder_RecordsTargetsDropsAndRotates(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := NewJSONLRecorder(RecorderOptions{Dir: dir, MaxBytes: 1, Entities: []string{"reservations"}})
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	hub := NewHub()
+	hub.SetRecorder(recorder)
+	owner := NewClient(hub, nil, "u-1", "s-1", "", "reservations", "", 4, nil)
+	other := NewClient(hub, nil, "u-2", "s-2", "", "reservations", "", 4, nil)
+	hub.AttachClient(owner, []string{"reservations.created"})
+	hub.AttachClient(other, []string{"reservations.created"})
+
+	ctx := port.WithSourceTopic(context.Background(), "mesa-ya.reservations.events")
+	hub.Broadcast(ctx, &domain.Message{Topic: "reservations.created", Entity: "reservations", Action: "created", Metadata: map[string]string{"userId": "u-1"}})
+	hub.Broadcast(ctx, &domain.Message{Topic: "tables.updated", Entity: "tables", Action: "updated"})
+	owner.SendDomainMessage(&domain.Message{Topic: "reservations.snapshot", Entity: "reservations", Action: "snapshot", Timestamp: time.Now()})
+	recorder.Close()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("expected 2 rotated files, got %v (%v)", files, err)
+	}
+	var records []recordedDelivery
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var record recordedDelivery
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("decode record: %v", err)
+			}
+			records = append(records, record)
+		}
+		file.Close()
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records (tables filtered out), got %d", len(records))
+	}
+	broadcast := records[0]
+	if broadcast.Kind != DeliveryBroadcast || broadcast.Topic != "mesa-ya.reservations.events" {
+		t.Fatalf("unexpected broadcast record: %+v", broadcast)
+	}
+	if len(broadcast.Delivered) != 1 || broadcast.Delivered[0] != "u-1:s-1" {
+		t.Fatalf("unexpected delivered clients: %v", broadcast.Delivered)
+	}
+	if len(broadcast.Dropped) != 1 || broadcast.Dropped[0].Client != "u-2:s-2" || broadcast.Dropped[0].Reason != DropTargetUser {
+		t.Fatalf("unexpected dropped clients: %v", broadcast.Dropped)
+	}
+	if records[1].Kind != DeliveryDirect || records[1].Topic != "reservations.snapshot" {
+		t.Fatalf("unexpected direct record: %+v", records[1])
+	}
+}
+
+func TestJSONLRecorder_IgnoresDeliveriesAfterClose(t *testing.T) {
+	recorder, err := NewJSONLRecorder(RecorderOptions{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("recorder: %v", err)
+	}
+	msg := &domain.Message{Topic: "tables.updated", Entity: "tables", Action: "updated"}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 200 {
+				recorder.RecordDelivery(context.Background(), Delivery{Kind: DeliveryBroadcast, Message: msg})
+			}
+		}()
+	}
+	recorder.Close()
+	wg.Wait()
+	recorder.Close()
+	recorder.RecordDelivery(context.Background(), Delivery{Kind: DeliveryBroadcast, Message: msg})
+}
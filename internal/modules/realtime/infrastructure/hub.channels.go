@@ -0,0 +1,129 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// ChannelKey identifies the (entity, section) channel of a multiplexed connection.
+func ChannelKey(entity, section string) string {
+	return strings.TrimSpace(entity) + ":" + strings.TrimSpace(section)
+}
+
+// JoinChannel adds the (entity, section) channel to a multiplexed client: broadcasts targeted at
+// that section of entity reach it and are tagged with the channel. It reports whether this is
+// the first channel of entity, i.e. whether the entity topics still need a subscription.
+func (c *Client) JoinChannel(entity, section string) bool {
+	entity = strings.TrimSpace(entity)
+	section = strings.TrimSpace(section)
+	c.channelMu.Lock()
+	defer c.channelMu.Unlock()
+	if c.channels == nil {
+		c.channels = make(map[string]map[string]struct{})
+	}
+	sections, ok := c.channels[entity]
+	if !ok {
+		sections = make(map[string]struct{})
+		c.channels[entity] = sections
+	}
+	sections[section] = struct{}{}
+	return !ok
+}
+
+// LeaveChannel removes the (entity, section) channel and reports whether it was the last channel
+// of entity, i.e. whether the entity topics can be unsubscribed.
+func (c *Client) LeaveChannel(entity, section string) bool {
+	entity = strings.TrimSpace(entity)
+	c.channelMu.Lock()
+	defer c.channelMu.Unlock()
+	sections, ok := c.channels[entity]
+	if !ok {
+		return false
+	}
+	delete(sections, strings.TrimSpace(section))
+	if len(sections) > 0 {
+		return false
+	}
+	delete(c.channels, entity)
+	return true
+}
+
+// multiplexed reports whether the client scopes its sections per channel instead of sectionID.
+func (c *Client) multiplexed() bool {
+	c.channelMu.RLock()
+	defer c.channelMu.RUnlock()
+	return c.channels != nil
+}
+
+// channelFor returns the key of the first channel of entity among sections, or "".
+func (c *Client) channelFor(entity string, sections []string) string {
+	entity = strings.TrimSpace(entity)
+	c.channelMu.RLock()
+	defer c.channelMu.RUnlock()
+	joined := c.channels[entity]
+	for _, section := range sections {
+		section = strings.TrimSpace(section)
+		if section == "" {
+			continue
+		}
+		if _, ok := joined[section]; ok {
+			return ChannelKey(entity, section)
+		}
+	}
+	return ""
+}
+
+// messageChannel returns the channel msg belongs to for this client, or "" when the client is
+// not multiplexed or has not joined the section of msg.
+func (c *Client) messageChannel(msg *domain.Message) string {
+	if msg == nil || msg.Metadata == nil || !c.multiplexed() {
+		return ""
+	}
+	if strings.TrimSpace(msg.Metadata["channel"]) != "" {
+		return "" // already tagged
+	}
+	return c.channelFor(msg.Entity, []string{msg.Metadata["sectionId"]})
+}
+
+// withChannel returns a copy of msg tagged with its channel, or msg itself when it has none.
+func (c *Client) withChannel(msg *domain.Message) *domain.Message {
+	channel := c.messageChannel(msg)
+	if channel == "" {
+		return msg
+	}
+	tagged := *msg
+	tagged.Metadata = make(map[string]string, len(msg.Metadata)+1)
+	for key, value := range msg.Metadata {
+		tagged.Metadata[key] = value
+	}
+	tagged.Metadata["channel"] = channel
+	return &tagged
+}
+
+// outgoing returns msg as the client sees it: rendered and tagged with its channel.
+func (c *Client) outgoing(msg *domain.Message) *domain.Message {
+	if c.render != nil {
+		if rendered := c.render(msg); rendered != nil {
+			msg = rendered
+		}
+	}
+	return c.withChannel(msg)
+}
+
+// clientData re-encodes a broadcast for a client that renders it or multiplexes channels;
+// other clients share the encoded data.
+func (c *Client) clientData(msg *domain.Message, data []byte) []byte {
+	tagged := c.outgoing(msg)
+	if tagged == msg {
+		return data
+	}
+	encoded, err := json.Marshal(tagged)
+	if err != nil {
+		slog.Error("broadcast channel marshal error", slog.Any("error", err))
+		return data
+	}
+	return encoded
+}
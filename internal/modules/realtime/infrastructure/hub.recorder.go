@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"context"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// Delivery kinds reported to a DeliveryRecorder.
+const (
+	DeliveryBroadcast = "broadcast"
+	DeliveryDirect    = "direct"
+)
+
+// Drop reasons reported for clients that did not receive a message.
+const (
+	DropTargetUser    = "target_user_mismatch"
+	DropTargetSession = "target_session_mismatch"
+	DropTargetSection = "target_section_mismatch"
+	DropTargetRole    = "target_role_mismatch"
+	DropTargetRest    = "target_restaurant_mismatch"
+	DropFiltered      = "client_filter"
+	DropBufferFull    = "buffer_full"
+	DropClientClosed  = "client_closed"
+)
+
+// DroppedDelivery identifies a client that was considered for a message but did not get it.
+type DroppedDelivery struct {
+	Client string `json:"client"`
+	Reason string `json:"reason"`
+}
+
+// Delivery describes what the hub did with one outbound message.
+type Delivery struct {
+	Kind        string
+	SourceTopic string
+	Message     *domain.Message
+	Delivered   []string
+	Dropped     []DroppedDelivery
+}
+
+// DeliveryRecorder observes every outbound message. Implementations must not block: they are
+// called from the broadcast path.
+type DeliveryRecorder interface {
+	RecordDelivery(ctx context.Context, delivery Delivery)
+}
+
+type recorderHolder struct {
+	recorder DeliveryRecorder
+}
+
+// SetRecorder installs (or, with nil, removes) the recorder observing Broadcast and
+// SendDomainMessage.
+func (h *Hub) SetRecorder(recorder DeliveryRecorder) {
+	if recorder == nil {
+		h.recorder.Store(nil)
+		return
+	}
+	h.recorder.Store(&recorderHolder{recorder: recorder})
+}
+
+func (h *Hub) currentRecorder() DeliveryRecorder {
+	if h == nil {
+		return nil
+	}
+	holder := h.recorder.Load()
+	if holder == nil {
+		return nil
+	}
+	return holder.recorder
+}
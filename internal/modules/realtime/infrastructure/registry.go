@@ -2,26 +2,135 @@ package infrastructure
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 
 	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
 )
 
+// HandlerFunc is the function form of a topic handler used by the middleware chain.
+type HandlerFunc func(ctx context.Context, msg *domain.Message) error
+
+// Middleware decorates the handler registered for topic. Middlewares wrap every handler
+// individually so a failure (or panic) in one handler does not prevent the others from running.
+type Middleware func(topic string, next HandlerFunc) HandlerFunc
+
+type registryEntry struct {
+	topic    string
+	wildcard bool
+	handler  port.TopicHandler
+	wrapped  HandlerFunc
+}
+
+// HandlerRegistry routes messages to topic handlers. Several handlers may share a topic and
+// run in registration order; topics containing glob characters (e.g. "reservations.*",
+// "mesa-ya.*.events") match any topic with the same shape. Handlers may be registered at
+// runtime (e.g. by topic discovery) while messages are being dispatched.
 type HandlerRegistry struct {
-	handlers map[string]port.TopicHandler
+	mu          sync.RWMutex
+	entries     []*registryEntry
+	middlewares []Middleware
 }
 
 func NewHandlerRegistry() *HandlerRegistry {
-	return &HandlerRegistry{handlers: make(map[string]port.TopicHandler)}
+	return &HandlerRegistry{}
+}
+
+// Use appends middlewares to the chain; the first middleware is the outermost one.
+// Handlers already registered are re-wrapped with the updated chain.
+func (r *HandlerRegistry) Use(middlewares ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, mw := range middlewares {
+		if mw != nil {
+			r.middlewares = append(r.middlewares, mw)
+		}
+	}
+	for _, entry := range r.entries {
+		entry.wrapped = r.wrapLocked(entry.topic, entry.handler)
+	}
 }
 
 func (r *HandlerRegistry) Register(h port.TopicHandler) {
-	r.handlers[h.Topic()] = h
+	topic := strings.TrimSpace(h.Topic())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, &registryEntry{
+		topic:    topic,
+		wildcard: strings.ContainsAny(topic, "*?["),
+		handler:  h,
+		wrapped:  r.wrapLocked(topic, h),
+	})
+}
+
+// Has reports whether at least one handler (exact or wildcard) matches topic.
+func (r *HandlerRegistry) Has(topic string) bool {
+	return len(r.match(topic)) > 0
 }
 
+// Dispatch runs every handler matching msg.Topic and joins their errors.
 func (r *HandlerRegistry) Dispatch(ctx context.Context, msg *domain.Message) error {
-	if handler, ok := r.handlers[msg.Topic]; ok {
-		return handler.Handle(ctx, msg)
+	return r.run(ctx, msg, r.match(msg.Topic))
+}
+
+// DispatchFrom routes a message consumed from sourceTopic. Handlers registered for the Kafka
+// topic itself (entity streams) take precedence: handlers keyed by msg.Topic only run when no
+// exact handler matches sourceTopic, so an event is never handled by both. Wildcard handlers
+// matching either topic run once.
+func (r *HandlerRegistry) DispatchFrom(ctx context.Context, sourceTopic string, msg *domain.Message) error {
+	entries := r.match(strings.TrimSpace(sourceTopic))
+	if slices.ContainsFunc(entries, func(entry *registryEntry) bool { return !entry.wildcard }) {
+		return r.run(ctx, msg, entries)
+	}
+	for _, entry := range r.match(msg.Topic) {
+		if !slices.Contains(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return r.run(ctx, msg, entries)
+}
+
+func (r *HandlerRegistry) run(ctx context.Context, msg *domain.Message, entries []*registryEntry) error {
+	var errs []error
+	for _, entry := range entries {
+		if err := entry.wrapped(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("handler %s: %w", entry.topic, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// match returns exact registrations first, then wildcard ones, each in registration order.
+func (r *HandlerRegistry) match(topic string) []*registryEntry {
+	if topic == "" {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var exact, wildcard []*registryEntry
+	for _, entry := range r.entries {
+		if !entry.wildcard {
+			if entry.topic == topic {
+				exact = append(exact, entry)
+			}
+			continue
+		}
+		if ok, err := path.Match(entry.topic, topic); err == nil && ok {
+			wildcard = append(wildcard, entry)
+		}
+	}
+	return append(exact, wildcard...)
+}
+
+func (r *HandlerRegistry) wrapLocked(topic string, h port.TopicHandler) HandlerFunc {
+	wrapped := HandlerFunc(h.Handle)
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		wrapped = r.middlewares[i](topic, wrapped)
 	}
-	return nil
+	return wrapped
 }
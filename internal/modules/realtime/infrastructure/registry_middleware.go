@@ -0,0 +1,145 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+// RecoveryMiddleware converts handler panics into errors so the consumer loop keeps running.
+func RecoveryMiddleware() Middleware {
+	return func(topic string, next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *domain.Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("topic handler panic", slog.String("handler", topic), slog.String("topic", msg.Topic), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// LoggingMiddleware logs handler execution time and failures.
+func LoggingMiddleware() Middleware {
+	return func(topic string, next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, msg *domain.Message) error {
+			started := time.Now()
+			err := next(ctx, msg)
+			if err != nil {
+				slog.Warn("topic handler failed", slog.String("handler", topic), slog.String("topic", msg.Topic), slog.String("resourceId", msg.ResourceID), slog.Duration("elapsed", time.Since(started)), slog.Any("error", err))
+				return err
+			}
+			slog.Debug("topic handler done", slog.String("handler", topic), slog.String("topic", msg.Topic), slog.Duration("elapsed", time.Since(started)))
+			return nil
+		}
+	}
+}
+
+// MetricsMiddleware counts handled messages and failures per handler topic.
+func MetricsMiddleware(registry *metrics.Registry) Middleware {
+	if registry == nil {
+		registry = metrics.Default
+	}
+	return func(topic string, next HandlerFunc) HandlerFunc {
+		handled := registry.Counter("topic_handler_messages_total", "handler", topic)
+		failed := registry.Counter("topic_handler_errors_total", "handler", topic)
+		return func(ctx context.Context, msg *domain.Message) error {
+			handled.Inc()
+			err := next(ctx, msg)
+			if err != nil {
+				failed.Inc()
+			}
+			return err
+		}
+	}
+}
+
+// TimeoutMiddleware bounds each handler invocation. A non-positive timeout disables it.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(topic string, next HandlerFunc) HandlerFunc {
+		if timeout <= 0 {
+			return next
+		}
+		return func(ctx context.Context, msg *domain.Message) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(ctx, msg)
+		}
+	}
+}
+
+// DedupeMiddleware skips Kafka redeliveries already handled by the same handler within window.
+// Messages are keyed on their source topic, partition and offset (see port.SourcePositionFrom),
+// so two distinct events with the same content are both handled; messages without a source
+// position (memory or replay drivers, HTTP broadcasts) are never skipped. A position is only
+// recorded once the handler succeeds, so a failed attempt may be retried.
+func DedupeMiddleware(window time.Duration) Middleware {
+	return func(topic string, next HandlerFunc) HandlerFunc {
+		if window <= 0 {
+			return next
+		}
+		seen := &dedupeCache{window: window, entries: make(map[string]time.Time)}
+		return func(ctx context.Context, msg *domain.Message) error {
+			key, ok := deliveryKey(ctx)
+			if !ok {
+				return next(ctx, msg)
+			}
+			if seen.contains(key) {
+				slog.Debug("topic handler duplicate skipped", slog.String("handler", topic), slog.String("topic", msg.Topic), slog.String("delivery", key))
+				return nil
+			}
+			if err := next(ctx, msg); err != nil {
+				return err
+			}
+			seen.record(key)
+			return nil
+		}
+	}
+}
+
+type dedupeCache struct {
+	mu        sync.Mutex
+	window    time.Duration
+	entries   map[string]time.Time
+	lastSweep time.Time
+}
+
+func (c *dedupeCache) contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.entries[key]
+	return ok && time.Since(at) < c.window
+}
+
+func (c *dedupeCache) record(key string) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastSweep) >= c.window {
+		for k, at := range c.entries {
+			if now.Sub(at) >= c.window {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = now
+}
+
+func deliveryKey(ctx context.Context) (string, bool) {
+	topic := port.SourceTopic(ctx)
+	position, ok := port.SourcePositionFrom(ctx)
+	if topic == "" || !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s/%d/%d", topic, position.Partition, position.Offset), true
+}
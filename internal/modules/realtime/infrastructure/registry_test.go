@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+type recordingHandler struct {
+	topic string
+	calls int
+	err   error
+	panic bool
+}
+
+func (h *recordingHandler) Topic() string { return h.topic }
+
+func (h *recordingHandler) Handle(context.Context, *domain.Message) error {
+	h.calls++
+	if h.panic {
+		panic("boom")
+	}
+	return h.err
+}
+
+func TestHandlerRegistryDispatchFrom_SourceHandlersTakePrecedence(t *testing.T) {
+	registry := NewHandlerRegistry()
+	stream := &recordingHandler{topic: "mesa-ya.auth.events"}
+	legacy := &recordingHandler{topic: "user.created"}
+	wildcard := &recordingHandler{topic: "*"}
+	registry.Register(stream)
+	registry.Register(legacy)
+	registry.Register(wildcard)
+
+	msg := &domain.Message{Topic: "user.created", Entity: "users", Action: "created"}
+	if err := registry.DispatchFrom(context.Background(), "mesa-ya.auth.events", msg); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if stream.calls != 1 || legacy.calls != 0 || wildcard.calls != 1 {
+		t.Fatalf("entity stream topic: unexpected calls stream=%d legacy=%d wildcard=%d", stream.calls, legacy.calls, wildcard.calls)
+	}
+
+	// Without a handler for the Kafka topic, the message falls back to msg.Topic; wildcards
+	// matching both topics still run once.
+	if err := registry.DispatchFrom(context.Background(), "mesa-ya.unknown.events", msg); err != nil {
+		t.Fatalf("dispatch failed: %v", err)
+	}
+	if stream.calls != 1 || legacy.calls != 1 || wildcard.calls != 2 {
+		t.Fatalf("fallback: unexpected calls stream=%d legacy=%d wildcard=%d", stream.calls, legacy.calls, wildcard.calls)
+	}
+}
+
+func TestHandlerRegistryDispatch_MultipleHandlersAndWildcards(t *testing.T) {
+	registry := NewHandlerRegistry()
+	registry.Use(RecoveryMiddleware())
+	first := &recordingHandler{topic: "reservations.created", err: errors.New("first failed")}
+	second := &recordingHandler{topic: "reservations.created", panic: true}
+	pattern := &recordingHandler{topic: "reservations.*"}
+	other := &recordingHandler{topic: "tables.*"}
+	registry.Register(first)
+	registry.Register(second)
+	registry.Register(pattern)
+	registry.Register(other)
+
+	err := registry.Dispatch(context.Background(), &domain.Message{Topic: "reservations.created"})
+	if err == nil {
+		t.Fatal("expected aggregated error")
+	}
+	if first.calls != 1 || second.calls != 1 || pattern.calls != 1 || other.calls != 0 {
+		t.Fatalf("unexpected calls first=%d second=%d pattern=%d other=%d", first.calls, second.calls, pattern.calls, other.calls)
+	}
+	if !registry.Has("reservations.deleted") || registry.Has("users.created") {
+		t.Fatal("unexpected Has result")
+	}
+}
+
+func TestDedupeMiddleware_SkipsRedeliveries(t *testing.T) {
+	registry := NewHandlerRegistry()
+	registry.Use(DedupeMiddleware(time.Minute))
+	handler := &recordingHandler{topic: "payments.updated"}
+	registry.Register(handler)
+
+	delivery := func(offset int64) context.Context {
+		ctx := port.WithSourceTopic(context.Background(), "mesa-ya.payments.events")
+		return port.WithSourcePosition(ctx, port.SourcePosition{Partition: 1, Offset: offset})
+	}
+	msg := &domain.Message{Topic: "payments.updated", ResourceID: "pay-1", Data: map[string]any{"status": "paid"}}
+
+	// offset 10 twice is a redelivery; offset 11 is a new event with the same content.
+	for _, ctx := range []context.Context{delivery(10), delivery(10), delivery(11), context.Background(), context.Background()} {
+		if err := registry.Dispatch(ctx, msg); err != nil {
+			t.Fatalf("dispatch failed: %v", err)
+		}
+	}
+	if handler.calls != 4 {
+		t.Fatalf("expected 4 calls, got %d", handler.calls)
+	}
+
+	handler.err = errors.New("temporary failure")
+	if err := registry.Dispatch(delivery(12), msg); err == nil {
+		t.Fatal("expected handler error")
+	}
+	handler.err = nil
+	if err := registry.Dispatch(delivery(12), msg); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if handler.calls != 6 {
+		t.Fatalf("failed deliveries must not be recorded, got %d calls", handler.calls)
+	}
+}
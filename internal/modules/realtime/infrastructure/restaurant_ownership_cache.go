@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+)
+
+// CachedRestaurantOwnership memoizes a RestaurantOwnershipResolver per token for ttl, so
+// analytics checks and notification filtering do not hit the REST API on every request.
+// Concurrent lookups of the same token share one call.
+type CachedRestaurantOwnership struct {
+	next port.RestaurantOwnershipResolver
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*ownershipEntry
+}
+
+type ownershipEntry struct {
+	ready     chan struct{}
+	ids       []string
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachedRestaurantOwnership wraps next; a non-positive ttl disables the cache.
+func NewCachedRestaurantOwnership(next port.RestaurantOwnershipResolver, ttl time.Duration) *CachedRestaurantOwnership {
+	return &CachedRestaurantOwnership{next: next, ttl: ttl, now: time.Now, entries: make(map[string]*ownershipEntry)}
+}
+
+// OwnedRestaurants returns the cached restaurants of token, resolving them when missing or
+// expired. Errors are not cached.
+func (c *CachedRestaurantOwnership) OwnedRestaurants(ctx context.Context, token string) ([]string, error) {
+	if c.ttl <= 0 {
+		return c.next.OwnedRestaurants(ctx, token)
+	}
+
+	c.mu.Lock()
+	now := c.now()
+	for key, entry := range c.entries {
+		if isReady(entry) && now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	entry, ok := c.entries[token]
+	if !ok {
+		entry = &ownershipEntry{ready: make(chan struct{})}
+		c.entries[token] = entry
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		ids, err := c.next.OwnedRestaurants(ctx, token)
+		c.mu.Lock()
+		entry.ids, entry.err = ids, err
+		entry.expiresAt = c.now().Add(c.ttl)
+		if err != nil && c.entries[token] == entry {
+			delete(c.entries, token)
+		}
+		close(entry.ready)
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return append([]string(nil), entry.ids...), nil
+}
+
+func isReady(entry *ownershipEntry) bool {
+	select {
+	case <-entry.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+var _ port.RestaurantOwnershipResolver = (*CachedRestaurantOwnership)(nil)
@@ -0,0 +1,96 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/shared/normalization"
+)
+
+const ownedRestaurantsPath = "/api/v1/restaurants/me"
+
+// RestaurantOwnershipHTTPClient implements RestaurantOwnershipResolver with the owner listing of
+// the REST API (GET /api/v1/restaurants/me).
+type RestaurantOwnershipHTTPClient struct {
+	rest    *RESTClient
+	timeout time.Duration
+}
+
+// NewRestaurantOwnershipHTTPClient creates a new ownership REST client.
+func NewRestaurantOwnershipHTTPClient(baseURL string, timeout time.Duration, client *http.Client) *RestaurantOwnershipHTTPClient {
+	return &RestaurantOwnershipHTTPClient{rest: NewRESTClient(baseURL, timeout, client), timeout: timeoutOrDefault(timeout)}
+}
+
+// OwnedRestaurants returns the ids of the restaurants owned by the token user.
+func (c *RestaurantOwnershipHTTPClient) OwnedRestaurants(ctx context.Context, token string) ([]string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := c.rest.NewRequest(ctx, http.MethodGet, ownedRestaurantsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if trimmedToken := strings.TrimSpace(token); trimmedToken != "" {
+		req.Header.Set("Authorization", "Bearer "+trimmedToken)
+	}
+
+	res, err := c.rest.Do(req)
+	if err != nil {
+		slog.Error("owned restaurants request error", slog.Any("error", err))
+		return nil, fmt.Errorf("owned restaurants request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return nil, port.ErrAnalyticsForbidden
+	default:
+		return nil, fmt.Errorf("unexpected owned restaurants response %d", res.StatusCode)
+	}
+
+	var payload any
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode owned restaurants: %w", err)
+	}
+	return restaurantIDs(payload), nil
+}
+
+// restaurantIDs extracts the ids from a list payload, plain or wrapped in data/items.
+func restaurantIDs(payload any) []string {
+	items := normalization.AsInterfaceSlice(payload)
+	if envelope, ok := payload.(map[string]any); ok {
+		items = normalization.AsInterfaceSlice(envelope["data"])
+		if items == nil {
+			items = normalization.AsInterfaceSlice(envelope["items"])
+		}
+		if items == nil {
+			if data := normalization.MapFromPayload(envelope); data != nil {
+				items = normalization.AsInterfaceSlice(data["items"])
+			}
+		}
+	}
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if id := normalization.AsString(entry["id"]); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+var _ port.RestaurantOwnershipResolver = (*RestaurantOwnershipHTTPClient)(nil)
@@ -14,13 +14,17 @@ import (
 
 	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
 	"mesaYaWs/internal/shared/normalization"
 )
 
 // SectionSnapshotHTTPClient implements SectionSnapshotFetcher using the REST API described in swagger.json.
 type SectionSnapshotHTTPClient struct {
-	rest    *RESTClient
-	timeout time.Duration
+	rest     *RESTClient
+	timeout  time.Duration
+	entities *entities.Registry
+	// endpoints are the REST paths of the entity registry, by canonical entity.
+	endpoints map[string]entityEndpoint
 }
 
 type pathBuilder func(string) (string, error)
@@ -32,265 +36,73 @@ type endpointVariant struct {
 	filterAliases     map[string]string
 }
 
+// entityEndpoint resolves the REST paths of an entity of the registry per audience.
 type entityEndpoint struct {
-	defaultVariant endpointVariant
-	ownerVariant   *endpointVariant
+	definition entities.Definition
 }
 
 func (e entityEndpoint) resolveVariant(audience port.SnapshotAudience) endpointVariant {
-	variant := e.defaultVariant
-	if audience == port.SnapshotAudienceOwner && e.ownerVariant != nil {
-		variant = mergeVariants(variant, *e.ownerVariant)
+	endpoint, _ := e.definition.Endpoint(string(audience))
+	return endpointVariant{
+		listPathBuilder:   templatePathBuilder(endpoint.List, entities.SectionPlaceholder),
+		detailPathBuilder: templatePathBuilder(endpoint.Detail, entities.ResourcePlaceholder),
+		sectionQueryKey:   endpoint.SectionQuery,
+		filterAliases:     endpoint.Filters,
 	}
-	return variant
 }
 
-func mergeVariants(base, override endpointVariant) endpointVariant {
-	result := base
-	if override.listPathBuilder != nil {
-		result.listPathBuilder = override.listPathBuilder
-	}
-	if override.detailPathBuilder != nil {
-		result.detailPathBuilder = override.detailPathBuilder
-	}
-	if strings.TrimSpace(override.sectionQueryKey) != "" {
-		result.sectionQueryKey = override.sectionQueryKey
-	}
-	if override.filterAliases != nil {
-		result.filterAliases = override.filterAliases
-	}
-	return result
-}
-
-var entityEndpoints = map[string]entityEndpoint{
-	"restaurants": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/restaurants"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/restaurants"),
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/restaurants/me"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/restaurants"),
-		},
-	},
-	"tables": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/tables"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/tables"),
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-				"sectionid":    "sectionId",
-			},
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder:   requiredValuePathBuilder("/api/v1/tables/section/%s"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/tables"),
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-				"sectionid":    "sectionId",
-			},
-		},
-	},
-	"reservations": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/reservations"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/reservations"),
-			filterAliases: map[string]string{
-				"status":       "status",
-				"restaurantid": "restaurantId",
-				"date":         "date",
-			},
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/reservations"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/reservations"),
-			sectionQueryKey:   "restaurantId",
-			filterAliases: map[string]string{
-				"status":       "status",
-				"restaurantid": "restaurantId",
-				"ownerid":      "",
-				"date":         "date",
-			},
-		},
-	},
-	"reviews": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/reviews"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/reviews"),
-		},
-	},
-	"sections": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/sections"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/sections"),
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-			},
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/sections"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/sections"),
-			sectionQueryKey:   "restaurantId",
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-			},
-		},
-	},
-	"objects": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/objects"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/objects"),
-		},
-	},
-	"menus": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/menus"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/menus"),
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-			},
-		},
-	},
-	"dishes": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/dishes"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/dishes"),
-			filterAliases: map[string]string{
-				"restaurantid": "restaurantId",
-			},
-		},
-	},
-	"images": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/images"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/images"),
-			filterAliases: map[string]string{
-				"entityid": "entityId",
-			},
-		},
-	},
-	"section-objects": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/section-objects"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/section-objects"),
-		},
-	},
-	"payments": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/payments"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/payments"),
-			filterAliases: map[string]string{
-				"status":        "status",
-				"type":          "type",
-				"reservationid": "reservationId",
-				"restaurantid":  "restaurantId",
-				"startdate":     "startDate",
-				"enddate":       "endDate",
-				"minamount":     "minAmount",
-				"maxamount":     "maxAmount",
-			},
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/payments"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/payments"),
-			sectionQueryKey:   "restaurantId",
-		},
-	},
-	"schedules": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   requiredValuePathBuilder("/api/v1/restaurants/%s/schedules"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/schedules"),
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder: requiredValuePathBuilder("/api/v1/restaurants/%s/schedules"),
-		},
-	},
-	"subscriptions": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/subscriptions"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/subscriptions"),
-		},
-		ownerVariant: &endpointVariant{
-			listPathBuilder: staticPathBuilder("/api/v1/subscriptions"),
-			sectionQueryKey: "restaurantId",
-		},
-	},
-	"subscription-plans": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/subscription-plans"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/subscription-plans"),
-		},
-	},
-	"users": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/users"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/users"),
-			filterAliases: map[string]string{
-				"status":       "status",
-				"role":         "role",
-				"restaurantid": "restaurantId",
-				"active":       "active",
-			},
-		},
-		ownerVariant: &endpointVariant{
-			filterAliases: map[string]string{
-				"status":       "status",
-				"role":         "role",
-				"restaurantid": "restaurantId",
-				"active":       "active",
-			},
-		},
-	},
-	"owner-upgrades": {
-		defaultVariant: endpointVariant{
-			listPathBuilder:   staticPathBuilder("/api/v1/owner-upgrades"),
-			detailPathBuilder: resourcePathBuilder("/api/v1/owner-upgrades"),
-			filterAliases: map[string]string{
-				"status": "status",
-				"userid": "userId",
-			},
-		},
-	},
-}
-
-func staticPathBuilder(path string) pathBuilder {
-	trimmed := strings.TrimSpace(path)
-	return func(string) (string, error) {
-		if trimmed == "" {
-			return "", fmt.Errorf("missing path configuration")
+// newEntityEndpoints indexes the entities of registry that declare REST paths.
+func newEntityEndpoints(registry *entities.Registry) map[string]entityEndpoint {
+	endpoints := make(map[string]entityEndpoint)
+	for _, definition := range registry.Definitions() {
+		if _, ok := definition.Endpoint(entities.AudienceDefault); ok {
+			endpoints[definition.Name] = entityEndpoint{definition: definition}
 		}
-		return trimmed, nil
 	}
+	return endpoints
 }
 
-func requiredValuePathBuilder(format string) pathBuilder {
-	trimmed := strings.TrimSpace(format)
+// templatePathBuilder builds a path from template, replacing placeholder with the escaped
+// value; templates without placeholder are static and an empty template builds nothing.
+func templatePathBuilder(template, placeholder string) pathBuilder {
+	trimmed := strings.TrimSpace(template)
+	switch {
+	case trimmed == "":
+		return nil
+	case !strings.Contains(trimmed, placeholder):
+		return staticPathBuilder(trimmed)
+	}
 	return func(value string) (string, error) {
 		identifier := strings.TrimSpace(value)
 		if identifier == "" {
 			return "", port.ErrSnapshotNotFound
 		}
-		return fmt.Sprintf(trimmed, url.PathEscape(identifier)), nil
+		return strings.ReplaceAll(trimmed, placeholder, url.PathEscape(identifier)), nil
 	}
 }
 
-func resourcePathBuilder(base string) pathBuilder {
-	trimmed := strings.TrimSpace(base)
-	return func(value string) (string, error) {
-		identifier := strings.TrimSpace(value)
-		if identifier == "" {
-			return "", port.ErrSnapshotNotFound
+func staticPathBuilder(path string) pathBuilder {
+	trimmed := strings.TrimSpace(path)
+	return func(string) (string, error) {
+		if trimmed == "" {
+			return "", fmt.Errorf("missing path configuration")
 		}
-		return strings.TrimRight(trimmed, "/") + "/" + url.PathEscape(identifier), nil
+		return trimmed, nil
 	}
 }
 
-func NewSectionSnapshotHTTPClient(baseURL string, timeout time.Duration, client *http.Client) *SectionSnapshotHTTPClient {
-	return &SectionSnapshotHTTPClient{rest: NewRESTClient(baseURL, timeout, client), timeout: timeoutOrDefault(timeout)}
+// NewSectionSnapshotHTTPClient creates the client for the REST paths declared in registry.
+func NewSectionSnapshotHTTPClient(baseURL string, timeout time.Duration, client *http.Client, registry *entities.Registry) *SectionSnapshotHTTPClient {
+	return &SectionSnapshotHTTPClient{
+		rest:      NewRESTClient(baseURL, timeout, client),
+		timeout:   timeoutOrDefault(timeout),
+		entities:  registry,
+		endpoints: newEntityEndpoints(registry),
+	}
 }
 
 func (c *SectionSnapshotHTTPClient) FetchEntityList(ctx context.Context, token, entity string, snapshotCtx port.SnapshotContext, query domain.PagedQuery) (*domain.SectionSnapshot, error) {
-	endpoint, ok := entityEndpoints[strings.ToLower(strings.TrimSpace(entity))]
+	endpoint, ok := c.endpoints[c.entities.Normalize(entity)]
 	variant := endpoint.resolveVariant(snapshotCtx.Audience)
 	if !ok || variant.listPathBuilder == nil {
 		slog.Warn("snapshot list entity unsupported", slog.String("entity", entity))
@@ -324,7 +136,7 @@ func (c *SectionSnapshotHTTPClient) FetchEntityList(ctx context.Context, token,
 }
 
 func (c *SectionSnapshotHTTPClient) FetchEntityDetail(ctx context.Context, token, entity string, snapshotCtx port.SnapshotContext, resourceID string) (*domain.SectionSnapshot, error) {
-	endpoint, ok := entityEndpoints[strings.ToLower(strings.TrimSpace(entity))]
+	endpoint, ok := c.endpoints[c.entities.Normalize(entity)]
 	variant := endpoint.resolveVariant(snapshotCtx.Audience)
 	if !ok || variant.detailPathBuilder == nil {
 		slog.Warn("snapshot detail entity unsupported", slog.String("entity", entity))
@@ -350,7 +162,12 @@ func (c *SectionSnapshotHTTPClient) FetchEntityDetail(ctx context.Context, token
 		return nil, err
 	}
 
-	return c.performDetailRequest(ctx, token, detailPath)
+	snapshot, err := c.performDetailRequest(ctx, token, detailPath)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.ResourceKey = c.entities.ResourceKey(endpoint.definition.Name)
+	return snapshot, nil
 }
 
 func (c *SectionSnapshotHTTPClient) performListRequest(ctx context.Context, token, path, sectionID string, query domain.PagedQuery, extras map[string]string, variant endpointVariant) (*domain.SectionSnapshot, error) {
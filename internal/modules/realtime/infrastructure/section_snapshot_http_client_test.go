@@ -11,6 +11,7 @@ import (
 
 	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/entities"
 )
 
 func TestBuildQueryValues_UsesFilterAliases(t *testing.T) {
@@ -70,6 +71,7 @@ func TestEndpointVariantMapFilterKey_FallsBackToTrimmedKey(t *testing.T) {
 
 func TestEntityEndpointsResolveOwnerVariants(t *testing.T) {
 	t.Parallel()
+	entityEndpoints := newEntityEndpoints(entities.Default())
 
 	t.Run("sections", func(t *testing.T) {
 		sections := entityEndpoints["sections"].resolveVariant(port.SnapshotAudienceOwner)
@@ -204,7 +206,7 @@ func TestSectionSnapshotHTTPClient_OwnerReservations(t *testing.T) {
 	}))
 	defer server.Close()
 
-	client := NewSectionSnapshotHTTPClient(server.URL, time.Second, server.Client())
+	client := NewSectionSnapshotHTTPClient(server.URL, time.Second, server.Client(), entities.Default())
 	ctx := port.SnapshotContext{SectionID: "rest-123", Audience: port.SnapshotAudienceOwner}
 	query := domain.PagedQuery{
 		Filters: map[string]string{
@@ -249,9 +251,13 @@ func TestSectionSnapshotHTTPClient_OwnerReservations(t *testing.T) {
 		t.Fatalf("unexpected items payload: %#v", payload["items"])
 	}
 
-	if _, err := client.FetchEntityDetail(context.Background(), "token-abc", "reservations", ctx, "res-1"); err != nil {
+	detail, err := client.FetchEntityDetail(context.Background(), "token-abc", "reservations", ctx, "res-1")
+	if err != nil {
 		t.Fatalf("detail fetch failed: %v", err)
 	}
+	if detail.ResourceKey != "reservationId" {
+		t.Fatalf("unexpected resource key: %q", detail.ResourceKey)
+	}
 
 	detailReq := <-requests
 	if got := detailReq.URL.Path; got != "/api/v1/reservations/res-1" {
@@ -6,10 +6,12 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 
+	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
 )
 
@@ -26,15 +28,25 @@ type Client struct {
 	subscribed map[string]struct{}
 	closeOnce  sync.Once
 	receiveAll bool
+	roles      []string
+	restaurant string
 	closeHooks []func(*Client)
-	hookMu     sync.Mutex
-	mu         sync.Mutex
-	closed     bool
+	// channels holds the joined sections per entity of a multiplexed client (nil otherwise).
+	channels  map[string]map[string]struct{}
+	channelMu sync.RWMutex
+	// filter, when set, narrows the broadcasts the client receives (see SetFilter).
+	filter func(*domain.Message) bool
+	// render, when set, decorates every message sent to the client (see SetRenderer).
+	render func(*domain.Message) *domain.Message
+	hookMu sync.Mutex
+	mu     sync.Mutex
+	closed bool
 }
 
 type Command struct {
 	Action  string          `json:"action"`
 	Topic   string          `json:"topic,omitempty"`
+	Channel string          `json:"channel,omitempty"`
 	Payload json.RawMessage `json:"payload,omitempty"`
 }
 
@@ -60,6 +72,44 @@ func (c *Client) EnableReceiveAll() {
 	c.receiveAll = true
 }
 
+// SetAudience records the client roles and restaurant so targeted broadcasts can select it.
+// Call it before attaching the client to the hub.
+func (c *Client) SetAudience(roles []string, restaurantID string) {
+	c.roles = append([]string(nil), roles...)
+	c.restaurant = strings.TrimSpace(restaurantID)
+}
+
+// SetFilter restricts the broadcasts delivered to the client to those accepted by filter
+// (e.g. the notifications of the recipient). Call it before attaching the client to the hub.
+func (c *Client) SetFilter(filter func(*domain.Message) bool) {
+	c.filter = filter
+}
+
+// SetRenderer decorates the messages sent to the client (e.g. notification texts in its
+// locale); render returns msg itself when it has nothing to add. Call it before attaching the
+// client to the hub.
+func (c *Client) SetRenderer(render func(*domain.Message) *domain.Message) {
+	c.render = render
+}
+
+// Subscribe adds topics to the client subscriptions (e.g. analytics keys watched later on).
+func (c *Client) Subscribe(topics ...string) {
+	for _, topic := range topics {
+		if topic != "" {
+			c.hub.subscribe(c, topic)
+		}
+	}
+}
+
+// Unsubscribe removes topics from the client subscriptions.
+func (c *Client) Unsubscribe(topics ...string) {
+	for _, topic := range topics {
+		if topic != "" {
+			c.hub.unsubscribe(c, topic)
+		}
+	}
+}
+
 func (c *Client) key() string {
 	parts := []string{c.userID, c.sessionID}
 	if c.sectionID != "" {
@@ -75,7 +125,9 @@ func (c *Client) close() {
 		close(c.send)
 		c.mu.Unlock()
 
-		_ = c.conn.Close()
+		if c.conn != nil {
+			_ = c.conn.Close()
+		}
 		c.invokeCloseHooks()
 	})
 }
@@ -109,24 +161,41 @@ func (c *Client) invokeCloseHooks() {
 }
 
 func (c *Client) SendDomainMessage(msg *domain.Message) {
+	msg = c.outgoing(msg)
 	data, err := json.Marshal(msg)
 	if err != nil {
 		slog.Error("websocket marshal error", slog.Any("error", err))
 		return
 	}
 
+	dropReason := c.enqueue(data)
+	if recorder := c.hub.currentRecorder(); recorder != nil {
+		delivery := Delivery{Kind: DeliveryDirect, Message: msg}
+		if dropReason == "" {
+			delivery.Delivered = []string{c.key()}
+		} else {
+			delivery.Dropped = []DroppedDelivery{{Client: c.key(), Reason: dropReason}}
+		}
+		recorder.RecordDelivery(context.Background(), delivery)
+	}
+}
+
+// enqueue queues data for the write pump and returns the drop reason when it could not.
+func (c *Client) enqueue(data []byte) string {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.closed {
-		return
+		return DropClientClosed
 	}
 
 	select {
 	case c.send <- data:
+		return ""
 	default:
 		slog.Warn("websocket send buffer full", slog.String("userId", c.userID), slog.String("sessionId", c.sessionID), slog.String("sectionId", c.sectionID))
 		go c.hub.detachClient(c)
+		return DropBufferFull
 	}
 }
 
@@ -205,10 +274,11 @@ func (c *Client) handleCommand(cmd Command) {
 }
 
 type Hub struct {
-	topics  map[string]map[*Client]struct{}
-	clients map[string]*Client
-	global  map[*Client]struct{}
-	mu      sync.RWMutex
+	topics   map[string]map[*Client]struct{}
+	clients  map[string]*Client
+	global   map[*Client]struct{}
+	mu       sync.RWMutex
+	recorder atomic.Pointer[recorderHolder]
 }
 
 func NewHub() *Hub {
@@ -278,12 +348,84 @@ func (h *Hub) detachLocked(c *Client) {
 	slog.Info("ws client detached", slog.String("userId", c.userID), slog.String("sessionId", c.sessionID), slog.String("sectionId", c.sectionID))
 }
 
-func (h *Hub) Broadcast(_ context.Context, msg *domain.Message) {
+// Broadcast sends msg to the subscribers of its topic and the global subscribers. The userId,
+// sessionId and sectionId metadata narrow the recipients.
+func (h *Hub) Broadcast(ctx context.Context, msg *domain.Message) {
+	h.deliver(ctx, msg, metadataTarget(msg))
+}
+
+// BroadcastTo sends msg to the subscribers of its topic (and global subscribers) matching
+// target, and returns how many clients received it.
+func (h *Hub) BroadcastTo(ctx context.Context, msg *domain.Message, target port.BroadcastTarget) int {
+	return h.deliver(ctx, msg, target)
+}
+
+func (h *Hub) deliver(ctx context.Context, msg *domain.Message, target port.BroadcastTarget) int {
 	data, err := json.Marshal(msg)
 	if err != nil {
 		slog.Error("broadcast marshal error", slog.Any("error", err))
-		return
+		return 0
 	}
+
+	recorder := h.currentRecorder()
+	targets, dropped := h.route(msg, target, recorder != nil)
+	delivery := Delivery{Dropped: dropped}
+	delivered := 0
+	for _, c := range targets {
+		select {
+		case c.send <- c.clientData(msg, data):
+			delivered++
+			if recorder != nil {
+				delivery.Delivered = append(delivery.Delivered, c.key())
+			}
+		default:
+			if recorder != nil {
+				delivery.Dropped = append(delivery.Dropped, DroppedDelivery{Client: c.key(), Reason: DropBufferFull})
+			}
+			go h.detachClient(c)
+		}
+	}
+
+	if recorder != nil {
+		delivery.Kind = DeliveryBroadcast
+		delivery.SourceTopic = port.SourceTopic(ctx)
+		delivery.Message = msg
+		recorder.RecordDelivery(ctx, delivery)
+	}
+	return delivered
+}
+
+// Targets reports which attached clients Broadcast would deliver msg to, and which ones the
+// metadata targeting would skip, without sending anything.
+func (h *Hub) Targets(msg *domain.Message) ([]string, []DroppedDelivery) {
+	targets, dropped := h.route(msg, metadataTarget(msg), true)
+	keys := make([]string, 0, len(targets))
+	for _, c := range targets {
+		keys = append(keys, c.key())
+	}
+	return keys, dropped
+}
+
+func metadataTarget(msg *domain.Message) port.BroadcastTarget {
+	var target port.BroadcastTarget
+	if msg.Metadata == nil {
+		return target
+	}
+	if value := strings.TrimSpace(msg.Metadata["userId"]); value != "" {
+		target.UserIDs = []string{value}
+	}
+	if value := strings.TrimSpace(msg.Metadata["sessionId"]); value != "" {
+		target.SessionIDs = []string{value}
+	}
+	if value := strings.TrimSpace(msg.Metadata["sectionId"]); value != "" {
+		target.SectionIDs = []string{value}
+	}
+	return target
+}
+
+// route selects the subscribers of msg.Topic plus the global subscribers, narrowed by target.
+// Skipped clients are only collected when withDrops is set.
+func (h *Hub) route(msg *domain.Message, target port.BroadcastTarget, withDrops bool) ([]*Client, []DroppedDelivery) {
 	h.mu.RLock()
 	clientsMap := h.topics[msg.Topic]
 	clients := make([]*Client, 0, len(clientsMap)+len(h.global))
@@ -300,31 +442,71 @@ func (h *Hub) Broadcast(_ context.Context, msg *domain.Message) {
 	}
 	h.mu.RUnlock()
 
-	targetUser := ""
-	targetSession := ""
-	targetSection := ""
-	if msg.Metadata != nil {
-		targetUser = strings.TrimSpace(msg.Metadata["userId"])
-		targetSession = strings.TrimSpace(msg.Metadata["sessionId"])
-		targetSection = strings.TrimSpace(msg.Metadata["sectionId"])
-	}
-
+	targets := clients[:0]
+	var dropped []DroppedDelivery
 	for _, c := range clients {
-		if targetUser != "" && c.userID != targetUser {
-			continue
+		var reason string
+		if !target.IsZero() {
+			reason = c.targetMismatch(msg.Entity, target)
 		}
-		if targetSession != "" && c.sessionID != targetSession {
-			continue
+		if reason == "" && c.filter != nil && !c.filter(msg) {
+			reason = DropFiltered
 		}
-		if targetSection != "" && c.sectionID != targetSection {
+		if reason == "" {
+			targets = append(targets, c)
 			continue
 		}
-		select {
-		case c.send <- data:
-		default:
-			go h.detachClient(c)
+		if withDrops {
+			dropped = append(dropped, DroppedDelivery{Client: c.key(), Reason: reason})
+		}
+	}
+	return targets, dropped
+}
+
+// targetMismatch returns the drop reason when the client is outside target, or "". The sections
+// and restaurants of a multiplexed client are the channels it joined for entity.
+func (c *Client) targetMismatch(entity string, target port.BroadcastTarget) string {
+	multiplexed := c.multiplexed()
+	switch {
+	case len(target.UserIDs) > 0 && !containsTrimmed(target.UserIDs, c.userID, false):
+		return DropTargetUser
+	case len(target.SessionIDs) > 0 && !containsTrimmed(target.SessionIDs, c.sessionID, false):
+		return DropTargetSession
+	case len(target.SectionIDs) > 0 && multiplexed && c.channelFor(entity, target.SectionIDs) == "":
+		return DropTargetSection
+	case len(target.SectionIDs) > 0 && !multiplexed && !containsTrimmed(target.SectionIDs, c.sectionID, false):
+		return DropTargetSection
+	case len(target.RestaurantIDs) > 0 && multiplexed && c.channelFor(entity, target.RestaurantIDs) == "":
+		return DropTargetRest
+	case len(target.RestaurantIDs) > 0 && !multiplexed && !containsTrimmed(target.RestaurantIDs, c.restaurant, false):
+		return DropTargetRest
+	case len(target.Roles) > 0 && !c.hasAnyRole(target.Roles):
+		return DropTargetRole
+	}
+	return ""
+}
+
+func (c *Client) hasAnyRole(roles []string) bool {
+	for _, role := range c.roles {
+		if containsTrimmed(roles, role, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsTrimmed(values []string, candidate string, foldCase bool) bool {
+	candidate = strings.TrimSpace(candidate)
+	if candidate == "" {
+		return false
+	}
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == candidate || (foldCase && strings.EqualFold(value, candidate)) {
+			return true
 		}
 	}
+	return false
 }
 
 func (h *Hub) AttachClient(c *Client, topics []string) {
@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+func TestHubBroadcastTo_FiltersByRoleAndRestaurant(t *testing.T) {
+	hub := NewHub()
+	owner := NewClient(hub, nil, "u-1", "s-1", "rest-1", "restaurants", "", 4, nil)
+	owner.SetAudience([]string{"OWNER"}, "rest-1")
+	otherOwner := NewClient(hub, nil, "u-2", "s-2", "rest-2", "restaurants", "", 4, nil)
+	otherOwner.SetAudience([]string{"OWNER"}, "rest-2")
+	client := NewClient(hub, nil, "u-3", "s-3", "rest-1", "restaurants", "", 4, nil)
+	client.SetAudience([]string{"CLIENT"}, "rest-1")
+	admin := NewClient(hub, nil, "u-4", "s-4", "", "", "", 4, nil)
+	admin.SetAudience([]string{"admin"}, "")
+	for _, c := range []*Client{owner, otherOwner, client} {
+		hub.AttachClient(c, []string{"restaurants.announcement"})
+	}
+	hub.AttachClientToAll(admin)
+
+	msg := &domain.Message{Topic: "restaurants.announcement", Entity: "restaurants", Action: "announcement"}
+	delivered := hub.BroadcastTo(context.Background(), msg, port.BroadcastTarget{RestaurantIDs: []string{"rest-1"}, Roles: []string{"OWNER"}})
+	if delivered != 1 || len(owner.send) != 1 {
+		t.Fatalf("expected only the rest-1 owner, delivered=%d", delivered)
+	}
+
+	delivered = hub.BroadcastTo(context.Background(), msg, port.BroadcastTarget{Roles: []string{"ADMIN", "CLIENT"}})
+	if delivered != 2 || len(admin.send) != 1 || len(client.send) != 1 {
+		t.Fatalf("expected admin and client (case-insensitive roles), delivered=%d", delivered)
+	}
+
+	delivered = hub.BroadcastTo(context.Background(), msg, port.BroadcastTarget{})
+	if delivered != 4 {
+		t.Fatalf("empty target should reach every subscriber, delivered=%d", delivered)
+	}
+}
+
+func TestHubBroadcast_RoutesJoinedChannels(t *testing.T) {
+	hub := NewHub()
+	stand := NewClient(hub, nil, "u-1", "s-1", "", "", "", 4, nil)
+	stand.SetAudience([]string{"OWNER"}, "")
+	hub.AttachClient(stand, []string{"tables.updated"})
+	if !stand.JoinChannel("tables", "sec-1") || stand.JoinChannel("tables", "sec-2") {
+		t.Fatal("only the first channel of an entity should report a new subscription")
+	}
+
+	ctx := context.Background()
+	hub.Broadcast(ctx, &domain.Message{Topic: "tables.updated", Entity: "tables", Action: "updated", Metadata: map[string]string{"sectionId": "sec-2"}})
+	hub.Broadcast(ctx, &domain.Message{Topic: "tables.updated", Entity: "tables", Action: "updated", Metadata: map[string]string{"sectionId": "sec-9"}})
+	if len(stand.send) != 1 {
+		t.Fatalf("expected only the joined section, got %d messages", len(stand.send))
+	}
+	var received domain.Message
+	if err := json.Unmarshal(<-stand.send, &received); err != nil {
+		t.Fatal(err)
+	}
+	if received.Metadata["channel"] != "tables:sec-2" {
+		t.Fatalf("broadcast not tagged with its channel: %v", received.Metadata)
+	}
+
+	if stand.LeaveChannel("tables", "sec-1") || !stand.LeaveChannel("tables", "sec-2") {
+		t.Fatal("only the last channel of an entity should report the unsubscription")
+	}
+	hub.Broadcast(ctx, &domain.Message{Topic: "tables.updated", Entity: "tables", Action: "updated", Metadata: map[string]string{"sectionId": "sec-2"}})
+	if len(stand.send) != 0 {
+		t.Fatal("left channel still receives broadcasts")
+	}
+}
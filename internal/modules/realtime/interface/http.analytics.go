@@ -26,7 +26,7 @@ func NewAnalyticsWebsocketHandler(hub *infrastructure.Hub, analyticsUC *usecase.
 	return func(c echo.Context) error {
 		scopeParam := c.Param("scope")
 		entityParam := c.Param("entity")
-		key := usecase.AnalyticsKey(scopeParam, entityParam)
+		key := analyticsUC.Key(scopeParam, entityParam)
 		cfg, ok := analyticsUC.Endpoint(key)
 		if !ok {
 			slog.Warn("analytics ws unsupported endpoint", slog.String("scope", scopeParam), slog.String("entity", entityParam), slog.String("key", key))
@@ -77,26 +77,25 @@ func NewAnalyticsWebsocketHandler(hub *infrastructure.Hub, analyticsUC *usecase.
 		}
 
 		var (
-			userID    string
-			sessionID string
-			roles     []string
+			userID   string
+			tokenSID string
+			roles    []string
 		)
 		if claims := output.Claims; claims != nil {
 			userID = strings.TrimSpace(claims.RegisteredClaims.Subject)
-			sessionID = strings.TrimSpace(claims.SessionID)
+			tokenSID = strings.TrimSpace(claims.SessionID)
 			roles = claims.Roles
 		}
-		if sessionID == "" {
-			sessionID = fmt.Sprintf("%s-%d", cfg.Key, analyticsCounter.Add(1))
-		}
+		sessionID := analyticsConnectionID(cfg.Key, tokenSID)
 
 		topics := []string{domain.SnapshotTopic(cfg.Entity), domain.ErrorTopic(cfg.Entity)}
 		baseRequest := output.Request.Clone()
 		commandHandler := newAnalyticsCommandHandler(cfg.Key, cfg, analyticsUC, token, sessionID, &baseRequest)
 
 		client := infrastructure.NewClient(hub, conn, userID, sessionID, "", cfg.Entity, token, 4, commandHandler)
+		client.SetAudience(roles, analyticsRestaurantID(cfg, baseRequest))
 		hub.AttachClient(client, topics)
-		analyticsUC.RegisterSession(sessionID, cfg.Key, token, baseRequest)
+		analyticsUC.RegisterSession(sessionID, cfg.Key, token, output.Claims, baseRequest)
 		client.AddCloseHook(func(*infrastructure.Client) {
 			analyticsUC.UnregisterSession(sessionID)
 		})
@@ -143,6 +142,16 @@ func NewAnalyticsWebsocketHandler(hub *infrastructure.Hub, analyticsUC *usecase.
 	}
 }
 
+// analyticsConnectionID identifies one analytics socket. Sockets opened with the same token share
+// the JWT sid, which would let them evict each other in the hub and drop each other's analytics
+// sessions on close.
+func analyticsConnectionID(prefix, tokenSID string) string {
+	if tokenSID == "" {
+		tokenSID = prefix
+	}
+	return fmt.Sprintf("%s-%d", tokenSID, analyticsCounter.Add(1))
+}
+
 func extractBearerToken(r *http.Request) string {
 	authz := strings.TrimSpace(r.Header.Get("Authorization"))
 	lower := strings.ToLower(authz)
@@ -184,3 +193,11 @@ func newAnalyticsCommandHandler(key string, cfg usecase.AnalyticsEndpointConfig,
 		}
 	}
 }
+
+// analyticsRestaurantID returns the restaurant an analytics session is scoped to, if any.
+func analyticsRestaurantID(cfg usecase.AnalyticsEndpointConfig, req domain.AnalyticsRequest) string {
+	if cfg.IdentifierParam == "restaurantId" {
+		return req.Identifier
+	}
+	return req.Query["restaurantId"]
+}
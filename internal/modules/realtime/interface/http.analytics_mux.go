@@ -0,0 +1,208 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	"mesaYaWs/internal/shared/auth"
+)
+
+// maxAnalyticsWatches bounds the dashboards a single multiplexed connection may watch.
+const maxAnalyticsWatches = 32
+
+const analyticsMuxEntity = "analytics"
+
+// analyticsWatchCommand is the payload of watch/unwatch/refresh/query on /ws/analytics. The
+// dashboard is either Key or the Scope/Entity pair of /ws/analytics/:scope/:entity.
+type analyticsWatchCommand struct {
+	Key        string            `json:"key,omitempty"`
+	Scope      string            `json:"scope,omitempty"`
+	Entity     string            `json:"entity,omitempty"`
+	Identifier string            `json:"identifier,omitempty"`
+	Query      map[string]string `json:"query,omitempty"`
+}
+
+// analyticsWatches tracks the request of every key watched by one connection.
+type analyticsWatches struct {
+	mu       sync.Mutex
+	requests map[string]domain.AnalyticsRequest
+}
+
+// NewAnalyticsMuxWebsocketHandler exposes /ws/analytics: one connection that watches several
+// analytics keys, each with its own request parameters.
+func NewAnalyticsMuxWebsocketHandler(hub *infrastructure.Hub, analyticsUC *usecase.AnalyticsUseCase) func(echo.Context) error {
+	return func(c echo.Context) error {
+		token := auth.ExtractToken(c.Request(), "token")
+		claims, err := analyticsUC.Authenticate(token)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+		}
+
+		conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			slog.Error("analytics mux ws upgrade failed", slog.Any("error", err))
+			return err
+		}
+
+		var (
+			userID   string
+			tokenSID string
+			roles    []string
+		)
+		if claims != nil {
+			userID = strings.TrimSpace(claims.RegisteredClaims.Subject)
+			tokenSID = strings.TrimSpace(claims.SessionID)
+			roles = claims.Roles
+		}
+		sessionID := analyticsConnectionID("analytics-mux", tokenSID)
+
+		watches := &analyticsWatches{requests: make(map[string]domain.AnalyticsRequest)}
+		commandHandler := newAnalyticsMuxCommandHandler(analyticsUC, watches, token, sessionID)
+		client := infrastructure.NewClient(hub, conn, userID, sessionID, "", analyticsMuxEntity, token, 16, commandHandler)
+		client.SetAudience(roles, "")
+		hub.AttachClient(client, nil)
+		client.AddCloseHook(func(*infrastructure.Client) {
+			analyticsUC.UnregisterSession(sessionID)
+		})
+
+		go client.WritePump()
+		go client.ReadPump()
+
+		metadata := map[string]string{"sessionId": sessionID}
+		if userID != "" {
+			metadata["userId"] = userID
+		}
+		client.SendDomainMessage(&domain.Message{
+			Topic:     domain.TopicSystemConnected,
+			Entity:    domain.SystemEntity,
+			Action:    domain.ActionConnected,
+			Metadata:  metadata,
+			Data:      map[string]any{"mode": "analytics-mux", "roles": roles, "maxWatches": maxAnalyticsWatches},
+			Timestamp: time.Now().UTC(),
+		})
+		slog.Info("analytics mux ws connected", slog.String("userId", userID), slog.String("sessionId", sessionID))
+		return nil
+	}
+}
+
+func newAnalyticsMuxCommandHandler(analyticsUC *usecase.AnalyticsUseCase, watches *analyticsWatches, token, sessionID string) func(context.Context, *infrastructure.Client, infrastructure.Command) {
+	trimmedToken := strings.TrimSpace(token)
+	return func(cmdCtx context.Context, client *infrastructure.Client, cmd infrastructure.Command) {
+		action := strings.ToLower(strings.TrimSpace(cmd.Action))
+		payload, err := decodeCommand[analyticsWatchCommand](cmd.Payload)
+		if err != nil {
+			sendCommandError(client, analyticsMuxEntity, "", action, "invalid payload")
+			return
+		}
+		key := strings.TrimSpace(payload.Key)
+		if key == "" {
+			key = analyticsUC.Key(payload.Scope, payload.Entity)
+		}
+		cfg, ok := analyticsUC.Endpoint(key)
+		if !ok {
+			sendCommandError(client, analyticsMuxEntity, "", action, "analytics endpoint not available")
+			return
+		}
+
+		commandCtx, cancel := context.WithTimeout(cmdCtx, 15*time.Second)
+		defer cancel()
+
+		switch action {
+		case "watch":
+			watches.mu.Lock()
+			_, watching := watches.requests[cfg.Key]
+			full := !watching && len(watches.requests) >= maxAnalyticsWatches
+			watches.mu.Unlock()
+			if full {
+				sendCommandError(client, cfg.Entity, "", action, fmt.Sprintf("at most %d analytics keys per connection", maxAnalyticsWatches))
+				return
+			}
+			request := domain.AnalyticsRequest{Identifier: payload.Identifier, Query: payload.Query}
+			output, err := analyticsUC.Connect(commandCtx, cfg.Key, trimmedToken, cfg.SanitizeRequest(request))
+			if err != nil {
+				slog.Warn("analytics mux watch failed", slog.String("key", cfg.Key), slog.String("sessionId", sessionID), slog.Any("error", err))
+				sendCommandError(client, cfg.Entity, "", action, analyticsWatchError(err))
+				return
+			}
+			watches.mu.Lock()
+			watches.requests[cfg.Key] = output.Request.Clone()
+			watches.mu.Unlock()
+			client.Subscribe(domain.SnapshotTopic(cfg.Entity), domain.ErrorTopic(cfg.Entity))
+			analyticsUC.RegisterSession(sessionID, cfg.Key, trimmedToken, output.Claims, output.Request)
+			sendAnalyticsMuxMessage(client, cfg.Key, sessionID, output.Message)
+		case "unwatch":
+			watches.mu.Lock()
+			delete(watches.requests, cfg.Key)
+			watches.mu.Unlock()
+			client.Unsubscribe(domain.SnapshotTopic(cfg.Entity), domain.ErrorTopic(cfg.Entity))
+			analyticsUC.UnwatchSession(sessionID, cfg.Key)
+			client.SendDomainMessage(&domain.Message{
+				Topic:     domain.CustomTopic(analyticsMuxEntity, "unwatched"),
+				Entity:    analyticsMuxEntity,
+				Action:    "unwatched",
+				Metadata:  map[string]string{"analyticsKey": cfg.Key, "sessionId": sessionID},
+				Timestamp: time.Now().UTC(),
+			})
+		case "refresh", "fetch", "query":
+			watches.mu.Lock()
+			base, watching := watches.requests[cfg.Key]
+			watches.mu.Unlock()
+			if !watching {
+				sendCommandError(client, cfg.Entity, "", action, "analytics key not watched")
+				return
+			}
+			command := domain.AnalyticsCommand{Identifier: payload.Identifier, Query: payload.Query}
+			message, updated, err := analyticsUC.HandleCommand(commandCtx, cfg.Key, trimmedToken, base, command)
+			if err != nil {
+				slog.Warn("analytics mux command failed", slog.String("key", cfg.Key), slog.String("action", action), slog.Any("error", err))
+				sendCommandError(client, cfg.Entity, "", action, err.Error())
+				return
+			}
+			watches.mu.Lock()
+			if _, still := watches.requests[cfg.Key]; still {
+				watches.requests[cfg.Key] = updated.Clone()
+			}
+			watches.mu.Unlock()
+			analyticsUC.UpdateSession(sessionID, cfg.Key, trimmedToken, updated)
+			sendAnalyticsMuxMessage(client, cfg.Key, sessionID, message)
+		default:
+			sendCommandError(client, analyticsMuxEntity, "", action, "unsupported action")
+		}
+	}
+}
+
+func sendAnalyticsMuxMessage(client *infrastructure.Client, key, sessionID string, message *domain.Message) {
+	if message == nil {
+		return
+	}
+	if message.Metadata == nil {
+		message.Metadata = map[string]string{}
+	}
+	message.Metadata["analyticsKey"] = key
+	message.Metadata["sessionId"] = sessionID
+	client.SendDomainMessage(message)
+}
+
+func analyticsWatchError(err error) string {
+	switch {
+	case errors.Is(err, usecase.ErrMissingToken), errors.Is(err, auth.ErrMissingToken):
+		return "missing token"
+	case errors.Is(err, auth.ErrInvalidToken):
+		return "invalid token"
+	case errors.Is(err, usecase.ErrAnalyticsMissingIdentifier):
+		return "missing identifier"
+	default:
+		return err.Error()
+	}
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/shared/auth"
+)
+
+// NewAnalyticsRegistryHTTPHandler exposes the resolved analytics registry (endpoints, aliases and
+// entity dependencies) to administrators, in the ANALYTICS_CONFIG_FILE format.
+func NewAnalyticsRegistryHTTPHandler(analyticsUC *usecase.AnalyticsUseCase, validator auth.TokenValidator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := extractBearerToken(c.Request())
+		if token == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "missing token")
+		}
+		claims, err := validator.Validate(token)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+		}
+		if !claimsHaveRole(claims, roleAdmin) {
+			return echo.NewHTTPError(http.StatusForbidden, "admin role required")
+		}
+		return c.JSON(http.StatusOK, analyticsUC.Registry())
+	}
+}
+
+func claimsHaveRole(claims *auth.Claims, role string) bool {
+	if claims == nil {
+		return false
+	}
+	for _, candidate := range claims.Roles {
+		if strings.EqualFold(strings.TrimSpace(candidate), role) {
+			return true
+		}
+	}
+	return false
+}
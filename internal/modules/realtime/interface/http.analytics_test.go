@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
+)
+
+type staticValidator struct{ claims *auth.Claims }
+
+func (v staticValidator) Validate(string) (*auth.Claims, error) { return v.claims, nil }
+
+type staticAnalyticsFetcher struct{}
+
+func (staticAnalyticsFetcher) Fetch(context.Context, string, string, map[string]string) (*domain.AnalyticsSnapshot, error) {
+	return &domain.AnalyticsSnapshot{Payload: map[string]any{"total": 1}}, nil
+}
+
+func readTopic(t *testing.T, conn *websocket.Conn, topic string) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg domain.Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for %s: %v", topic, err)
+		}
+		if msg.Topic == topic {
+			return
+		}
+	}
+}
+
+func TestAnalyticsWebsocket_ClosingOneSocketKeepsTheOtherSession(t *testing.T) {
+	claims := &auth.Claims{SessionID: "sid-1", Roles: []string{"ADMIN"}}
+	claims.Subject = "admin-1"
+	analyticsUC := usecase.NewAnalyticsUseCase(staticValidator{claims: claims}, staticAnalyticsFetcher{}, entities.Default())
+	hub := infrastructure.NewHub()
+	e := echo.New()
+	e.GET("/ws/analytics/:scope/:entity", NewAnalyticsWebsocketHandler(hub, analyticsUC))
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/analytics/admin/restaurants"
+	header := http.Header{"Authorization": []string{"Bearer token"}}
+	snapshotTopic := domain.SnapshotTopic("analytics-admin-restaurants")
+	var conns []*websocket.Conn
+	for range 2 {
+		conn, _, err := websocket.DefaultDialer.Dial(url, header)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		readTopic(t, conn, snapshotTopic)
+		conns = append(conns, conn)
+	}
+
+	// Both sockets share the token sid: neither evicts the other, and closing the first must
+	// not end the second session.
+	if targets, _ := hub.Targets(&domain.Message{Topic: snapshotTopic}); len(targets) != 2 {
+		t.Fatalf("expected both sockets attached, got %v", targets)
+	}
+	_ = conns[0].Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		targets, _ := hub.Targets(&domain.Message{Topic: snapshotTopic})
+		if len(targets) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected one socket left, got %d", len(targets))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	analyticsUC.RefreshAll(context.Background(), usecase.NewBroadcastUseCase(hub))
+	readTopic(t, conns[1], snapshotTopic)
+}
@@ -90,6 +90,10 @@ func NewBroadcastHTTPHandler(broadcastUC *usecase.BroadcastUseCase) echo.Handler
 			msg.ResourceID = req.PaymentID
 		}
 
+		if err := authorizeBroadcast(c, msg.Topic, msg.Entity); err != nil {
+			return err
+		}
+
 		// Execute broadcast
 		broadcastUC.Execute(c.Request().Context(), msg)
 
@@ -0,0 +1,67 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/shared/auth"
+)
+
+const (
+	broadcastKeyContextKey = "broadcastKey"
+	maxBroadcastBodyBytes  = 1 << 20
+)
+
+// NewBroadcastAuthMiddleware requires a valid API key or HMAC signature (see auth.KeySet) on
+// the broadcast endpoints. The authenticated key is stored in the echo context so handlers
+// can enforce its topic/entity scope with authorizeBroadcast.
+func NewBroadcastAuthMiddleware(keys *auth.KeySet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxBroadcastBodyBytes+1))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+			}
+			if len(body) > maxBroadcastBodyBytes {
+				return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body too large")
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			key, err := keys.Verify(c.Request().Header.Get, body)
+			if err != nil {
+				slog.Warn("broadcast http: authentication failed", slog.String("ip", c.RealIP()), slog.String("keyId", c.Request().Header.Get(auth.HeaderKeyID)), slog.Any("error", err))
+				message := "invalid credentials"
+				switch {
+				case errors.Is(err, auth.ErrMissingCredentials):
+					message = "missing credentials"
+				case errors.Is(err, auth.ErrStaleSignature):
+					message = "signature expired"
+				case errors.Is(err, auth.ErrReplayedSignature):
+					message = "signature already used"
+				}
+				return echo.NewHTTPError(http.StatusUnauthorized, message)
+			}
+			c.Set(broadcastKeyContextKey, key)
+			return next(c)
+		}
+	}
+}
+
+// authorizeBroadcast rejects messages outside the scope of the authenticated key. Requests
+// without a key (authentication disabled) are allowed.
+func authorizeBroadcast(c echo.Context, topic, entity string) error {
+	key, ok := c.Get(broadcastKeyContextKey).(*auth.APIKey)
+	if !ok || key == nil {
+		return nil
+	}
+	if key.Allows(topic, entity) {
+		return nil
+	}
+	slog.Warn("broadcast http: key not allowed for topic", slog.String("keyId", key.ID), slog.String("topic", topic), slog.String("entity", entity))
+	return echo.NewHTTPError(http.StatusForbidden, "key not allowed to publish "+topic)
+}
@@ -0,0 +1,85 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/auth"
+)
+
+var errSchedulingDisabled = errors.New("scheduled broadcasts are disabled")
+
+// scheduleBroadcast stores a /v2/broadcast item with deliverAt or cron. The caller already
+// checked the key scope for msg.
+func scheduleBroadcast(c echo.Context, scheduler port.BroadcastScheduler, msg *domain.Message, req BroadcastV2Request) (port.ScheduledBroadcast, error) {
+	if scheduler == nil {
+		return port.ScheduledBroadcast{}, errSchedulingDisabled
+	}
+	job := port.ScheduledBroadcast{
+		Message:   msg,
+		Target:    req.target(),
+		Cron:      strings.TrimSpace(req.Cron),
+		CreatedBy: broadcastKeyID(c),
+	}
+	if req.DeliverAt != nil {
+		job.DeliverAt = *req.DeliverAt
+	}
+	return scheduler.Schedule(job)
+}
+
+// NewScheduledBroadcastsListHandler returns the pending jobs the authenticated key may publish.
+func NewScheduledBroadcastsListHandler(scheduler port.BroadcastScheduler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if scheduler == nil {
+			return echo.NewHTTPError(http.StatusNotFound, errSchedulingDisabled.Error())
+		}
+		jobs := make([]port.ScheduledBroadcast, 0)
+		for _, job := range scheduler.List() {
+			if authorizeBroadcastQuiet(c, job.Message.Topic, job.Message.Entity) {
+				jobs = append(jobs, job)
+			}
+		}
+		return c.JSON(http.StatusOK, map[string]any{"jobs": jobs})
+	}
+}
+
+// NewScheduledBroadcastCancelHandler cancels the job in the :id path parameter.
+func NewScheduledBroadcastCancelHandler(scheduler port.BroadcastScheduler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if scheduler == nil {
+			return echo.NewHTTPError(http.StatusNotFound, errSchedulingDisabled.Error())
+		}
+		id := strings.TrimSpace(c.Param("id"))
+		job, ok := scheduler.Get(id)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, port.ErrScheduleNotFound.Error())
+		}
+		if err := authorizeBroadcast(c, job.Message.Topic, job.Message.Entity); err != nil {
+			return err
+		}
+		if err := scheduler.Cancel(id); err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		slog.Info("broadcast http: scheduled broadcast cancelled", slog.String("id", id), slog.String("keyId", broadcastKeyID(c)))
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+func broadcastKeyID(c echo.Context) string {
+	if key, ok := c.Get(broadcastKeyContextKey).(*auth.APIKey); ok && key != nil {
+		return key.ID
+	}
+	return ""
+}
+
+// authorizeBroadcastQuiet reports whether the authenticated key (if any) covers topic/entity.
+func authorizeBroadcastQuiet(c echo.Context, topic, entity string) bool {
+	key, ok := c.Get(broadcastKeyContextKey).(*auth.APIKey)
+	return !ok || key == nil || key.Allows(topic, entity)
+}
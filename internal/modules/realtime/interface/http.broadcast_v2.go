@@ -0,0 +1,173 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+const maxBroadcastBatch = 100
+
+// BroadcastV2Request is one generic message for /v2/broadcast. Topic defaults to
+// "<entity>.<action>"; Target narrows the recipients (empty target = every subscriber) and,
+// as in /broadcast, the userId, sessionId and sectionId metadata narrow the lists it leaves empty.
+// DeliverAt or Cron schedule the message instead of sending it immediately.
+type BroadcastV2Request struct {
+	Topic      string               `json:"topic,omitempty"`
+	Entity     string               `json:"entity"`
+	Action     string               `json:"action"`
+	ResourceID string               `json:"resourceId,omitempty"`
+	Data       interface{}          `json:"data,omitempty"`
+	Metadata   map[string]string    `json:"metadata,omitempty"`
+	Target     port.BroadcastTarget `json:"target,omitempty"`
+	DeliverAt  *time.Time           `json:"deliverAt,omitempty"`
+	Cron       string               `json:"cron,omitempty"`
+}
+
+// BroadcastV2Result reports the outcome of one message of the batch.
+type BroadcastV2Result struct {
+	Index     int    `json:"index"`
+	Topic     string `json:"topic,omitempty"`
+	Delivered int    `json:"delivered"`
+	Error     string `json:"error,omitempty"`
+	// Set when the message was scheduled instead of sent.
+	JobID   string     `json:"jobId,omitempty"`
+	NextRun *time.Time `json:"nextRun,omitempty"`
+}
+
+// BroadcastV2Response aggregates the per-message results.
+type BroadcastV2Response struct {
+	Results   []BroadcastV2Result `json:"results"`
+	Delivered int                 `json:"delivered"`
+}
+
+// NewBroadcastV2HTTPHandler accepts one BroadcastV2Request or an array of them and replies with
+// how many clients received each message. Invalid or out-of-scope items are reported in their
+// result without aborting the rest of the batch. Scheduled items are handed to scheduler; a nil
+// scheduler rejects them.
+func NewBroadcastV2HTTPHandler(broadcastUC *usecase.BroadcastUseCase, scheduler port.BroadcastScheduler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requests, err := decodeBroadcastV2(c.Response(), c.Request().Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body too large")
+		}
+		if err != nil {
+			slog.Warn("broadcast v2 http: invalid request body", slog.Any("error", err))
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+		}
+		if len(requests) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "at least one message is required")
+		}
+		if len(requests) > maxBroadcastBatch {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "too many messages in batch")
+		}
+
+		ctx := c.Request().Context()
+		response := BroadcastV2Response{Results: make([]BroadcastV2Result, 0, len(requests))}
+		for i, req := range requests {
+			result := BroadcastV2Result{Index: i}
+			msg, err := req.message()
+			if err != nil {
+				result.Error = err.Error()
+				response.Results = append(response.Results, result)
+				continue
+			}
+			result.Topic = msg.Topic
+			if err := authorizeBroadcast(c, msg.Topic, msg.Entity); err != nil {
+				result.Error = "key not allowed to publish " + msg.Topic
+				response.Results = append(response.Results, result)
+				continue
+			}
+			if req.scheduled() {
+				job, err := scheduleBroadcast(c, scheduler, msg, req)
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.JobID = job.ID
+					result.NextRun = &job.NextRun
+				}
+				response.Results = append(response.Results, result)
+				continue
+			}
+			result.Delivered = broadcastUC.ExecuteTargeted(ctx, msg, req.target())
+			if result.Delivered > 0 {
+				response.Delivered += result.Delivered
+			}
+			response.Results = append(response.Results, result)
+		}
+
+		slog.Info("broadcast v2 http: batch processed", slog.Int("messages", len(requests)), slog.Int("delivered", response.Delivered))
+		return c.JSON(http.StatusOK, response)
+	}
+}
+
+// decodeBroadcastV2 accepts either a single JSON object or an array of objects. Bodies larger
+// than maxBroadcastBodyBytes fail with *http.MaxBytesError.
+func decodeBroadcastV2(w http.ResponseWriter, body io.ReadCloser) ([]BroadcastV2Request, error) {
+	raw, err := io.ReadAll(http.MaxBytesReader(w, body, maxBroadcastBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var requests []BroadcastV2Request
+		if err := json.Unmarshal(raw, &requests); err != nil {
+			return nil, err
+		}
+		return requests, nil
+	}
+	var single BroadcastV2Request
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, err
+	}
+	return []BroadcastV2Request{single}, nil
+}
+
+func (r BroadcastV2Request) scheduled() bool {
+	return r.DeliverAt != nil || strings.TrimSpace(r.Cron) != ""
+}
+
+// target returns the explicit Target, completed with the userId, sessionId and sectionId
+// metadata honoured by /broadcast for the lists Target leaves empty.
+func (r BroadcastV2Request) target() port.BroadcastTarget {
+	target := r.Target
+	for key, list := range map[string]*[]string{"userId": &target.UserIDs, "sessionId": &target.SessionIDs, "sectionId": &target.SectionIDs} {
+		if value := strings.TrimSpace(r.Metadata[key]); value != "" && len(*list) == 0 {
+			*list = []string{value}
+		}
+	}
+	return target
+}
+
+func (r BroadcastV2Request) message() (*domain.Message, error) {
+	entity := strings.TrimSpace(r.Entity)
+	action := strings.TrimSpace(r.Action)
+	if entity == "" || action == "" {
+		return nil, errors.New("entity and action are required")
+	}
+	topic := strings.TrimSpace(r.Topic)
+	if topic == "" {
+		topic = domain.CustomTopic(entity, action)
+	}
+	return &domain.Message{
+		Topic:      topic,
+		Entity:     entity,
+		Action:     action,
+		ResourceID: strings.TrimSpace(r.ResourceID),
+		Metadata:   r.Metadata,
+		Data:       r.Data,
+		Timestamp:  time.Now().UTC(),
+	}, nil
+}
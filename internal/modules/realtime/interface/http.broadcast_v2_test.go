@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+)
+
+func serveBroadcastV2(t *testing.T, hub *infrastructure.Hub, body string) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/v2/broadcast", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	handler := NewBroadcastV2HTTPHandler(usecase.NewBroadcastUseCase(hub), nil)
+	return rec, handler(e.NewContext(req, rec))
+}
+
+func TestBroadcastV2_RejectsOversizedBodies(t *testing.T) {
+	body := `{"entity":"reservations","action":"reminder","data":"` + strings.Repeat("x", maxBroadcastBodyBytes) + `"}`
+	_, err := serveBroadcastV2(t, infrastructure.NewHub(), body)
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %v", err)
+	}
+}
+
+func TestBroadcastV2_MetadataNarrowsTarget(t *testing.T) {
+	hub := infrastructure.NewHub()
+	for _, user := range []string{"u-1", "u-2"} {
+		hub.AttachClient(infrastructure.NewClient(hub, nil, user, "s-"+user, "", "reservations", "", 4, nil), []string{"reservations.reminder"})
+	}
+
+	rec, err := serveBroadcastV2(t, hub, `[
+		{"entity":"reservations","action":"reminder","metadata":{"userId":"u-1"}},
+		{"entity":"reservations","action":"reminder","metadata":{"userId":"u-1"},"target":{"userIds":["u-1","u-2"]}},
+		{"entity":"reservations","action":"reminder"}
+	]`)
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	var response BroadcastV2Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	got := []int{response.Results[0].Delivered, response.Results[1].Delivered, response.Results[2].Delivered}
+	if got[0] != 1 || got[1] != 2 || got[2] != 2 {
+		t.Fatalf("unexpected deliveries %v (metadata narrows, explicit target wins)", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	domain "mesaYaWs/internal/modules/realtime/domain"
 	"mesaYaWs/internal/modules/realtime/infrastructure"
 	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
 )
 
 var upgrader = websocket.Upgrader{
@@ -26,62 +28,19 @@ var upgrader = websocket.Upgrader{
 const (
 	roleAdmin = "ADMIN"
 	roleOwner = "OWNER"
-	roleUser  = "USER"
 )
 
-type entityPolicy struct {
-	allowedRoles map[string]struct{}
-}
-
-func newEntityPolicy(roles ...string) entityPolicy {
-	allowed := make(map[string]struct{}, len(roles))
-	for _, role := range roles {
-		trimmed := strings.ToUpper(strings.TrimSpace(role))
-		if trimmed == "" {
-			continue
-		}
-		allowed[trimmed] = struct{}{}
-	}
-	return entityPolicy{allowedRoles: allowed}
-}
-
-func (p entityPolicy) allows(role string) bool {
-	if len(p.allowedRoles) == 0 {
-		return true
-	}
-	normalized := strings.ToUpper(strings.TrimSpace(role))
-	_, ok := p.allowedRoles[normalized]
-	return ok
-}
-
-var entityPolicies = map[string]entityPolicy{
-	"restaurants":        newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"tables":             newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"reservations":       newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"reviews":            newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"sections":           newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"objects":            newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"menus":              newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"dishes":             newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"images":             newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"section-objects":    newEntityPolicy(roleAdmin, roleOwner),
-	"payments":           newEntityPolicy(roleAdmin, roleOwner),
-	"subscriptions":      newEntityPolicy(roleAdmin, roleOwner),
-	"subscription-plans": newEntityPolicy(roleAdmin, roleOwner, roleUser),
-	"users":              newEntityPolicy(roleAdmin),
-	"owner-upgrades":     newEntityPolicy(roleAdmin),
-}
-
-func isEntityAccessAllowed(entity string, claims *auth.Claims) bool {
+// isEntityAccessAllowed applies the roles of the entity registry; unknown entities are open.
+func isEntityAccessAllowed(registry *entities.Registry, entity string, claims *auth.Claims) bool {
 	if claims == nil {
 		return false
 	}
-	policy, present := entityPolicies[entity]
+	definition, present := registry.Lookup(entity)
 	if !present {
 		return true
 	}
 	for _, role := range claims.Roles {
-		if policy.allows(role) {
+		if definition.AllowsRole(role) {
 			return true
 		}
 	}
@@ -109,10 +68,12 @@ func snapshotAudienceFromClaims(claims *auth.Claims) port.SnapshotAudience {
 func NewWebsocketHandler(
 	hub *infrastructure.Hub,
 	connectUC *usecase.ConnectSectionUseCase,
+	emitUC *usecase.EmitEventUseCase,
+	registry *entities.Registry,
 	defaultEntity string,
 	allowedActions []string,
 ) func(echo.Context) error {
-	defaultEntity = normalizeEntity(defaultEntity)
+	defaultEntity = normalizeEntity(registry, defaultEntity)
 	if defaultEntity == "" {
 		defaultEntity = "restaurants"
 	}
@@ -122,7 +83,7 @@ func NewWebsocketHandler(
 
 	return func(c echo.Context) error {
 		entityParam := c.Param("entity")
-		entity := normalizeEntity(entityParam)
+		entity := normalizeEntity(registry, entityParam)
 		if entity == "" {
 			entity = defaultEntity
 		}
@@ -152,7 +113,7 @@ func NewWebsocketHandler(
 			return echo.NewHTTPError(http.StatusBadRequest, "missing entity")
 		}
 
-		factory, supported := entityHandlers[entity]
+		factory, supported := entityHandler(registry, entity)
 		if !supported {
 			slog.Warn("ws handler entity not integrated", slog.String("entity", entity), slog.String("sectionId", section))
 			logger.Warnf("ws rejected: entity not integrated entity=%s section=%s ip=%s reqID=%s", entity, section, peerIP, requestID)
@@ -204,7 +165,7 @@ func NewWebsocketHandler(
 			return echo.NewHTTPError(status, message)
 		}
 
-		if !isEntityAccessAllowed(entity, output.Claims) {
+		if !isEntityAccessAllowed(registry, entity, output.Claims) {
 			roles := []string{}
 			if output.Claims != nil {
 				roles = append(roles, output.Claims.Roles...)
@@ -227,9 +188,11 @@ func NewWebsocketHandler(
 		roles := claims.Roles
 		slog.Info("ws handler upgrade success", slog.String("entity", entity), slog.String("sectionId", section), slog.String("userId", userID), slog.String("sessionId", sessionID), slog.Any("roles", roles))
 
-		commandHandler := factory(entity, section, token, output.Claims, connectUC)
+		commandHandler := withEmitCommands(factory(entity, section, token, output.Claims, connectUC), emitUC, entity, section, output.Claims)
 
 		client := infrastructure.NewClient(hub, conn, userID, sessionID, section, entity, token, 8, commandHandler)
+		// The section of entity streams is the restaurant the client is scoped to.
+		client.SetAudience(roles, section)
 
 		topics := buildTopics(entity, allowedActions)
 		hub.AttachClient(client, topics)
@@ -318,41 +281,48 @@ func sendCommandError(client *infrastructure.Client, entity, section, action, re
 	client.SendDomainMessage(message)
 }
 
+// withEmitCommands publishes the client-originated actions configured in emitUC (table
+// selection, presence, acknowledgements) to Kafka and hands every other command to next.
+func withEmitCommands(next func(context.Context, *infrastructure.Client, infrastructure.Command), emitUC *usecase.EmitEventUseCase, entity, section string, claims *auth.Claims) func(context.Context, *infrastructure.Client, infrastructure.Command) {
+	if emitUC == nil {
+		return next
+	}
+	return func(cmdCtx context.Context, client *infrastructure.Client, cmd infrastructure.Command) {
+		if !emitUC.Accepts(cmd.Action) {
+			next(cmdCtx, client, cmd)
+			return
+		}
+		_, err := emitUC.Execute(cmdCtx, usecase.EmitEventInput{
+			Entity:    entity,
+			Action:    cmd.Action,
+			SectionID: section,
+			Claims:    claims,
+			Payload:   cmd.Payload,
+		})
+		switch {
+		case err == nil:
+		case errors.Is(err, usecase.ErrEmitInvalidPayload):
+			sendCommandError(client, entity, section, cmd.Action, "invalid payload")
+		default:
+			sendCommandError(client, entity, section, cmd.Action, "event not delivered")
+		}
+	}
+}
+
 type commandHandlerFactory func(entity, section, token string, claims *auth.Claims, connectUC *usecase.ConnectSectionUseCase) func(context.Context, *infrastructure.Client, infrastructure.Command)
 
-var entityHandlers = func() map[string]commandHandlerFactory {
-	handlers := make(map[string]commandHandlerFactory)
-	configs := []struct {
-		entity   string
-		plural   string
-		singular string
-	}{
-		{entity: "restaurants", plural: "restaurants", singular: "restaurant"},
-		{entity: "tables", plural: "tables", singular: "table"},
-		{entity: "reservations", plural: "reservations", singular: "reservation"},
-		{entity: "reviews", plural: "reviews", singular: "review"},
-		{entity: "sections", plural: "sections", singular: "section"},
-		{entity: "objects", plural: "objects", singular: "object"},
-		{entity: "menus", plural: "menus", singular: "menu"},
-		{entity: "dishes", plural: "dishes", singular: "dish"},
-		{entity: "images", plural: "images", singular: "image"},
-		{entity: "section-objects", plural: "section_objects", singular: "section_object"},
-		{entity: "payments", plural: "payments", singular: "payment"},
-		{entity: "subscriptions", plural: "subscriptions", singular: "subscription"},
-		{entity: "subscription-plans", plural: "subscription_plans", singular: "subscription_plan"},
-		{entity: "auth-users", plural: "auth_users", singular: "auth_user"},
-		{entity: "owners", plural: "owners", singular: "owner"},
-		{entity: "owner-upgrade", plural: "owner_upgrade_requests", singular: "owner_upgrade_request"},
+// entityHandler returns the command handler factory of an entity of the registry.
+func entityHandler(registry *entities.Registry, entity string) (commandHandlerFactory, bool) {
+	definition, ok := registry.Lookup(entity)
+	if !ok {
+		return nil, false
 	}
-	for _, cfg := range configs {
-		handlers[cfg.entity] = newGenericCommandHandler(cfg.entity, cfg.plural, cfg.singular)
-	}
-	return handlers
-}()
+	return newGenericCommandHandler(definition.Name, definition.Commands), true
+}
 
-func newGenericCommandHandler(canonicalEntity, pluralAction, singularAction string) commandHandlerFactory {
-	normalizedPlural := strings.ToLower(strings.TrimSpace(pluralAction))
-	normalizedSingular := strings.ToLower(strings.TrimSpace(singularAction))
+func newGenericCommandHandler(canonicalEntity string, commands entities.Commands) commandHandlerFactory {
+	listActions := commandActions(commands.List, "list", "fetch_all")
+	detailActions := commandActions(commands.Detail, "detail", "fetch_one")
 	return func(entity, section, token string, claims *auth.Claims, connectUC *usecase.ConnectSectionUseCase) func(context.Context, *infrastructure.Client, infrastructure.Command) {
 		snapshotCtx := port.SnapshotContext{
 			SectionID: strings.TrimSpace(section),
@@ -360,10 +330,10 @@ func newGenericCommandHandler(canonicalEntity, pluralAction, singularAction stri
 		}
 		return func(cmdCtx context.Context, client *infrastructure.Client, cmd infrastructure.Command) {
 			action := strings.ToLower(strings.TrimSpace(cmd.Action))
-			switch action {
-			case "list_" + normalizedPlural, "list", "fetch_all":
+			switch {
+			case slices.Contains(listActions, action):
 				executeListCommand[domain.ListEntityCommand](cmdCtx, entity, section, token, snapshotCtx, cmd, client, connectUC.HandleListEntityCommand)
-			case "get_" + normalizedSingular, "detail", "fetch_one":
+			case slices.Contains(detailActions, action):
 				executeDetailCommand[domain.GetEntityCommand](cmdCtx, entity, section, token, snapshotCtx, cmd, client, connectUC.HandleGetEntityCommand, func(command domain.GetEntityCommand) string {
 					return command.ID
 				})
@@ -375,50 +345,18 @@ func newGenericCommandHandler(canonicalEntity, pluralAction, singularAction stri
 	}
 }
 
-func normalizeEntity(raw string) string {
-	trimmed := strings.ToLower(strings.TrimSpace(raw))
-	switch trimmed {
-	case "", "-", "default":
-		return ""
-	case "restaurant", "restaurants":
-		return "restaurants"
-	case "table", "tables":
-		return "tables"
-	case "reservation", "reservations":
-		return "reservations"
-	case "section", "sections":
-		return "sections"
-	case "review", "reviews":
-		return "reviews"
-	case "sectionobject", "sectionobjects":
-		return "section-objects"
-	case "object", "objects":
-		return "objects"
-	case "menu", "menus":
-		return "menus"
-	case "dish", "dishes":
-		return "dishes"
-	case "image", "images":
-		return "images"
-	case "section-object", "section-objects", "section_object", "section_objects":
-		return "section-objects"
-	case "payment", "payments":
-		return "payments"
-	case "subscription", "subscriptions":
-		return "subscriptions"
-	case "subscription-plan", "subscription-plans", "subscription_plan", "subscription_plans":
-		return "subscription-plans"
-	case "subscriptionplan", "subscriptionplans":
-		return "subscription-plans"
-	case "user", "users", "auth-user", "auth-users", "auth_user", "auth_users", "authuser", "authusers", "auth":
-		return "users"
-	case "owner", "owners":
-		return "users"
-	case "owner-upgrade", "owner-upgrades", "owner_upgrade", "owner_upgrades", "ownerupgrade", "ownerupgrades":
-		return "owner-upgrades"
-	default:
-		return trimmed
+func commandActions(names []string, generic ...string) []string {
+	actions := slices.Clone(generic)
+	for _, name := range names {
+		if normalized := strings.ToLower(strings.TrimSpace(name)); normalized != "" {
+			actions = append(actions, normalized)
+		}
 	}
+	return actions
+}
+
+func normalizeEntity(registry *entities.Registry, raw string) string {
+	return registry.Normalize(raw)
 }
 
 func executeListCommand[T any](
@@ -1,6 +1,10 @@
 package transport
 
-import "testing"
+import (
+	"testing"
+
+	"mesaYaWs/internal/shared/entities"
+)
 
 func TestNormalizeEntity(t *testing.T) {
 	cases := map[string]string{
@@ -17,7 +21,7 @@ func TestNormalizeEntity(t *testing.T) {
 	}
 
 	for input, expected := range cases {
-		actual := normalizeEntity(input)
+		actual := normalizeEntity(entities.Default(), input)
 		if actual != expected {
 			t.Fatalf("normalizeEntity(%q) expected %q got %q", input, expected, actual)
 		}
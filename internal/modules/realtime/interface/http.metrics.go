@@ -0,0 +1,19 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/shared/metrics"
+)
+
+// NewMetricsHTTPHandler exposes the in-process counters (Kafka validation, deliveries, ...) as JSON.
+func NewMetricsHTTPHandler(registry *metrics.Registry) echo.HandlerFunc {
+	if registry == nil {
+		registry = metrics.Default
+	}
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, registry.Snapshot())
+	}
+}
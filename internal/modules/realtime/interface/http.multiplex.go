@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	"mesaYaWs/internal/shared/auth"
+	"mesaYaWs/internal/shared/entities"
+)
+
+// maxMultiplexChannels bounds the (entity, section) channels a single /ws connection may join.
+const maxMultiplexChannels = 32
+
+// channelCommand is the payload of join/leave on /ws.
+type channelCommand struct {
+	Entity  string `json:"entity"`
+	Section string `json:"section"`
+}
+
+// multiplexChannels keeps the command handler of every channel joined by one connection.
+type multiplexChannels struct {
+	mu       sync.Mutex
+	handlers map[string]func(context.Context, *infrastructure.Client, infrastructure.Command)
+}
+
+// NewMultiplexWebsocketHandler exposes /ws: one connection that joins (entity, section) channels
+// on the fly. Each channel is authorized like /ws/:entity/:section, keeps its own SnapshotContext
+// and tags its replies and broadcasts with metadata.channel ("entity:section").
+func NewMultiplexWebsocketHandler(
+	hub *infrastructure.Hub,
+	connectUC *usecase.ConnectSectionUseCase,
+	emitUC *usecase.EmitEventUseCase,
+	registry *entities.Registry,
+	allowedActions []string,
+) func(echo.Context) error {
+	if len(allowedActions) == 0 {
+		allowedActions = []string{"created", "updated", "deleted", "snapshot"}
+	}
+
+	return func(c echo.Context) error {
+		token := auth.ExtractToken(c.Request(), "token")
+		claims, err := connectUC.Authenticate(token)
+		if err != nil {
+			switch {
+			case errors.Is(err, usecase.ErrMissingToken), errors.Is(err, auth.ErrMissingToken):
+				return echo.NewHTTPError(http.StatusBadRequest, "missing token")
+			default:
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+		}
+
+		conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			slog.Error("multiplex ws upgrade failed", slog.Any("error", err))
+			return err
+		}
+
+		userID := claims.RegisteredClaims.Subject
+		sessionID := claims.SessionID
+		roles := claims.Roles
+
+		channels := &multiplexChannels{handlers: make(map[string]func(context.Context, *infrastructure.Client, infrastructure.Command))}
+		commandHandler := newMultiplexCommandHandler(connectUC, emitUC, registry, channels, token, claims, allowedActions)
+		client := infrastructure.NewClient(hub, conn, userID, sessionID, "", "", token, 16, commandHandler)
+		client.SetAudience(roles, "")
+		hub.AttachClient(client, nil)
+
+		go client.WritePump()
+		go client.ReadPump()
+
+		client.SendDomainMessage(&domain.Message{
+			Topic:  domain.TopicSystemConnected,
+			Entity: domain.SystemEntity,
+			Action: domain.ActionConnected,
+			Metadata: map[string]string{
+				"userId":    userID,
+				"sessionId": sessionID,
+			},
+			Data:      map[string]any{"mode": "multiplex", "roles": roles, "maxChannels": maxMultiplexChannels},
+			Timestamp: time.Now().UTC(),
+		})
+		slog.Info("multiplex ws connected", slog.String("userId", userID), slog.String("sessionId", sessionID), slog.Any("roles", roles))
+		return nil
+	}
+}
+
+func newMultiplexCommandHandler(
+	connectUC *usecase.ConnectSectionUseCase,
+	emitUC *usecase.EmitEventUseCase,
+	registry *entities.Registry,
+	channels *multiplexChannels,
+	token string,
+	claims *auth.Claims,
+	allowedActions []string,
+) func(context.Context, *infrastructure.Client, infrastructure.Command) {
+	return func(cmdCtx context.Context, client *infrastructure.Client, cmd infrastructure.Command) {
+		action := strings.ToLower(strings.TrimSpace(cmd.Action))
+		switch action {
+		case "join", "leave":
+			payload, err := decodeCommand[channelCommand](cmd.Payload)
+			entity := normalizeEntity(registry, payload.Entity)
+			section := strings.TrimSpace(payload.Section)
+			if err != nil || entity == "" || section == "" {
+				sendCommandError(client, domain.SystemEntity, section, action, "invalid payload")
+				return
+			}
+			if action == "join" {
+				joinChannel(client, connectUC, emitUC, registry, channels, entity, section, token, claims, allowedActions)
+				return
+			}
+			leaveChannel(client, channels, entity, section, allowedActions)
+		default:
+			channel := strings.TrimSpace(cmd.Channel)
+			channels.mu.Lock()
+			handler, ok := channels.handlers[channel]
+			channels.mu.Unlock()
+			if !ok {
+				sendCommandError(client, domain.SystemEntity, "", action, "channel not joined")
+				return
+			}
+			handler(cmdCtx, client, cmd)
+		}
+	}
+}
+
+func joinChannel(
+	client *infrastructure.Client,
+	connectUC *usecase.ConnectSectionUseCase,
+	emitUC *usecase.EmitEventUseCase,
+	registry *entities.Registry,
+	channels *multiplexChannels,
+	entity, section, token string,
+	claims *auth.Claims,
+	allowedActions []string,
+) {
+	factory, supported := entityHandler(registry, entity)
+	if !supported {
+		sendCommandError(client, entity, section, "join", "entity "+entity+" is not integrated")
+		return
+	}
+	if !isEntityAccessAllowed(registry, entity, claims) {
+		slog.Warn("multiplex ws forbidden entity access", slog.String("entity", entity), slog.String("sectionId", section), slog.Any("roles", claims.Roles))
+		sendCommandError(client, entity, section, "join", "forbidden")
+		return
+	}
+
+	channel := infrastructure.ChannelKey(entity, section)
+	channels.mu.Lock()
+	_, joined := channels.handlers[channel]
+	if !joined && len(channels.handlers) >= maxMultiplexChannels {
+		channels.mu.Unlock()
+		sendCommandError(client, entity, section, "join", fmt.Sprintf("at most %d channels per connection", maxMultiplexChannels))
+		return
+	}
+	channels.handlers[channel] = withEmitCommands(factory(entity, section, token, claims, connectUC), emitUC, entity, section, claims)
+	channels.mu.Unlock()
+
+	topics := buildTopics(entity, allowedActions)
+	if client.JoinChannel(entity, section) {
+		client.Subscribe(topics...)
+	}
+	client.SendDomainMessage(&domain.Message{
+		Topic:      domain.CustomTopic(entity, "joined"),
+		Entity:     entity,
+		Action:     "joined",
+		ResourceID: section,
+		Metadata:   map[string]string{"channel": channel, "sectionId": section},
+		Data:       map[string]any{"entity": entity, "sectionId": section, "allowedTopics": topics},
+		Timestamp:  time.Now().UTC(),
+	})
+	slog.Info("multiplex ws channel joined", slog.String("channel", channel), slog.Any("roles", claims.Roles))
+}
+
+func leaveChannel(client *infrastructure.Client, channels *multiplexChannels, entity, section string, allowedActions []string) {
+	channel := infrastructure.ChannelKey(entity, section)
+	channels.mu.Lock()
+	delete(channels.handlers, channel)
+	channels.mu.Unlock()
+
+	if client.LeaveChannel(entity, section) {
+		client.Unsubscribe(buildTopics(entity, allowedActions)...)
+	}
+	client.SendDomainMessage(&domain.Message{
+		Topic:      domain.CustomTopic(entity, "left"),
+		Entity:     entity,
+		Action:     "left",
+		ResourceID: section,
+		Metadata:   map[string]string{"channel": channel, "sectionId": section},
+		Timestamp:  time.Now().UTC(),
+	})
+}
@@ -1,14 +1,22 @@
 package transport
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
 	"mesaYaWs/internal/modules/realtime/infrastructure"
 	"mesaYaWs/internal/shared/auth"
 )
@@ -93,10 +101,50 @@ func roleBasedTopicFilter(topics []string, roles []string) []string {
 	return filtered
 }
 
+// notificationsInboxLimit es el máximo de notificaciones no leídas enviadas al conectar.
+const notificationsInboxLimit = 50
+
+// notificationsCommand es el payload de los comandos de bandeja y preferencias.
+type notificationsCommand struct {
+	IDs        []string `json:"ids,omitempty"`
+	Limit      int      `json:"limit,omitempty"`
+	UnreadOnly bool     `json:"unreadOnly,omitempty"`
+	Topics     []string `json:"topics,omitempty"`
+	Entities   []string `json:"entities,omitempty"`
+	Start      string   `json:"start,omitempty"`
+	End        string   `json:"end,omitempty"`
+	Timezone   string   `json:"timezone,omitempty"`
+}
+
+// notificationsSession guarda el estado de una conexión de notificaciones: los topics
+// permitidos por rol, los suscritos tras aplicar las preferencias y las reglas vigentes.
+type notificationsSession struct {
+	inbox       *usecase.NotificationInbox
+	preferences *usecase.NotificationPreferencesUseCase
+	userID      string
+	roleTopics  []string
+
+	mu     sync.Mutex
+	topics []string
+	rules  atomic.Pointer[usecase.NotificationRules]
+}
+
 // NewNotificationsWebsocketHandler exposes /ws/notifications requiring authentication
 // and streams broadcasted messages to the connected client.
-// Solo envía notificaciones relevantes según el rol del usuario.
-func NewNotificationsWebsocketHandler(hub *infrastructure.Hub, validator auth.TokenValidator) func(echo.Context) error {
+// Solo envía notificaciones relevantes según el rol del usuario y, con audience, solo las
+// de sus propias reservas/reviews o de los restaurantes que posee. Con inbox, al conectar
+// envía las notificaciones no leídas y acepta mark_read, mark_all_read y list_notifications;
+// con preferences acepta mute, unmute, set_quiet_hours y get_preferences. Con templates, cada
+// notificación incluye title/body en el idioma del usuario (?locale=, claim locale o
+// Accept-Language).
+func NewNotificationsWebsocketHandler(
+	hub *infrastructure.Hub,
+	validator auth.TokenValidator,
+	audience *usecase.NotificationAudience,
+	inbox *usecase.NotificationInbox,
+	preferences *usecase.NotificationPreferencesUseCase,
+	templates *usecase.NotificationTemplates,
+) func(echo.Context) error {
 	return func(c echo.Context) error {
 		requestID := c.Response().Header().Get(echo.HeaderXRequestID)
 		peerIP := c.RealIP()
@@ -118,10 +166,54 @@ func NewNotificationsWebsocketHandler(hub *infrastructure.Hub, validator auth.To
 		sessionID := fmt.Sprintf("notif-%d", notificationCounter.Add(1))
 		roles := claims.Roles
 
-		// Filtrar topics según el rol del usuario
-		filteredTopics := roleBasedTopicFilter(allowedNotificationTopics, roles)
+		// Filtrar topics según el rol del usuario y luego según sus preferencias
+		session := &notificationsSession{
+			inbox:       inbox,
+			preferences: preferences,
+			userID:      userID,
+			roleTopics:  roleBasedTopicFilter(allowedNotificationTopics, roles),
+		}
+		filteredTopics := session.roleTopics
+		if preferences != nil {
+			prefs, err := preferences.Get(c.Request().Context(), userID)
+			if err != nil {
+				slog.Warn("notifications preferences unavailable", slog.String("userId", userID), slog.Any("error", err))
+			}
+			rules := preferences.Rules(prefs)
+			session.rules.Store(rules)
+			filteredTopics = rules.Topics(session.roleTopics)
+		}
+		session.topics = filteredTopics
 
-		client := infrastructure.NewClient(hub, conn, userID, sessionID, "", "notifications", token, 8, nil)
+		var commandHandler func(context.Context, *infrastructure.Client, infrastructure.Command)
+		if inbox != nil || preferences != nil {
+			commandHandler = session.handleCommand
+		}
+		if inbox != nil {
+			filteredTopics = append(slices.Clone(filteredTopics), domain.CustomTopic(usecase.NotificationsEntity, usecase.NotificationActionUnreadCount))
+		}
+		client := infrastructure.NewClient(hub, conn, userID, sessionID, "", "notifications", token, 8, commandHandler)
+		client.SetAudience(roles, "")
+		var recipient *usecase.NotificationRecipient
+		if audience != nil {
+			// Filtrar por destinatario: dueño del evento o propietario del restaurante
+			ctx, cancel := context.WithTimeout(c.Request().Context(), 10*time.Second)
+			recipient = audience.Recipient(ctx, token, claims)
+			cancel()
+		}
+		client.SetFilter(func(msg *domain.Message) bool {
+			if recipient != nil && !recipient.Allows(msg) {
+				return false
+			}
+			rules := session.rules.Load()
+			return rules == nil || rules.Allows(msg)
+		})
+		if templates != nil {
+			locale := templates.Locale(notificationLocale(c, claims))
+			client.SetRenderer(func(msg *domain.Message) *domain.Message {
+				return templates.Render(msg, locale)
+			})
+		}
 		// Suscribir solo a topics filtrados por rol en lugar de todos
 		hub.AttachClient(client, filteredTopics)
 
@@ -130,6 +222,15 @@ func NewNotificationsWebsocketHandler(hub *infrastructure.Hub, validator auth.To
 
 		// No enviar mensaje de sistema "connected" - es ruido innecesario para el cliente
 		// El cliente sabe que está conectado por el éxito del handshake WebSocket
+		if inbox != nil {
+			// Entregar las notificaciones recibidas mientras estaba desconectado
+			unread, err := inbox.Unread(c.Request().Context(), userID, notificationsInboxLimit)
+			if err != nil {
+				slog.Warn("notifications inbox unavailable", slog.String("userId", userID), slog.Any("error", err))
+			} else {
+				client.SendDomainMessage(unread)
+			}
+		}
 
 		slog.Info("notifications ws connected",
 			slog.String("userId", userID),
@@ -141,3 +242,117 @@ func NewNotificationsWebsocketHandler(hub *infrastructure.Hub, validator auth.To
 		return nil
 	}
 }
+
+// notificationLocale elige el idioma pedido: parámetro locale, claim locale o el primer
+// idioma de Accept-Language.
+func notificationLocale(c echo.Context, claims *auth.Claims) string {
+	if locale := strings.TrimSpace(c.QueryParam("locale")); locale != "" {
+		return locale
+	}
+	if claims != nil && strings.TrimSpace(claims.Locale) != "" {
+		return claims.Locale
+	}
+	return c.Request().Header.Get("Accept-Language")
+}
+
+func (s *notificationsSession) handleCommand(cmdCtx context.Context, client *infrastructure.Client, cmd infrastructure.Command) {
+	action := strings.ToLower(strings.TrimSpace(cmd.Action))
+	payload, err := decodeCommand[notificationsCommand](cmd.Payload)
+	if err != nil {
+		sendCommandError(client, usecase.NotificationsEntity, "", action, "invalid payload")
+		return
+	}
+
+	switch action {
+	case "mark_read", "mark_all_read", "list_notifications":
+		if s.inbox == nil {
+			sendCommandError(client, usecase.NotificationsEntity, "", action, "unsupported action")
+			return
+		}
+		s.handleInboxCommand(cmdCtx, client, action, payload)
+	case "mute", "unmute", "set_quiet_hours", "get_preferences":
+		if s.preferences == nil {
+			sendCommandError(client, usecase.NotificationsEntity, "", action, "unsupported action")
+			return
+		}
+		s.handlePreferencesCommand(cmdCtx, client, action, payload)
+	default:
+		sendCommandError(client, usecase.NotificationsEntity, "", action, "unsupported action")
+	}
+}
+
+func (s *notificationsSession) handleInboxCommand(ctx context.Context, client *infrastructure.Client, action string, payload notificationsCommand) {
+	var (
+		message *domain.Message
+		err     error
+	)
+	switch action {
+	case "mark_read":
+		if len(payload.IDs) == 0 {
+			sendCommandError(client, usecase.NotificationsEntity, "", action, "missing ids")
+			return
+		}
+		message, err = s.inbox.MarkRead(ctx, s.userID, payload.IDs)
+	case "mark_all_read":
+		message, err = s.inbox.MarkRead(ctx, s.userID, nil)
+	case "list_notifications":
+		message, err = s.inbox.List(ctx, s.userID, payload.Limit, payload.UnreadOnly, usecase.NotificationActionList)
+	}
+	if err != nil {
+		slog.Warn("notifications inbox command failed", slog.String("userId", s.userID), slog.String("action", action), slog.Any("error", err))
+		sendCommandError(client, usecase.NotificationsEntity, "", action, "inbox unavailable")
+		return
+	}
+	client.SendDomainMessage(message)
+}
+
+func (s *notificationsSession) handlePreferencesCommand(ctx context.Context, client *infrastructure.Client, action string, payload notificationsCommand) {
+	var (
+		prefs port.NotificationPreferences
+		err   error
+	)
+	switch action {
+	case "mute":
+		prefs, err = s.preferences.Mute(ctx, s.userID, payload.Topics, payload.Entities)
+	case "unmute":
+		prefs, err = s.preferences.Unmute(ctx, s.userID, payload.Topics, payload.Entities)
+	case "set_quiet_hours":
+		var hours *port.QuietHours
+		if payload.Start != "" || payload.End != "" {
+			hours = &port.QuietHours{Start: payload.Start, End: payload.End, Timezone: payload.Timezone}
+		}
+		prefs, err = s.preferences.SetQuietHours(ctx, s.userID, hours)
+	case "get_preferences":
+		prefs, err = s.preferences.Get(ctx, s.userID)
+	}
+	switch {
+	case errors.Is(err, usecase.ErrInvalidNotificationPreferences):
+		sendCommandError(client, usecase.NotificationsEntity, "", action, err.Error())
+		return
+	case err != nil:
+		slog.Warn("notifications preferences command failed", slog.String("userId", s.userID), slog.String("action", action), slog.Any("error", err))
+		sendCommandError(client, usecase.NotificationsEntity, "", action, "preferences unavailable")
+		return
+	}
+	s.apply(client, s.preferences.Rules(prefs))
+	client.SendDomainMessage(s.preferences.Message(s.userID, prefs))
+}
+
+// apply swaps the delivery rules and re-subscribes the topics unmuted or muted by them.
+func (s *notificationsSession) apply(client *infrastructure.Client, rules *usecase.NotificationRules) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules.Store(rules)
+	next := rules.Topics(s.roleTopics)
+	for _, topic := range s.topics {
+		if !slices.Contains(next, topic) {
+			client.Unsubscribe(topic)
+		}
+	}
+	for _, topic := range next {
+		if !slices.Contains(s.topics, topic) {
+			client.Subscribe(topic)
+		}
+	}
+	s.topics = next
+}
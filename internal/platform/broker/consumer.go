@@ -10,6 +10,8 @@ import (
 
 	"github.com/segmentio/kafka-go"
 
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/application/usecase"
 	"mesaYaWs/internal/modules/realtime/domain"
 )
 
@@ -100,22 +102,33 @@ func (cb *circuitBreaker) reset() {
 }
 
 type KafkaConsumer struct {
-	reader *kafka.Reader
-	topic  string
+	reader           *kafka.Reader
+	topic            string
+	validator        *EventValidator
+	startTime        time.Time
+	catchUpThreshold time.Duration
 }
 
-func NewKafkaConsumer(brokers []string, groupID string, topic string) *KafkaConsumer {
+// NewKafkaConsumer creates a group reader for topic. dialer may be nil to use kafka-go defaults
+// (plaintext, no authentication). startOffset (kafka.FirstOffset or kafka.LastOffset) only
+// applies while the group has no committed offset for the topic.
+func NewKafkaConsumer(brokers []string, groupID string, topic string, dialer *kafka.Dialer, startOffset int64) *KafkaConsumer {
+	if startOffset != kafka.LastOffset {
+		startOffset = kafka.FirstOffset
+	}
 	return &KafkaConsumer{
 		reader: kafka.NewReader(kafka.ReaderConfig{
-			Brokers: brokers,
-			GroupID: groupID,
-			Topic:   topic,
+			Brokers:     brokers,
+			GroupID:     groupID,
+			Topic:       topic,
+			Dialer:      dialer,
+			StartOffset: startOffset,
 		}),
 		topic: topic,
 	}
 }
 
-func (c *KafkaConsumer) Consume(ctx context.Context, handler func(*domain.Message) error) error {
+func (c *KafkaConsumer) Consume(ctx context.Context, handler func(context.Context, *domain.Message) error) error {
 	for {
 		// Check if global circuit is open
 		if globalCircuit.isOpen() {
@@ -131,6 +144,18 @@ func (c *KafkaConsumer) Consume(ctx context.Context, handler func(*domain.Messag
 
 		// Reset global circuit on success
 		globalCircuit.reset()
+		// Timestamp start: group readers cannot seek by time, so older events are skipped here.
+		if !c.startTime.IsZero() && !m.Time.IsZero() && m.Time.Before(c.startTime) {
+			continue
+		}
+		if !c.validator.Check(m) {
+			continue
+		}
+		msgCtx := port.WithSourcePosition(ctx, port.SourcePosition{Partition: m.Partition, Offset: m.Offset})
+		catchUp := c.catchUpThreshold > 0 && !m.Time.IsZero() && time.Since(m.Time) > c.catchUpThreshold
+		if catchUp {
+			msgCtx = usecase.WithCatchUp(msgCtx)
+		}
 		msg := decodeMessage(m)
 		slog.Info("kafka message consumed",
 			slog.String("topic", m.Topic),
@@ -140,8 +165,9 @@ func (c *KafkaConsumer) Consume(ctx context.Context, handler func(*domain.Messag
 			slog.String("action", msg.Action),
 			slog.String("resourceId", msg.ResourceID),
 			slog.Any("metadata", msg.Metadata),
+			slog.Bool("catchUp", catchUp),
 		)
-		if err := handler(msg); err != nil {
+		if err := handler(msgCtx, msg); err != nil {
 			slog.Warn("kafka handler error", slog.Any("error", err))
 		}
 	}
@@ -0,0 +1,164 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+	"mesaYaWs/internal/shared/entities"
+)
+
+// TopicRegistrar is invoked once for every newly discovered topic before its consumer starts,
+// so callers can register the handlers that will process it.
+type TopicRegistrar func(topic, entity string)
+
+// TopicDiscovery periodically lists the cluster topics and starts a consumer for every
+// topic that matches the pattern and is not consumed yet.
+type TopicDiscovery struct {
+	pattern   *regexp.Regexp
+	interval  time.Duration
+	brokers   []string
+	dialer    *kafka.Dialer
+	pubsub    port.PubSubPort
+	registry  *infrastructure.HandlerRegistry
+	registrar TopicRegistrar
+	entities  *entities.Registry
+
+	mu    sync.Mutex
+	known map[string]struct{}
+}
+
+// NewTopicDiscovery builds a discovery loop. Topics in existing are treated as already
+// consumed (typically the statically configured ones); entityRegistry names their entities.
+func NewTopicDiscovery(
+	pattern string,
+	interval time.Duration,
+	brokers []string,
+	dialer *kafka.Dialer,
+	pubsub port.PubSubPort,
+	registry *infrastructure.HandlerRegistry,
+	registrar TopicRegistrar,
+	existing []string,
+	entityRegistry *entities.Registry,
+) (*TopicDiscovery, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("compile topic pattern: %w", err)
+	}
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	known := make(map[string]struct{}, len(existing))
+	for _, topic := range existing {
+		known[topic] = struct{}{}
+	}
+	return &TopicDiscovery{
+		pattern:   compiled,
+		interval:  interval,
+		brokers:   brokers,
+		dialer:    dialer,
+		pubsub:    pubsub,
+		registry:  registry,
+		registrar: registrar,
+		entities:  entityRegistry,
+		known:     known,
+	}, nil
+}
+
+// Run scans immediately and then on every interval until ctx is cancelled.
+func (d *TopicDiscovery) Run(ctx context.Context) {
+	if len(d.brokers) == 0 {
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		if err := d.scan(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Warn("kafka topic discovery failed", slog.String("pattern", d.pattern.String()), slog.Any("error", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *TopicDiscovery) scan(ctx context.Context) error {
+	topics, err := listTopics(ctx, d.brokers, d.dialer)
+	if err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		if !d.pattern.MatchString(topic) || !d.claim(topic) {
+			continue
+		}
+		entity := EntityFromTopic(d.entities, topic)
+		slog.Info("kafka topic discovered", slog.String("topic", topic), slog.String("entity", entity))
+		if d.registrar != nil {
+			d.registrar(topic, entity)
+		}
+		go consumeTopic(ctx, d.pubsub, d.registry, topic)
+	}
+	return nil
+}
+
+func (d *TopicDiscovery) claim(topic string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.known[topic]; ok {
+		return false
+	}
+	d.known[topic] = struct{}{}
+	return true
+}
+
+// EntityFromTopic derives the canonical entity in registry from a domain topic such as
+// "mesa-ya.owner-upgrade.events" (=> "owner-upgrades").
+func EntityFromTopic(registry *entities.Registry, topic string) string {
+	return registry.Normalize(extractEntityFromTopic(topic))
+}
+
+func listTopics(ctx context.Context, brokers []string, dialer *kafka.Dialer) ([]string, error) {
+	if dialer == nil {
+		dialer = kafka.DefaultDialer
+	}
+	var lastErr error
+	for _, address := range brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		partitions, err := conn.ReadPartitions()
+		_ = conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		seen := make(map[string]struct{}, len(partitions))
+		topics := make([]string, 0, len(partitions))
+		for _, partition := range partitions {
+			if _, ok := seen[partition.Topic]; ok {
+				continue
+			}
+			seen[partition.Topic] = struct{}{}
+			topics = append(topics, partition.Topic)
+		}
+		sort.Strings(topics)
+		return topics, nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no brokers configured")
+	}
+	return nil, fmt.Errorf("list kafka topics: %w", lastErr)
+}
@@ -0,0 +1,209 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// maxRecordLine bounds a single JSONL record (snapshots embedded in messages can be large).
+const maxRecordLine = 8 * 1024 * 1024
+
+// RecordedEvent is one line of a replay file. Two shapes are accepted:
+//   - raw Kafka events (quarantine files): Value holds the payload, either as a JSON object or
+//     as a JSON string, and is decoded exactly like a consumed Kafka message;
+//   - decoded events (broadcast recordings): Message holds the domain message as broadcast.
+//
+// Topic is the Kafka topic the event was read from and is the topic it is re-published on.
+// Recordings also contain direct replies (Kind "direct": snapshots, pongs); those are not
+// events and are skipped on replay.
+type RecordedEvent struct {
+	Topic      string          `json:"topic"`
+	Kind       string          `json:"kind,omitempty"`
+	Partition  int             `json:"partition,omitempty"`
+	Offset     int64           `json:"offset,omitempty"`
+	Key        string          `json:"key,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	Message    *domain.Message `json:"message,omitempty"`
+	Time       time.Time       `json:"time,omitempty"`
+	ReceivedAt time.Time       `json:"receivedAt,omitempty"`
+}
+
+// At returns when the event originally happened, used to pace replays.
+func (e RecordedEvent) At() time.Time {
+	switch {
+	case !e.Time.IsZero():
+		return e.Time
+	case !e.ReceivedAt.IsZero():
+		return e.ReceivedAt
+	case e.Message != nil:
+		return e.Message.Timestamp
+	}
+	return time.Time{}
+}
+
+// Decode converts the record into the domain message the consumer would have produced.
+func (e RecordedEvent) Decode() *domain.Message {
+	if e.Message != nil {
+		msg := *e.Message
+		if strings.TrimSpace(msg.Topic) == "" {
+			msg.Topic = e.Topic
+		}
+		return &msg
+	}
+	value := []byte(e.Value)
+	var quoted string
+	if err := json.Unmarshal(value, &quoted); err == nil {
+		value = []byte(quoted)
+	}
+	return decodeMessage(kafka.Message{
+		Topic:     e.Topic,
+		Partition: e.Partition,
+		Offset:    e.Offset,
+		Key:       []byte(e.Key),
+		Value:     value,
+		Time:      e.At(),
+	})
+}
+
+// ReplayOptions controls ReadRecordedEvents pacing and filtering.
+type ReplayOptions struct {
+	// Speed multiplies the original pace: 1 keeps the recorded gaps, 10 is ten times faster
+	// and 0 (or negative) replays as fast as possible.
+	Speed float64
+	// Topics keeps only events whose Kafka topic or message topic matches one of the
+	// patterns (glob syntax, e.g. "mesa-ya.*.events", "reservations.*"). Empty keeps all.
+	Topics []string
+}
+
+// ReadRecordedEvents streams the JSONL records from r to fn, honouring opts. Malformed lines
+// are logged and skipped; an error returned by fn stops the replay.
+func ReadRecordedEvents(ctx context.Context, r io.Reader, opts ReplayOptions, fn func(RecordedEvent, *domain.Message) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordLine)
+
+	var previous time.Time
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var event RecordedEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			slog.Warn("replay record skipped", slog.Int("line", line), slog.Any("error", err))
+			continue
+		}
+		if event.Kind == "direct" {
+			continue
+		}
+		if event.Message == nil && len(event.Value) == 0 {
+			slog.Warn("replay record skipped", slog.Int("line", line), slog.String("reason", "record has neither value nor message"))
+			continue
+		}
+		msg := event.Decode()
+		if !matchesAnyTopic(opts.Topics, event.Topic, msg.Topic) {
+			continue
+		}
+
+		at := event.At()
+		if opts.Speed > 0 && !previous.IsZero() && at.After(previous) {
+			wait := time.Duration(float64(at.Sub(previous)) / opts.Speed)
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if !at.IsZero() {
+			previous = at
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(event, msg); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read replay records: %w", err)
+	}
+	return nil
+}
+
+func matchesAnyTopic(patterns []string, topics ...string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		for _, topic := range topics {
+			if topic == "" {
+				continue
+			}
+			if pattern == topic {
+				return true
+			}
+			if ok, err := path.Match(pattern, topic); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FileSource is a PubSubPort that replays a JSONL recording instead of reading from Kafka.
+// Records are re-published on their Kafka topic through an in-memory pubsub once Start runs.
+type FileSource struct {
+	path   string
+	opts   ReplayOptions
+	memory *MemoryPubSub
+}
+
+func NewFileSource(path string, opts ReplayOptions) *FileSource {
+	return &FileSource{path: strings.TrimSpace(path), opts: opts, memory: NewMemoryPubSub()}
+}
+
+// Consume subscribes to the replayed events of topic until ctx is cancelled.
+func (s *FileSource) Consume(ctx context.Context, topic string, handler func(context.Context, *domain.Message) error) error {
+	return s.memory.Consume(ctx, topic, handler)
+}
+
+// Start replays the file once and returns when it is exhausted or ctx is cancelled.
+func (s *FileSource) Start(ctx context.Context) error {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("open replay file: %w", err)
+	}
+	defer file.Close()
+
+	slog.Info("replay started", slog.String("file", s.path), slog.Float64("speed", s.opts.Speed))
+	count := 0
+	err = ReadRecordedEvents(ctx, file, s.opts, func(event RecordedEvent, msg *domain.Message) error {
+		topic := firstNonEmpty(event.Topic, msg.Topic)
+		if err := s.memory.Publish(ctx, topic, msg); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	slog.Info("replay finished", slog.String("file", s.path), slog.Int("events", count), slog.Any("error", err))
+	return err
+}
+
+var _ port.PubSubPort = (*FileSource)(nil)
@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+const replayFixture = `{"topic":"mesa-ya.reservations.events","partition":0,"offset":4,"value":"{\"event_type\":\"created\",\"entity_id\":\"r-1\",\"data\":{\"id\":\"r-1\"}}","violations":["x"],"receivedAt":"2026-01-01T10:00:00Z"}
+not json
+{"topic":"mesa-ya.tables.events","value":{"event_type":"updated","entity_id":"t-1"},"time":"2026-01-01T10:00:01Z"}
+{"topic":"mesa-ya.reservations.events","message":{"topic":"reservations.updated","entity":"reservations","action":"updated","resourceId":"r-1","timestamp":"2026-01-01T10:00:02Z"}}
+`
+
+func TestReadRecordedEvents_DecodesBothShapesAndFilters(t *testing.T) {
+	var got []*domain.Message
+	err := ReadRecordedEvents(context.Background(), strings.NewReader(replayFixture), ReplayOptions{Topics: []string{"reservations.*"}}, func(_ RecordedEvent, msg *domain.Message) error {
+		got = append(got, msg)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 reservation events, got %d", len(got))
+	}
+	if got[0].Topic != "reservations.created" || got[0].ResourceID != "r-1" {
+		t.Fatalf("unexpected decoded kafka value: %+v", got[0])
+	}
+	if got[1].Topic != "reservations.updated" || got[1].Action != "updated" {
+		t.Fatalf("unexpected recorded message: %+v", got[1])
+	}
+}
+
+func TestFileSource_DeliversToConsumersSubscribedAfterStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	if err := os.WriteFile(path, []byte(replayFixture), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	source := NewFileSource(path, ReplayOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := source.Start(ctx); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	received := make(chan *domain.Message, 4)
+	go source.Consume(ctx, "mesa-ya.reservations.events", func(_ context.Context, msg *domain.Message) error {
+		received <- msg
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-received:
+			if msg.Entity != "reservations" {
+				t.Fatalf("unexpected entity %q", msg.Entity)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for replayed event %d", i+1)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package broker
+
+import (
+	"context"
+	"errors"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+// KafkaPubSub implements PubSubPort on top of kafka-go group readers.
+type KafkaPubSub struct {
+	brokers []string
+	groupID string
+	opts    ConsumerOptions
+}
+
+// NewKafkaPubSub builds the Kafka-backed PubSubPort shared by every topic consumer.
+func NewKafkaPubSub(brokers []string, groupID string, opts ConsumerOptions) *KafkaPubSub {
+	return &KafkaPubSub{brokers: brokers, groupID: groupID, opts: opts}
+}
+
+// Consume reads topic until ctx is cancelled, passing each decoded message to handler.
+func (p *KafkaPubSub) Consume(ctx context.Context, topic string, handler func(context.Context, *domain.Message) error) error {
+	if len(p.brokers) == 0 {
+		// We avoid calling kafka.NewReader with an empty broker list.
+		return errors.New("no kafka brokers configured")
+	}
+	consumer := NewKafkaConsumer(p.brokers, p.groupID, topic, p.opts.Dialer, p.opts.StartOffset)
+	consumer.validator = p.opts.Validator
+	consumer.startTime = p.opts.StartTime
+	consumer.catchUpThreshold = p.opts.CatchUpThreshold
+	return consumer.Consume(ctx, handler)
+}
+
+var _ port.PubSubPort = (*KafkaPubSub)(nil)
@@ -0,0 +1,127 @@
+package broker
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+const (
+	memorySubscriberBuffer = 256
+	memoryPendingLimit     = 1000
+)
+
+// MemoryPubSub is an in-process PubSubPort. Every consumer of a topic receives each message
+// published to it. Messages published while a topic has no consumers are retained (up to
+// memoryPendingLimit per topic) and delivered to the first consumer, like a Kafka topic
+// read from the earliest offset.
+type MemoryPubSub struct {
+	mu          sync.Mutex
+	subscribers map[string][]*memorySubscriber
+	pending     map[string][]*domain.Message
+}
+
+type memorySubscriber struct {
+	ch   chan *domain.Message
+	done chan struct{}
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{
+		subscribers: make(map[string][]*memorySubscriber),
+		pending:     make(map[string][]*domain.Message),
+	}
+}
+
+// Publish delivers msg to every consumer of topic, blocking while their buffers are full
+// until ctx is cancelled.
+func (p *MemoryPubSub) Publish(ctx context.Context, topic string, msg *domain.Message) error {
+	topic = strings.TrimSpace(topic)
+	if msg == nil || topic == "" {
+		return nil
+	}
+
+	p.mu.Lock()
+	subscribers := append([]*memorySubscriber(nil), p.subscribers[topic]...)
+	if len(subscribers) == 0 {
+		pending := append(p.pending[topic], msg)
+		if len(pending) > memoryPendingLimit {
+			pending = pending[len(pending)-memoryPendingLimit:]
+		}
+		p.pending[topic] = pending
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+
+	for _, sub := range subscribers {
+		select {
+		case sub.ch <- msg:
+		case <-sub.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Consume delivers the messages published to topic until ctx is cancelled.
+func (p *MemoryPubSub) Consume(ctx context.Context, topic string, handler func(context.Context, *domain.Message) error) error {
+	topic = strings.TrimSpace(topic)
+	sub := &memorySubscriber{
+		ch:   make(chan *domain.Message, memorySubscriberBuffer),
+		done: make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	backlog := p.pending[topic]
+	delete(p.pending, topic)
+	p.subscribers[topic] = append(p.subscribers[topic], sub)
+	p.mu.Unlock()
+	defer p.unsubscribe(topic, sub)
+
+	for _, msg := range backlog {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.handle(ctx, topic, msg, handler)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg := <-sub.ch:
+			p.handle(ctx, topic, msg, handler)
+		}
+	}
+}
+
+func (p *MemoryPubSub) handle(ctx context.Context, topic string, msg *domain.Message, handler func(context.Context, *domain.Message) error) {
+	if err := handler(ctx, msg); err != nil {
+		slog.Warn("memory pubsub handler error", slog.String("topic", topic), slog.String("messageTopic", msg.Topic), slog.Any("error", err))
+	}
+}
+
+func (p *MemoryPubSub) unsubscribe(topic string, sub *memorySubscriber) {
+	close(sub.done)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	subscribers := p.subscribers[topic]
+	for i, candidate := range subscribers {
+		if candidate == sub {
+			subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+			break
+		}
+	}
+	if len(subscribers) == 0 {
+		delete(p.subscribers, topic)
+		return
+	}
+	p.subscribers[topic] = subscribers
+}
+
+var _ port.PubSubPort = (*MemoryPubSub)(nil)
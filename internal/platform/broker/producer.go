@@ -0,0 +1,165 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+// ProducerOptions tunes the kafka-go writer behind KafkaProducer.
+type ProducerOptions struct {
+	// Transport carries TLS/SASL settings (see NewTransport). Nil uses plaintext connections.
+	Transport *kafka.Transport
+	// BatchSize and BatchTimeout bound how many messages are grouped per produce request.
+	BatchSize    int
+	BatchTimeout time.Duration
+	// MaxAttempts is the number of delivery attempts before a message is reported as lost.
+	MaxAttempts int
+}
+
+// producedEvent mirrors the payload the consumer expects (see rawEvent) so events emitted here
+// can be consumed by the Nest backend and by this service alike.
+type producedEvent struct {
+	EventID   string            `json:"event_id"`
+	EventType string            `json:"event_type"`
+	Entity    string            `json:"entity"`
+	EntityID  string            `json:"entity_id,omitempty"`
+	Timestamp string            `json:"timestamp"`
+	Data      any               `json:"data,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// KafkaProducer implements EventPublisher with an asynchronous kafka-go writer, so websocket
+// commands never wait for the brokers. Messages are hash-partitioned by key, batched, and
+// acknowledged by every in-sync replica in the background; delivery failures are logged and
+// counted (kafka_produce_errors_total). kafka-go has no idempotent producer, so each event
+// carries a stable event_id (payload and header) that stays the same across retries and lets
+// consumers drop duplicates.
+type KafkaProducer struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaProducer builds the producer for brokers. Topics are chosen per message.
+func NewKafkaProducer(brokers []string, opts ProducerOptions) (*KafkaProducer, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no kafka brokers configured")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.BatchTimeout <= 0 {
+		opts.BatchTimeout = 50 * time.Millisecond
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		BatchSize:              opts.BatchSize,
+		BatchTimeout:           opts.BatchTimeout,
+		MaxAttempts:            opts.MaxAttempts,
+		RequiredAcks:           kafka.RequireAll,
+		AllowAutoTopicCreation: false,
+		Async:                  true,
+		Completion:             reportProduced,
+	}
+	if opts.Transport != nil {
+		writer.Transport = opts.Transport
+	}
+	slog.Info("kafka producer ready", slog.Any("brokers", brokers), slog.Int("batchSize", opts.BatchSize), slog.Duration("batchTimeout", opts.BatchTimeout), slog.Int("maxAttempts", opts.MaxAttempts))
+	return &KafkaProducer{writer: writer}, nil
+}
+
+// Publish encodes msg and queues it for topic without waiting for the brokers; concurrent calls
+// share batches. Errors are only returned for invalid messages or a closed producer.
+func (p *KafkaProducer) Publish(ctx context.Context, topic, key string, msg *domain.Message) error {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return errors.New("kafka producer: topic is required")
+	}
+	if msg == nil {
+		return errors.New("kafka producer: message is required")
+	}
+	eventID := ""
+	if msg.Metadata != nil {
+		eventID = strings.TrimSpace(msg.Metadata["eventId"])
+	}
+	if eventID == "" {
+		eventID = newEventID()
+	}
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now().UTC()
+	}
+	value, err := json.Marshal(producedEvent{
+		EventID:   eventID,
+		EventType: msg.Action,
+		Entity:    msg.Entity,
+		EntityID:  msg.ResourceID,
+		Timestamp: timestamp.Format(time.RFC3339Nano),
+		Data:      msg.Data,
+		Metadata:  msg.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("kafka producer: encode event: %w", err)
+	}
+
+	err = p.writer.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   []byte(key),
+		Value: value,
+		Time:  timestamp,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(eventID)},
+			{Key: "event_type", Value: []byte(msg.Action)},
+			{Key: "content-type", Value: []byte("application/json")},
+		},
+	})
+	if err != nil {
+		metrics.Default.Counter("kafka_produce_errors_total", "topic", topic).Inc()
+		return fmt.Errorf("kafka producer: queue %s: %w", topic, err)
+	}
+	return nil
+}
+
+// reportProduced is called by the writer once a batch is acknowledged or given up on.
+func reportProduced(messages []kafka.Message, err error) {
+	for _, m := range messages {
+		if err != nil {
+			metrics.Default.Counter("kafka_produce_errors_total", "topic", m.Topic).Inc()
+			continue
+		}
+		metrics.Default.Counter("kafka_produced_total", "topic", m.Topic).Inc()
+	}
+	if err != nil && len(messages) > 0 {
+		slog.Error("kafka producer: delivery failed", slog.String("topic", messages[0].Topic), slog.Int("messages", len(messages)), slog.Any("error", err))
+	}
+}
+
+// Close flushes pending batches and releases the writer connections.
+func (p *KafkaProducer) Close() error {
+	return p.writer.Close()
+}
+
+func newEventID() string {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(raw[:])
+}
+
+var _ port.EventPublisher = (*KafkaProducer)(nil)
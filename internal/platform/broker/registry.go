@@ -2,29 +2,53 @@ package broker
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/segmentio/kafka-go"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
 	"mesaYaWs/internal/modules/realtime/domain"
 	"mesaYaWs/internal/modules/realtime/infrastructure"
 )
 
-func StartKafkaConsumers(
-	ctx context.Context,
-	registry *infrastructure.HandlerRegistry,
-	brokers []string,
-	groupID string,
-	topics []string,
-) {
-	if len(brokers) == 0 {
-		// No brokers configured; skip starting consumers. In production this should be set
-		// by KAFKA_BROKER(S). We avoid calling kafka.NewReader with an empty broker list.
-		return
+// ConsumerOptions groups optional behaviour shared by every topic consumer.
+type ConsumerOptions struct {
+	// Validator checks payloads against the event schemas before dispatching. Nil disables validation.
+	Validator *EventValidator
+	// Dialer carries TLS/SASL settings (see NewDialer). Nil uses plaintext connections.
+	Dialer *kafka.Dialer
+	// StartOffset is kafka.FirstOffset or kafka.LastOffset for groups without committed offsets.
+	StartOffset int64
+	// StartTime skips events produced before it (timestamp start offset). Zero disables it.
+	StartTime time.Time
+	// CatchUpThreshold marks older events as catch-up (no client broadcast). Zero disables it.
+	CatchUpThreshold time.Duration
+}
+
+// StartOffsetFromConfig maps the configured start offset name to the kafka-go constant.
+// Timestamp starts read from the beginning and rely on StartTime to skip older events.
+func StartOffsetFromConfig(name string) int64 {
+	if name == "latest" {
+		return kafka.LastOffset
 	}
+	return kafka.FirstOffset
+}
+
+// StartConsumers subscribes to every topic through pubsub and dispatches the messages to the
+// registry, one goroutine per topic.
+func StartConsumers(ctx context.Context, pubsub port.PubSubPort, registry *infrastructure.HandlerRegistry, topics []string) {
 	for _, topic := range topics {
-		go func(tp string) {
-			consumer := NewKafkaConsumer(brokers, groupID, tp)
-			consumer.Consume(ctx, func(msg *domain.Message) error {
-				return registry.Dispatch(ctx, msg)
-			})
-		}(topic)
+		go consumeTopic(ctx, pubsub, registry, topic)
+	}
+}
+
+func consumeTopic(ctx context.Context, pubsub port.PubSubPort, registry *infrastructure.HandlerRegistry, topic string) {
+	err := pubsub.Consume(ctx, topic, func(msgCtx context.Context, msg *domain.Message) error {
+		return registry.DispatchFrom(port.WithSourceTopic(msgCtx, topic), topic, msg)
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		slog.Warn("pubsub consumer stopped", slog.String("topic", topic), slog.Any("error", err))
 	}
 }
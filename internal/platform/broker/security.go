@@ -0,0 +1,111 @@
+package broker
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"mesaYaWs/internal/config"
+)
+
+// NewDialer builds the kafka-go dialer used by readers, applying TLS and SASL settings.
+// It returns nil when neither is configured so kafka-go keeps its default dialer.
+func NewDialer(tlsCfg config.KafkaTLSConfig, saslCfg config.KafkaSASLConfig) (*kafka.Dialer, error) {
+	tlsConfig, mechanism, err := securitySettings(tlsCfg, saslCfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil && mechanism == nil {
+		return nil, nil
+	}
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           tlsConfig,
+		SASLMechanism: mechanism,
+	}, nil
+}
+
+// NewTransport builds the kafka-go transport used by writers with the same TLS and SASL
+// settings as NewDialer. It returns nil when neither is configured.
+func NewTransport(tlsCfg config.KafkaTLSConfig, saslCfg config.KafkaSASLConfig) (*kafka.Transport, error) {
+	tlsConfig, mechanism, err := securitySettings(tlsCfg, saslCfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil && mechanism == nil {
+		return nil, nil
+	}
+	return &kafka.Transport{
+		DialTimeout: 10 * time.Second,
+		TLS:         tlsConfig,
+		SASL:        mechanism,
+	}, nil
+}
+
+func securitySettings(tlsCfg config.KafkaTLSConfig, saslCfg config.KafkaSASLConfig) (*tls.Config, sasl.Mechanism, error) {
+	tlsConfig, err := buildTLSConfig(tlsCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	mechanism, err := buildSASLMechanism(saslCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tlsConfig, mechanism, nil
+}
+
+func buildTLSConfig(cfg config.KafkaTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, // opt-in for local clusters with self-signed certs
+	}
+	if cfg.CAPEM != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(cfg.CAPEM)) {
+			return nil, errors.New("kafka tls: no certificates found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(cfg.CertPEM), []byte(cfg.KeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls: load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func buildSASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Mechanism {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		mechanism, err := scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sasl scram-sha-256: %w", err)
+		}
+		return mechanism, nil
+	case "scram-sha-512":
+		mechanism, err := scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("kafka sasl scram-sha-512: %w", err)
+		}
+		return mechanism, nil
+	default:
+		return nil, fmt.Errorf("kafka sasl: unsupported mechanism %q", cfg.Mechanism)
+	}
+}
@@ -0,0 +1,109 @@
+package broker
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/config"
+)
+
+func selfSignedPEM(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certPEM, keyPEM := selfSignedPEM(t)
+
+	disabled, err := buildTLSConfig(config.KafkaTLSConfig{CAPEM: certPEM})
+	if err != nil || disabled != nil {
+		t.Fatalf("disabled TLS must build nothing, got %v %v", disabled, err)
+	}
+
+	tlsConfig, err := buildTLSConfig(config.KafkaTLSConfig{Enabled: true, CAPEM: certPEM, CertPEM: certPEM, KeyPEM: keyPEM})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("unexpected defaults: min=%x insecure=%v", tlsConfig.MinVersion, tlsConfig.InsecureSkipVerify)
+	}
+	if tlsConfig.RootCAs == nil || len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected CA pool and client certificate, got %+v", tlsConfig)
+	}
+
+	if _, err := buildTLSConfig(config.KafkaTLSConfig{Enabled: true, CAPEM: "not a pem"}); err == nil {
+		t.Fatal("invalid CA bundle must fail")
+	}
+	if _, err := buildTLSConfig(config.KafkaTLSConfig{Enabled: true, CertPEM: certPEM}); err == nil {
+		t.Fatal("client certificate without key must fail")
+	}
+}
+
+func TestBuildSASLMechanism(t *testing.T) {
+	cases := []struct {
+		mechanism string
+		name      string
+		wantErr   bool
+	}{
+		{mechanism: "", name: ""},
+		{mechanism: "plain", name: "PLAIN"},
+		{mechanism: "scram-sha-256", name: "SCRAM-SHA-256"},
+		{mechanism: "scram-sha-512", name: "SCRAM-SHA-512"},
+		{mechanism: "gssapi", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mechanism, func(t *testing.T) {
+			mechanism, err := buildSASLMechanism(config.KafkaSASLConfig{Mechanism: tc.mechanism, Username: "svc", Password: "secret"})
+			if tc.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tc.mechanism) {
+					t.Fatalf("expected unsupported mechanism error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.name == "" {
+				if mechanism != nil {
+					t.Fatalf("expected no mechanism, got %s", mechanism.Name())
+				}
+				return
+			}
+			if mechanism == nil || mechanism.Name() != tc.name {
+				t.Fatalf("expected %s, got %v", tc.name, mechanism)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package broker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"mesaYaWs/internal/shared/jsonschema"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+// ValidationMode controls what happens to events that do not match their schema.
+type ValidationMode string
+
+const (
+	// ValidationOff skips schema validation entirely.
+	ValidationOff ValidationMode = "off"
+	// ValidationWarn logs and quarantines invalid events but still dispatches them.
+	ValidationWarn ValidationMode = "warn"
+	// ValidationEnforce quarantines invalid events and drops them from the pipeline.
+	ValidationEnforce ValidationMode = "enforce"
+)
+
+// ParseValidationMode converts textual modes into a ValidationMode. An empty value means off;
+// unknown values are rejected so a typo cannot silently disable validation.
+func ParseValidationMode(raw string) (ValidationMode, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "off":
+		return ValidationOff, nil
+	case "warn", "warning", "log":
+		return ValidationWarn, nil
+	case "enforce", "strict", "reject":
+		return ValidationEnforce, nil
+	default:
+		return "", fmt.Errorf("unsupported validation mode %q", raw)
+	}
+}
+
+const defaultSchemaKey = "default"
+
+// EventValidator checks raw Kafka payloads against the JSON Schemas published for each
+// event type. Schemas are looked up as `{entity}.{event_type}`, then `{entity}`, then
+// `default`, where entity is derived from the topic (mesa-ya.{entity}.events).
+//
+// The schemas come from a directory rather than docs/websocket/asyncapi.yml: that file
+// describes the websocket envelopes sent to browsers (list/detail/error channels), not the
+// event_type/entity_id payloads producers write to Kafka, so it has nothing to validate them
+// against.
+type EventValidator struct {
+	mode       ValidationMode
+	schemas    map[string]*jsonschema.Schema
+	quarantine *quarantineWriter
+}
+
+// NewEventValidator loads every *.json schema from schemaDir. The file name without the
+// extension becomes the lookup key (e.g. reservations.created.json, reservations.json).
+func NewEventValidator(mode ValidationMode, schemaDir, quarantineDir string) (*EventValidator, error) {
+	validator := &EventValidator{mode: mode, schemas: make(map[string]*jsonschema.Schema)}
+	if mode == ValidationOff {
+		return validator, nil
+	}
+	if strings.TrimSpace(schemaDir) == "" {
+		return nil, errors.New("schema directory is required when validation is enabled")
+	}
+	entries, err := os.ReadDir(schemaDir)
+	if err != nil {
+		return nil, fmt.Errorf("read schema dir: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(schemaDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read schema %s: %w", entry.Name(), err)
+		}
+		schema, err := jsonschema.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("parse schema %s: %w", entry.Name(), err)
+		}
+		key := strings.ToLower(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+		validator.schemas[key] = schema
+	}
+	if strings.TrimSpace(quarantineDir) != "" {
+		validator.quarantine = &quarantineWriter{dir: quarantineDir}
+	}
+	slog.Info("kafka event validator ready", slog.String("mode", string(mode)), slog.Int("schemas", len(validator.schemas)), slog.String("quarantineDir", quarantineDir))
+	return validator, nil
+}
+
+// Mode returns the configured validation mode.
+func (v *EventValidator) Mode() ValidationMode {
+	if v == nil {
+		return ValidationOff
+	}
+	return v.mode
+}
+
+// Check validates the Kafka message. It returns true when the message should continue
+// through the pipeline (valid events, or invalid ones while running in warn mode).
+func (v *EventValidator) Check(m kafka.Message) bool {
+	if v == nil || v.mode == ValidationOff {
+		return true
+	}
+	violations := v.validate(m)
+	if len(violations) == 0 {
+		metrics.Default.Counter("kafka_events_valid_total", "topic", m.Topic).Inc()
+		return true
+	}
+
+	metrics.Default.Counter("kafka_events_invalid_total", "topic", m.Topic, "mode", string(v.mode)).Inc()
+	slog.Warn("kafka event failed schema validation",
+		slog.String("topic", m.Topic),
+		slog.Int("partition", m.Partition),
+		slog.Int64("offset", m.Offset),
+		slog.String("mode", string(v.mode)),
+		slog.Any("violations", violations),
+	)
+	if v.quarantine != nil {
+		if err := v.quarantine.write(m, violations); err != nil {
+			slog.Error("kafka quarantine write failed", slog.String("topic", m.Topic), slog.Any("error", err))
+		} else {
+			metrics.Default.Counter("kafka_events_quarantined_total", "topic", m.Topic).Inc()
+		}
+	}
+	return v.mode != ValidationEnforce
+}
+
+func (v *EventValidator) validate(m kafka.Message) []string {
+	var document any
+	if err := json.Unmarshal(m.Value, &document); err != nil {
+		return []string{"payload is not valid JSON: " + err.Error()}
+	}
+	eventType := ""
+	if object, ok := document.(map[string]any); ok {
+		eventType, _ = object["event_type"].(string)
+		if eventType == "" {
+			eventType, _ = object["action"].(string)
+		}
+	}
+	schema := v.lookup(extractEntityFromTopic(m.Topic), eventType)
+	if schema == nil {
+		return nil
+	}
+	errs := schema.Validate(document)
+	if len(errs) == 0 {
+		return nil
+	}
+	violations := make([]string, 0, len(errs))
+	for _, err := range errs {
+		violations = append(violations, err.Error())
+	}
+	return violations
+}
+
+func (v *EventValidator) lookup(entity, eventType string) *jsonschema.Schema {
+	entity = strings.ToLower(strings.TrimSpace(entity))
+	eventType = strings.ToLower(strings.TrimSpace(eventType))
+	if entity != "" && eventType != "" {
+		if schema, ok := v.schemas[entity+"."+eventType]; ok {
+			return schema
+		}
+	}
+	if entity != "" {
+		if schema, ok := v.schemas[entity]; ok {
+			return schema
+		}
+	}
+	return v.schemas[defaultSchemaKey]
+}
+
+// quarantineWriter appends rejected events to one JSONL file per topic so they can be
+// inspected or replayed once the producer is fixed.
+type quarantineWriter struct {
+	dir string
+	mu  sync.Mutex
+}
+
+type quarantineRecord struct {
+	Topic      string    `json:"topic"`
+	Partition  int       `json:"partition"`
+	Offset     int64     `json:"offset"`
+	Key        string    `json:"key,omitempty"`
+	Value      string    `json:"value"`
+	Violations []string  `json:"violations"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+func (q *quarantineWriter) write(m kafka.Message, violations []string) error {
+	record := quarantineRecord{
+		Topic:      m.Topic,
+		Partition:  m.Partition,
+		Offset:     m.Offset,
+		Key:        string(m.Key),
+		Value:      string(m.Value),
+		Violations: violations,
+		ReceivedAt: time.Now().UTC(),
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return fmt.Errorf("create quarantine dir: %w", err)
+	}
+	name := strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(m.Topic)
+	if name == "" {
+		name = "unknown"
+	}
+	file, err := os.OpenFile(filepath.Join(q.dir, name+".jsonl"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open quarantine file: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const reservationsSchema = `{
+  "type": "object",
+  "required": ["event_type", "entity_id"],
+  "properties": {
+    "event_type": {"type": "string", "enum": ["created", "updated"]},
+    "entity_id": {"type": "string", "minLength": 1}
+  }
+}`
+
+func newTestValidator(t *testing.T, mode ValidationMode) (*EventValidator, string) {
+	t.Helper()
+	schemaDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(schemaDir, "reservations.json"), []byte(reservationsSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	quarantineDir := filepath.Join(t.TempDir(), "quarantine")
+	validator, err := NewEventValidator(mode, schemaDir, quarantineDir)
+	if err != nil {
+		t.Fatalf("validator: %v", err)
+	}
+	return validator, quarantineDir
+}
+
+func TestEventValidator_ValidEventPassesWithoutQuarantine(t *testing.T) {
+	validator, quarantineDir := newTestValidator(t, ValidationEnforce)
+
+	valid := kafka.Message{Topic: "mesa-ya.reservations.events", Value: []byte(`{"event_type":"created","entity_id":"r-1"}`)}
+	if !validator.Check(valid) {
+		t.Fatal("valid event must continue through the pipeline")
+	}
+	unknown := kafka.Message{Topic: "mesa-ya.tables.events", Value: []byte(`{"anything":true}`)}
+	if !validator.Check(unknown) {
+		t.Fatal("events without schema must pass")
+	}
+	if _, err := os.Stat(quarantineDir); !os.IsNotExist(err) {
+		t.Fatalf("nothing should be quarantined, stat err=%v", err)
+	}
+}
+
+func TestEventValidator_InvalidEventsAreQuarantinedPerMode(t *testing.T) {
+	invalid := kafka.Message{Topic: "mesa-ya.reservations.events", Partition: 2, Offset: 41, Value: []byte(`{"event_type":"exploded"}`)}
+
+	enforce, quarantineDir := newTestValidator(t, ValidationEnforce)
+	if enforce.Check(invalid) {
+		t.Fatal("enforce mode must drop invalid events")
+	}
+	warn, _ := newTestValidator(t, ValidationWarn)
+	if !warn.Check(invalid) {
+		t.Fatal("warn mode must still dispatch invalid events")
+	}
+	notJSON := kafka.Message{Topic: "mesa-ya.reservations.events", Offset: 42, Value: []byte(`not json`)}
+	if enforce.Check(notJSON) {
+		t.Fatal("enforce mode must drop payloads that are not JSON")
+	}
+
+	raw, err := os.ReadFile(filepath.Join(quarantineDir, "mesa-ya.reservations.events.jsonl"))
+	if err != nil {
+		t.Fatalf("quarantine file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 quarantined events, got %d", len(lines))
+	}
+	var record quarantineRecord
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Partition != 2 || record.Offset != 41 || record.Value != `{"event_type":"exploded"}` || len(record.Violations) < 2 {
+		t.Fatalf("unexpected quarantine record: %+v", record)
+	}
+}
+
+func TestEventValidator_OffModeNeedsNoSchemas(t *testing.T) {
+	validator, err := NewEventValidator(ValidationOff, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validator.Check(kafka.Message{Value: []byte(`not json`)}) {
+		t.Fatal("off mode must not validate")
+	}
+	if _, err := NewEventValidator(ValidationWarn, "", ""); err == nil {
+		t.Fatal("warn mode without schema dir must fail")
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	cases := map[string]ValidationMode{"": ValidationOff, "off": ValidationOff, "Warn": ValidationWarn, " strict ": ValidationEnforce}
+	for raw, want := range cases {
+		if got, err := ParseValidationMode(raw); err != nil || got != want {
+			t.Fatalf("ParseValidationMode(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := ParseValidationMode("enforced"); err == nil {
+		t.Fatal("unknown mode must be rejected")
+	}
+}
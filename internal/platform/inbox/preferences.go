@@ -0,0 +1,79 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+)
+
+// PreferenceFile implements port.NotificationPreferenceStore with a single JSON file keyed by
+// user id. An empty file keeps the preferences in memory only.
+type PreferenceFile struct {
+	file string
+
+	mu    sync.Mutex
+	users map[string]port.NotificationPreferences
+}
+
+// NewPreferenceFile restores the preferences persisted in file.
+func NewPreferenceFile(file string) (*PreferenceFile, error) {
+	store := &PreferenceFile{file: strings.TrimSpace(file), users: make(map[string]port.NotificationPreferences)}
+	if store.file == "" {
+		return store, nil
+	}
+	raw, err := os.ReadFile(store.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read notification preferences: %w", err)
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return store, nil
+	}
+	if err := json.Unmarshal(raw, &store.users); err != nil {
+		return nil, fmt.Errorf("parse notification preferences: %w", err)
+	}
+	return store, nil
+}
+
+func (s *PreferenceFile) Get(_ context.Context, userID string) (port.NotificationPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[userID], nil
+}
+
+// Save replaces the preferences of userID and rewrites the file atomically.
+func (s *PreferenceFile) Save(_ context.Context, userID string, preferences port.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(preferences.MutedTopics) == 0 && len(preferences.MutedEntities) == 0 && preferences.QuietHours == nil {
+		delete(s.users, userID)
+	} else {
+		s.users[userID] = preferences
+	}
+	if s.file == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0o755); err != nil {
+		return fmt.Errorf("create notification preferences dir: %w", err)
+	}
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write notification preferences: %w", err)
+	}
+	return os.Rename(tmp, s.file)
+}
+
+var _ port.NotificationPreferenceStore = (*PreferenceFile)(nil)
@@ -0,0 +1,41 @@
+package inbox
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+)
+
+func TestPreferenceFile_PersistsAndClears(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "prefs", "preferences.json")
+	store, err := NewPreferenceFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	muted := port.NotificationPreferences{MutedTopics: []string{"tables.*"}}
+	if err := store.Save(ctx, "owner-1", muted); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, "owner-2", muted); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, "owner-2", port.NotificationPreferences{}); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewPreferenceFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefs, _ := restored.Get(ctx, "owner-1")
+	if !slices.Equal(prefs.MutedTopics, muted.MutedTopics) {
+		t.Fatalf("unexpected restored preferences: %+v", prefs)
+	}
+	if _, ok := restored.users["owner-2"]; ok {
+		t.Fatal("empty preferences must be dropped from the file")
+	}
+}
@@ -0,0 +1,178 @@
+// Package inbox keeps the last notifications of every user so they survive disconnects and
+// restarts.
+package inbox
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+)
+
+// DefaultLimit is the number of notifications kept per user when none is configured.
+const DefaultLimit = 100
+
+// FileStore implements port.NotificationStore with one JSON file per user under dir, newest
+// notification first. Every call reads the user file, so memory does not grow with the number of
+// users. An empty dir keeps the notifications in memory only.
+type FileStore struct {
+	dir   string
+	limit int
+
+	mu sync.Mutex
+	// users holds the inboxes when dir is empty.
+	users map[string][]port.StoredNotification
+}
+
+// NewFileStore creates the store, keeping at most limit notifications per user.
+func NewFileStore(dir string, limit int) (*FileStore, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	store := &FileStore{dir: strings.TrimSpace(dir), limit: limit, users: make(map[string][]port.StoredNotification)}
+	if store.dir != "" {
+		if err := os.MkdirAll(store.dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create inbox dir: %w", err)
+		}
+	}
+	return store, nil
+}
+
+// Append adds notification on top of the user inbox, dropping the oldest beyond the limit.
+// A notification already stored with the same id is ignored.
+func (s *FileStore) Append(_ context.Context, userID string, notification port.StoredNotification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.loadLocked(userID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.ID == notification.ID {
+			return nil
+		}
+	}
+	items = append([]port.StoredNotification{notification}, items...)
+	if len(items) > s.limit {
+		items = items[:s.limit]
+	}
+	return s.saveLocked(userID, items)
+}
+
+func (s *FileStore) List(_ context.Context, userID string, limit int, unreadOnly bool) ([]port.StoredNotification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.loadLocked(userID)
+	if err != nil {
+		return nil, err
+	}
+	listed := make([]port.StoredNotification, 0, len(items))
+	for _, item := range items {
+		if unreadOnly && item.ReadAt != nil {
+			continue
+		}
+		listed = append(listed, item)
+		if limit > 0 && len(listed) == limit {
+			break
+		}
+	}
+	return listed, nil
+}
+
+func (s *FileStore) MarkRead(_ context.Context, userID string, ids []string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.loadLocked(userID)
+	if err != nil {
+		return 0, err
+	}
+	wanted := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[strings.TrimSpace(id)] = struct{}{}
+	}
+	updated := make([]port.StoredNotification, len(items))
+	copy(updated, items)
+	changed := 0
+	for i, item := range updated {
+		if item.ReadAt != nil {
+			continue
+		}
+		if _, ok := wanted[item.ID]; len(ids) > 0 && !ok {
+			continue
+		}
+		readAt := at
+		updated[i].ReadAt = &readAt
+		changed++
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, s.saveLocked(userID, updated)
+}
+
+func (s *FileStore) UnreadCount(_ context.Context, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items, err := s.loadLocked(userID)
+	if err != nil {
+		return 0, err
+	}
+	unread := 0
+	for _, item := range items {
+		if item.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// loadLocked returns the inbox of userID from its file. Callers hold s.mu.
+func (s *FileStore) loadLocked(userID string) ([]port.StoredNotification, error) {
+	if s.dir == "" {
+		return s.users[userID], nil
+	}
+	var items []port.StoredNotification
+	raw, err := os.ReadFile(s.file(userID))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("read inbox: %w", err)
+	case len(strings.TrimSpace(string(raw))) > 0:
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("parse inbox: %w", err)
+		}
+	}
+	return items, nil
+}
+
+// saveLocked replaces the inbox of userID and rewrites its file atomically. Callers hold s.mu.
+func (s *FileStore) saveLocked(userID string, items []port.StoredNotification) error {
+	if s.dir == "" {
+		s.users[userID] = items
+		return nil
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	file := s.file(userID)
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write inbox: %w", err)
+	}
+	return os.Rename(tmp, file)
+}
+
+func (s *FileStore) file(userID string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(userID))+".json")
+}
+
+var _ port.NotificationStore = (*FileStore)(nil)
@@ -0,0 +1,77 @@
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+func TestFileStore_KeepsLastNotificationsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, err := NewFileStore(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 4; i++ {
+		notification := port.StoredNotification{ID: fmt.Sprintf("n-%d", i), Message: &domain.Message{Topic: "reservations.created"}, CreatedAt: time.Now()}
+		if err := store.Append(ctx, "user-1", notification); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Append(ctx, "user-1", port.StoredNotification{ID: "n-4"}); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := store.MarkRead(ctx, "user-1", []string{"n-3", "n-1"}, time.Now()); err != nil || changed != 1 {
+		t.Fatalf("expected only n-3 (n-1 was dropped) to change, got %d (%v)", changed, err)
+	}
+
+	restarted, err := NewFileStore(dir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := restarted.List(ctx, "user-1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[0].ID != "n-4" || items[2].ID != "n-2" {
+		t.Fatalf("unexpected inbox after restart: %+v", items)
+	}
+	unread, _ := restarted.List(ctx, "user-1", 0, true)
+	if count, _ := restarted.UnreadCount(ctx, "user-1"); count != 2 || len(unread) != 2 {
+		t.Fatalf("expected 2 unread, got count=%d list=%d", count, len(unread))
+	}
+	if changed, _ := restarted.MarkRead(ctx, "user-1", nil, time.Now()); changed != 2 {
+		t.Fatalf("mark all read changed %d", changed)
+	}
+	if count, _ := restarted.UnreadCount(ctx, "user-2"); count != 0 {
+		t.Fatalf("unknown user unread = %d", count)
+	}
+	if len(store.users) != 0 || len(restarted.users) != 0 {
+		t.Fatal("file-backed inboxes must not be kept in memory")
+	}
+}
+
+func TestFileStore_MemoryOnly(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore("", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"n-1", "n-2", "n-3"} {
+		if err := store.Append(ctx, "user-1", port.StoredNotification{ID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if changed, err := store.MarkRead(ctx, "user-1", []string{"n-3"}, time.Now()); err != nil || changed != 1 {
+		t.Fatalf("mark_read changed %d (%v)", changed, err)
+	}
+	items, _ := store.List(ctx, "user-1", 0, false)
+	if count, _ := store.UnreadCount(ctx, "user-1"); len(items) != 2 || items[0].ID != "n-3" || count != 1 {
+		t.Fatalf("unexpected inbox: %+v (unread %d)", items, count)
+	}
+}
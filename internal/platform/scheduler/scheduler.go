@@ -0,0 +1,323 @@
+// Package scheduler delivers broadcasts at a given time or on a cron-like recurrence.
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+const tickInterval = time.Second
+
+// Deliverer sends a due message; usecase.BroadcastUseCase implements it.
+type Deliverer interface {
+	ExecuteTargeted(ctx context.Context, msg *domain.Message, target port.BroadcastTarget) int
+}
+
+// Options configures persistence and the time zone of cron expressions.
+type Options struct {
+	// File keeps the pending jobs across restarts. Empty keeps them in memory.
+	File string
+	// Location evaluates cron expressions; nil uses the local time zone.
+	Location *time.Location
+	// Now overrides the clock (tests).
+	Now func() time.Time
+}
+
+// Scheduler keeps the pending broadcasts and sends them when due. One-shot jobs missed while
+// the service was down are sent on start; recurring jobs resume at their next occurrence.
+type Scheduler struct {
+	deliver Deliverer
+	opts    Options
+	now     func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*job
+
+	sent    *metrics.Counter
+	invalid *metrics.Counter
+}
+
+type job struct {
+	port.ScheduledBroadcast
+	recurrence recurrence
+}
+
+// New restores the jobs persisted in opts.File. Call Start to begin delivering.
+func New(deliver Deliverer, opts Options) (*Scheduler, error) {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &Scheduler{
+		deliver: deliver,
+		opts:    opts,
+		now:     opts.Now,
+		jobs:    make(map[string]*job),
+		sent:    metrics.Default.Counter("scheduled_broadcasts_sent_total"),
+		invalid: metrics.Default.Counter("scheduled_broadcasts_invalid_total"),
+	}
+	stored, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	for _, item := range stored {
+		restored := &job{ScheduledBroadcast: item}
+		if item.Cron != "" {
+			rec, err := parseRecurrence(item.Cron, opts.Location)
+			if err != nil {
+				s.invalid.Inc()
+				slog.Warn("scheduled broadcast dropped: invalid cron", slog.String("id", item.ID), slog.String("cron", item.Cron), slog.Any("error", err))
+				continue
+			}
+			restored.recurrence = rec
+			if restored.NextRun.Before(now) {
+				restored.NextRun = rec.next(now)
+			}
+		}
+		s.jobs[item.ID] = restored
+	}
+	slog.Info("broadcast scheduler ready", slog.Int("restored", len(s.jobs)), slog.String("file", opts.File))
+	return s, nil
+}
+
+// Start delivers due jobs until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		s.runDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Schedule validates and stores a job. It needs either DeliverAt (in the future) or Cron.
+func (s *Scheduler) Schedule(item port.ScheduledBroadcast) (port.ScheduledBroadcast, error) {
+	if item.Message == nil {
+		return port.ScheduledBroadcast{}, fmt.Errorf("%w: message is required", port.ErrInvalidSchedule)
+	}
+	item.Cron = strings.TrimSpace(item.Cron)
+	hasDeliverAt := !item.DeliverAt.IsZero()
+	if hasDeliverAt == (item.Cron != "") {
+		return port.ScheduledBroadcast{}, fmt.Errorf("%w: exactly one of deliverAt or cron is required", port.ErrInvalidSchedule)
+	}
+	now := s.now()
+	created := &job{ScheduledBroadcast: item}
+	if hasDeliverAt {
+		if !item.DeliverAt.After(now) {
+			return port.ScheduledBroadcast{}, fmt.Errorf("%w: deliverAt must be in the future", port.ErrInvalidSchedule)
+		}
+		created.DeliverAt = item.DeliverAt.UTC()
+		created.NextRun = created.DeliverAt
+	} else {
+		rec, err := parseRecurrence(item.Cron, s.opts.Location)
+		if err != nil {
+			return port.ScheduledBroadcast{}, fmt.Errorf("%w: %v", port.ErrInvalidSchedule, err)
+		}
+		created.recurrence = rec
+		created.NextRun = rec.next(now)
+		if created.NextRun.IsZero() {
+			return port.ScheduledBroadcast{}, fmt.Errorf("%w: cron never fires", port.ErrInvalidSchedule)
+		}
+	}
+	created.ID = newJobID()
+	created.CreatedAt = now.UTC()
+	created.Runs = 0
+	created.LastRun = time.Time{}
+
+	s.mu.Lock()
+	s.jobs[created.ID] = created
+	err := s.persistLocked()
+	s.mu.Unlock()
+	if err != nil {
+		slog.Error("scheduled broadcasts persist failed", slog.Any("error", err))
+	}
+	slog.Info("broadcast scheduled", slog.String("id", created.ID), slog.String("topic", created.Message.Topic), slog.Time("nextRun", created.NextRun), slog.String("cron", created.Cron))
+	return created.ScheduledBroadcast, nil
+}
+
+// List returns the pending jobs ordered by next run.
+func (s *Scheduler) List() []port.ScheduledBroadcast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]port.ScheduledBroadcast, 0, len(s.jobs))
+	for _, item := range s.jobs {
+		jobs = append(jobs, item.ScheduledBroadcast)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return runsBefore(jobs[i], jobs[j]) })
+	return jobs
+}
+
+// Get returns a pending job.
+func (s *Scheduler) Get(id string) (port.ScheduledBroadcast, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.jobs[id]
+	if !ok {
+		return port.ScheduledBroadcast{}, false
+	}
+	return item.ScheduledBroadcast, true
+}
+
+// Cancel removes a pending job.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return port.ErrScheduleNotFound
+	}
+	delete(s.jobs, id)
+	if err := s.persistLocked(); err != nil {
+		slog.Error("scheduled broadcasts persist failed", slog.Any("error", err))
+	}
+	slog.Info("scheduled broadcast cancelled", slog.String("id", id))
+	return nil
+}
+
+// runDue advances the due jobs under the lock and delivers them outside of it.
+func (s *Scheduler) runDue(ctx context.Context) {
+	now := s.now()
+	var due []port.ScheduledBroadcast
+	s.mu.Lock()
+	for id, item := range s.jobs {
+		if item.NextRun.After(now) {
+			continue
+		}
+		due = append(due, item.ScheduledBroadcast)
+		item.Runs++
+		item.LastRun = now.UTC()
+		if item.recurrence == nil {
+			delete(s.jobs, id)
+			continue
+		}
+		item.NextRun = item.recurrence.next(now)
+		if item.NextRun.IsZero() {
+			delete(s.jobs, id)
+		}
+	}
+	if len(due) > 0 {
+		if err := s.persistLocked(); err != nil {
+			slog.Error("scheduled broadcasts persist failed", slog.Any("error", err))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(due, func(i, j int) bool { return runsBefore(due[i], due[j]) })
+	for _, item := range due {
+		msg := *item.Message
+		msg.Timestamp = now.UTC()
+		if msg.Metadata == nil {
+			msg.Metadata = map[string]string{}
+		} else {
+			msg.Metadata = cloneMetadata(msg.Metadata)
+		}
+		msg.Metadata["scheduleId"] = item.ID
+		delivered := s.deliver.ExecuteTargeted(ctx, &msg, item.Target)
+		s.sent.Inc()
+		slog.Info("scheduled broadcast sent", slog.String("id", item.ID), slog.String("topic", msg.Topic), slog.Int("delivered", delivered), slog.Duration("late", now.Sub(item.NextRun)))
+	}
+}
+
+func (s *Scheduler) load() ([]port.ScheduledBroadcast, error) {
+	if s.opts.File == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(s.opts.File)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scheduled broadcasts: %w", err)
+	}
+	if len(strings.TrimSpace(string(raw))) == 0 {
+		return nil, nil
+	}
+	var jobs []port.ScheduledBroadcast
+	if err := json.Unmarshal(raw, &jobs); err != nil {
+		return nil, fmt.Errorf("parse scheduled broadcasts: %w", err)
+	}
+	valid := jobs[:0]
+	for _, item := range jobs {
+		if item.ID == "" || item.Message == nil {
+			continue
+		}
+		valid = append(valid, item)
+	}
+	return valid, nil
+}
+
+// runsBefore orders jobs by next run; on ties one-shot jobs go before recurring ones, then by id,
+// so List and deliveries are deterministic.
+func runsBefore(a, b port.ScheduledBroadcast) bool {
+	if !a.NextRun.Equal(b.NextRun) {
+		return a.NextRun.Before(b.NextRun)
+	}
+	if (a.Cron == "") != (b.Cron == "") {
+		return a.Cron == ""
+	}
+	return a.ID < b.ID
+}
+
+// persistLocked rewrites the jobs file atomically. Callers hold s.mu.
+func (s *Scheduler) persistLocked() error {
+	if s.opts.File == "" {
+		return nil
+	}
+	jobs := make([]port.ScheduledBroadcast, 0, len(s.jobs))
+	for _, item := range s.jobs {
+		jobs = append(jobs, item.ScheduledBroadcast)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	raw, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.opts.File), 0o755); err != nil {
+		return fmt.Errorf("create scheduler dir: %w", err)
+	}
+	tmp := s.opts.File + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write scheduled broadcasts: %w", err)
+	}
+	return os.Rename(tmp, s.opts.File)
+}
+
+func cloneMetadata(metadata map[string]string) map[string]string {
+	cloned := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		cloned[key] = value
+	}
+	return cloned
+}
+
+func newJobID() string {
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "sch-" + hex.EncodeToString(raw[:])
+}
+
+var _ port.BroadcastScheduler = (*Scheduler)(nil)
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+)
+
+type recordingDeliverer struct {
+	messages []*domain.Message
+	targets  []port.BroadcastTarget
+}
+
+func (d *recordingDeliverer) ExecuteTargeted(_ context.Context, msg *domain.Message, target port.BroadcastTarget) int {
+	d.messages = append(d.messages, msg)
+	d.targets = append(d.targets, target)
+	return 1
+}
+
+func TestScheduler_PersistsDeliversAndCancels(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "scheduled.json")
+	now := time.Date(2026, 3, 2, 21, 40, 0, 0, time.UTC)
+	deliverer := &recordingDeliverer{}
+	clock := func() time.Time { return now }
+	s, err := New(deliverer, Options{File: file, Location: time.UTC, Now: clock})
+	if err != nil {
+		t.Fatalf("scheduler: %v", err)
+	}
+
+	msg := &domain.Message{Topic: "restaurants.announcement", Entity: "restaurants", Action: "announcement", Data: map[string]any{"text": "kitchen closes in 15 minutes"}}
+	once, err := s.Schedule(port.ScheduledBroadcast{Message: msg, DeliverAt: now.Add(5 * time.Minute), Target: port.BroadcastTarget{RestaurantIDs: []string{"rest-1"}}})
+	if err != nil {
+		t.Fatalf("schedule once: %v", err)
+	}
+	daily, err := s.Schedule(port.ScheduledBroadcast{Message: msg, Cron: "45 21 * * *"})
+	if err != nil {
+		t.Fatalf("schedule cron: %v", err)
+	}
+	if want := time.Date(2026, 3, 2, 21, 45, 0, 0, time.UTC); !daily.NextRun.Equal(want) {
+		t.Fatalf("cron next run = %s, want %s", daily.NextRun, want)
+	}
+	if _, err := s.Schedule(port.ScheduledBroadcast{Message: msg, DeliverAt: now.Add(-time.Minute)}); !errors.Is(err, port.ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule for past deliverAt, got %v", err)
+	}
+
+	// A restart restores both jobs from the file.
+	restored, err := New(deliverer, Options{File: file, Location: time.UTC, Now: clock})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if jobs := restored.List(); len(jobs) != 2 || jobs[0].ID != once.ID {
+		t.Fatalf("unexpected restored jobs: %+v", jobs)
+	}
+
+	now = now.Add(5 * time.Minute)
+	restored.runDue(context.Background())
+	if len(deliverer.messages) != 2 {
+		t.Fatalf("expected both jobs due, got %d deliveries", len(deliverer.messages))
+	}
+	if deliverer.messages[0].Metadata["scheduleId"] == "" || msg.Metadata != nil {
+		t.Fatalf("scheduleId must be added to a copy of the message: %+v", deliverer.messages[0].Metadata)
+	}
+	jobs := restored.List()
+	if len(jobs) != 1 || jobs[0].ID != daily.ID || jobs[0].Runs != 1 {
+		t.Fatalf("expected only the recurring job left, got %+v", jobs)
+	}
+	if want := time.Date(2026, 3, 3, 21, 45, 0, 0, time.UTC); !jobs[0].NextRun.Equal(want) {
+		t.Fatalf("recurring next run = %s, want %s", jobs[0].NextRun, want)
+	}
+
+	if err := restored.Cancel(daily.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := restored.Cancel(daily.ID); !errors.Is(err, port.ErrScheduleNotFound) {
+		t.Fatalf("expected ErrScheduleNotFound, got %v", err)
+	}
+	again, err := New(deliverer, Options{File: file, Location: time.UTC, Now: clock})
+	if err != nil || len(again.List()) != 0 {
+		t.Fatalf("expected empty file after cancel, got %v (%v)", again.List(), err)
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	from := time.Date(2026, 3, 6, 10, 7, 30, 0, time.UTC) // Friday
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 3, 6, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)},
+		{"30 22 * * 0,6", time.Date(2026, 3, 7, 22, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90m", time.Date(2026, 3, 6, 11, 37, 30, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		rec, err := parseRecurrence(tc.expr, time.UTC)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.expr, err)
+		}
+		if got := rec.next(from); !got.Equal(tc.want) {
+			t.Fatalf("%s: next = %s, want %s", tc.expr, got, tc.want)
+		}
+	}
+	for _, invalid := range []string{"* * *", "61 * * * *", "@every 10s", "a * * * *"} {
+		if _, err := parseRecurrence(invalid, time.UTC); err == nil {
+			t.Fatalf("%q should be rejected", invalid)
+		}
+	}
+}
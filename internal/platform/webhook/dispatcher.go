@@ -0,0 +1,347 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/shared/metrics"
+)
+
+// Headers sent with every webhook POST. The signature is hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	HeaderSignature = "X-MesaYa-Signature"
+	HeaderTimestamp = "X-MesaYa-Timestamp"
+	HeaderDelivery  = "X-MesaYa-Delivery"
+	HeaderTopic     = "X-MesaYa-Topic"
+)
+
+const (
+	endpointQueueSize  = 1024
+	retryCheckInterval = 500 * time.Millisecond
+)
+
+// Options configures retries and the on-disk queue.
+type Options struct {
+	// QueueDir persists pending deliveries across restarts. Empty keeps them in memory.
+	QueueDir string
+	// MaxAttempts before a delivery is moved to the dead-letter directory.
+	MaxAttempts int
+	// InitialBackoff doubles after every failed attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Timeout bounds each POST.
+	Timeout time.Duration
+	// Client overrides the HTTP client (tests).
+	Client *http.Client
+}
+
+// Dispatcher POSTs messages to the webhook endpoints subscribed to their topic. Registered as a
+// topic handler it receives every consumed event, catch-up included; as a port.Broadcaster
+// observer it only forwards messages that did not come from a consumer (HTTP or scheduled
+// broadcasts), so consumed events are never posted twice.
+type Dispatcher struct {
+	endpoints []*endpointWorker
+	store     *queueStore
+	opts      Options
+	client    *http.Client
+
+	mu      sync.Mutex
+	retries []*delivery
+}
+
+type endpointWorker struct {
+	Endpoint
+	queue     chan *delivery
+	delivered *metrics.Counter
+	failed    *metrics.Counter
+	retried   *metrics.Counter
+	dead      *metrics.Counter
+	latencyMs *metrics.Counter
+}
+
+// NewDispatcher prepares the workers and restores the deliveries left in opts.QueueDir.
+// Call Start to begin sending.
+func NewDispatcher(endpoints []Endpoint, opts Options) (*Dispatcher, error) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = time.Second
+	}
+	if opts.MaxBackoff < opts.InitialBackoff {
+		opts.MaxBackoff = 5 * time.Minute
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	store, err := newQueueStore(opts.QueueDir)
+	if err != nil {
+		return nil, err
+	}
+	d := &Dispatcher{store: store, opts: opts, client: client}
+	for _, endpoint := range endpoints {
+		d.endpoints = append(d.endpoints, &endpointWorker{
+			Endpoint:  endpoint,
+			queue:     make(chan *delivery, endpointQueueSize),
+			delivered: metrics.Default.Counter("webhook_delivered_total", "endpoint", endpoint.Name),
+			failed:    metrics.Default.Counter("webhook_failed_attempts_total", "endpoint", endpoint.Name),
+			retried:   metrics.Default.Counter("webhook_retries_total", "endpoint", endpoint.Name),
+			dead:      metrics.Default.Counter("webhook_dead_letters_total", "endpoint", endpoint.Name),
+			latencyMs: metrics.Default.Counter("webhook_latency_ms_total", "endpoint", endpoint.Name),
+		})
+	}
+
+	pending, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedAt.Before(pending[j].CreatedAt) })
+	for _, item := range pending {
+		if d.worker(item.Endpoint) == nil {
+			slog.Warn("webhook delivery for unknown endpoint dropped", slog.String("endpoint", item.Endpoint), slog.String("id", item.ID))
+			d.store.remove(item)
+			continue
+		}
+		d.retries = append(d.retries, item)
+	}
+	slog.Info("webhook dispatcher ready", slog.Int("endpoints", len(d.endpoints)), slog.Int("restored", len(d.retries)), slog.String("queueDir", opts.QueueDir))
+	return d, nil
+}
+
+// Start runs one sender per endpoint plus the retry scheduler until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	for _, worker := range d.endpoints {
+		go d.run(ctx, worker)
+	}
+	ticker := time.NewTicker(retryCheckInterval)
+	defer ticker.Stop()
+	for {
+		d.flushDueRetries()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Topic subscribes the dispatcher to every consumed event.
+func (d *Dispatcher) Topic() string { return "*" }
+
+// Handle queues a consumed event for every endpoint subscribed to its topic.
+func (d *Dispatcher) Handle(_ context.Context, msg *domain.Message) error {
+	d.enqueue(msg)
+	return nil
+}
+
+// Broadcast queues msg unless it comes from a consumer (see port.SourceTopic), in which case
+// Handle already queued the event.
+func (d *Dispatcher) Broadcast(ctx context.Context, msg *domain.Message) {
+	if port.SourceTopic(ctx) != "" {
+		return
+	}
+	d.enqueue(msg)
+}
+
+func (d *Dispatcher) enqueue(msg *domain.Message) {
+	if msg == nil {
+		return
+	}
+	var body []byte
+	for _, worker := range d.endpoints {
+		if !worker.Matches(msg.Topic) {
+			continue
+		}
+		if body == nil {
+			encoded, err := json.Marshal(msg)
+			if err != nil {
+				slog.Error("webhook marshal error", slog.String("topic", msg.Topic), slog.Any("error", err))
+				return
+			}
+			body = encoded
+		}
+		now := time.Now().UTC()
+		item := &delivery{
+			ID:          newDeliveryID(),
+			Endpoint:    worker.Name,
+			Topic:       msg.Topic,
+			Body:        body,
+			NextAttempt: now,
+			CreatedAt:   now,
+		}
+		if err := d.store.save(item); err != nil {
+			slog.Error("webhook queue persist failed", slog.String("endpoint", worker.Name), slog.Any("error", err))
+		}
+		select {
+		case worker.queue <- item:
+		default:
+			d.scheduleRetry(item)
+		}
+	}
+}
+
+func (d *Dispatcher) run(ctx context.Context, worker *endpointWorker) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-worker.queue:
+			d.attempt(ctx, worker, item)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, worker *endpointWorker, item *delivery) {
+	item.Attempts++
+	started := time.Now()
+	retryable, err := d.post(ctx, worker.Endpoint, item)
+	worker.latencyMs.Add(time.Since(started).Milliseconds())
+	if err == nil {
+		worker.delivered.Inc()
+		d.store.remove(item)
+		slog.Debug("webhook delivered", slog.String("endpoint", worker.Name), slog.String("topic", item.Topic), slog.String("id", item.ID), slog.Int("attempts", item.Attempts))
+		return
+	}
+
+	worker.failed.Inc()
+	item.LastError = err.Error()
+	if !retryable || item.Attempts >= d.opts.MaxAttempts {
+		worker.dead.Inc()
+		if storeErr := d.store.deadLetter(item); storeErr != nil {
+			slog.Error("webhook dead letter persist failed", slog.String("endpoint", worker.Name), slog.Any("error", storeErr))
+		}
+		slog.Error("webhook delivery abandoned", slog.String("endpoint", worker.Name), slog.String("topic", item.Topic), slog.String("id", item.ID), slog.Int("attempts", item.Attempts), slog.Any("error", err))
+		return
+	}
+
+	item.NextAttempt = time.Now().Add(d.backoff(item.Attempts))
+	if storeErr := d.store.save(item); storeErr != nil {
+		slog.Error("webhook queue persist failed", slog.String("endpoint", worker.Name), slog.Any("error", storeErr))
+	}
+	worker.retried.Inc()
+	slog.Warn("webhook delivery failed, retrying", slog.String("endpoint", worker.Name), slog.String("topic", item.Topic), slog.String("id", item.ID), slog.Int("attempts", item.Attempts), slog.Time("nextAttempt", item.NextAttempt), slog.Any("error", err))
+	d.scheduleRetry(item)
+}
+
+// post sends the delivery and reports whether a failure is worth retrying (network errors,
+// 408, 429 and 5xx responses).
+func (d *Dispatcher) post(ctx context.Context, endpoint Endpoint, item *delivery) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(item.Body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(endpoint.Secret, timestamp, item.Body))
+	req.Header.Set(HeaderDelivery, item.ID)
+	req.Header.Set(HeaderTopic, item.Topic)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("unexpected status %d", resp.StatusCode)
+}
+
+// Sign computes the hex HMAC-SHA256 of timestamp + "." + body, as sent in HeaderSignature.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	wait := float64(d.opts.InitialBackoff) * math.Pow(2, float64(attempts-1))
+	if wait > float64(d.opts.MaxBackoff) {
+		wait = float64(d.opts.MaxBackoff)
+	}
+	// ±20% jitter so endpoints recovering from an outage are not hit in lockstep.
+	wait *= 0.8 + 0.4*mathrand.Float64()
+	return time.Duration(wait)
+}
+
+func (d *Dispatcher) scheduleRetry(item *delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.retries = append(d.retries, item)
+}
+
+func (d *Dispatcher) flushDueRetries() {
+	now := time.Now()
+	d.mu.Lock()
+	remaining := d.retries[:0]
+	var due []*delivery
+	for _, item := range d.retries {
+		if item.NextAttempt.After(now) {
+			remaining = append(remaining, item)
+			continue
+		}
+		due = append(due, item)
+	}
+	d.retries = remaining
+	d.mu.Unlock()
+
+	for _, item := range due {
+		worker := d.worker(item.Endpoint)
+		if worker == nil {
+			continue
+		}
+		select {
+		case worker.queue <- item:
+		default:
+			d.scheduleRetry(item)
+		}
+	}
+}
+
+func (d *Dispatcher) worker(name string) *endpointWorker {
+	for _, worker := range d.endpoints {
+		if worker.Name == name {
+			return worker
+		}
+	}
+	return nil
+}
+
+func newDeliveryID() string {
+	var raw [12]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return strconv.FormatInt(time.Now().UnixMilli(), 36) + "-" + hex.EncodeToString(raw[:])
+}
+
+var _ port.Broadcaster = (*Dispatcher)(nil)
+var _ port.TopicHandler = (*Dispatcher)(nil)
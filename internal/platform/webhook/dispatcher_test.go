@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mesaYaWs/internal/modules/realtime/application/port"
+	"mesaYaWs/internal/modules/realtime/application/usecase"
+	"mesaYaWs/internal/modules/realtime/domain"
+	"mesaYaWs/internal/modules/realtime/infrastructure"
+)
+
+func TestDispatcher_SignsRetriesAndClearsQueue(t *testing.T) {
+	var calls atomic.Int32
+	signatures := make(chan bool, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := "sha256=" + Sign("s3cret", r.Header.Get(HeaderTimestamp), body)
+		signatures <- r.Header.Get(HeaderSignature) == expected
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	dispatcher, err := NewDispatcher([]Endpoint{{Name: "n8n", URL: server.URL, Topics: []string{"reservations.*"}, Secret: "s3cret"}}, Options{
+		QueueDir:       dir,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("dispatcher: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go dispatcher.Start(ctx)
+
+	dispatcher.Broadcast(ctx, &domain.Message{Topic: "tables.updated"})
+	dispatcher.Broadcast(ctx, &domain.Message{Topic: "reservations.created", ResourceID: "r-1"})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case ok := <-signatures:
+			if !ok {
+				t.Fatalf("attempt %d: invalid signature", i+1)
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for attempt %d", i+1)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		entries, _ := os.ReadDir(filepath.Join(dir, "pending"))
+		if len(entries) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pending queue not cleared: %d entries", len(entries))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected 2 attempts (tables.updated not subscribed), got %d", got)
+	}
+}
+
+func TestDispatcher_ConsumedEventsAreQueuedOnce(t *testing.T) {
+	dir := t.TempDir()
+	dispatcher, err := NewDispatcher([]Endpoint{{Name: "n8n", URL: "http://127.0.0.1:0", Topics: []string{"reservations.*"}}}, Options{QueueDir: dir})
+	if err != nil {
+		t.Fatalf("dispatcher: %v", err)
+	}
+	registry := infrastructure.NewHandlerRegistry()
+	registry.Register(dispatcher)
+	broadcastUC := usecase.NewBroadcastUseCase(&noopBroadcaster{}, dispatcher)
+
+	consumed := &domain.Message{Topic: "reservations.created", ResourceID: "r-1"}
+	ctx := port.WithSourceTopic(usecase.WithCatchUp(context.Background()), "mesa-ya.reservations.events")
+	if err := registry.DispatchFrom(ctx, "mesa-ya.reservations.events", consumed); err != nil {
+		t.Fatalf("dispatch: %v", err)
+	}
+	// The entity stream handler broadcasts the same event: the observer must skip it.
+	broadcastUC.Execute(port.WithSourceTopic(context.Background(), "mesa-ya.reservations.events"), consumed)
+	// HTTP broadcasts have no source topic and reach webhooks through the observer.
+	broadcastUC.Execute(context.Background(), &domain.Message{Topic: "reservations.updated", ResourceID: "r-1"})
+
+	entries, err := os.ReadDir(filepath.Join(dir, "pending"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 queued deliveries (catch-up event and HTTP broadcast), got %d", len(entries))
+	}
+}
+
+type noopBroadcaster struct{}
+
+func (noopBroadcaster) Broadcast(context.Context, *domain.Message) {}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Endpoint is one webhook subscription: messages whose topic matches any pattern are POSTed to URL.
+type Endpoint struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Topics []string `json:"topics"`
+	// Secret signs the body (HMAC-SHA256). SecretEnv names an environment variable holding it,
+	// so the subscriptions file can be committed without credentials.
+	Secret    string `json:"secret,omitempty"`
+	SecretEnv string `json:"secretEnv,omitempty"`
+}
+
+// Matches reports whether topic matches one of the endpoint patterns (glob syntax).
+func (e Endpoint) Matches(topic string) bool {
+	for _, pattern := range e.Topics {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == topic {
+			return true
+		}
+		if ok, err := path.Match(pattern, topic); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadEndpoints reads the JSON array of subscriptions from file and validates it.
+func LoadEndpoints(file string) ([]Endpoint, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read webhook config: %w", err)
+	}
+	var endpoints []Endpoint
+	if err := json.Unmarshal(raw, &endpoints); err != nil {
+		return nil, fmt.Errorf("parse webhook config: %w", err)
+	}
+	seen := make(map[string]struct{}, len(endpoints))
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+		endpoint.Name = strings.TrimSpace(endpoint.Name)
+		endpoint.URL = strings.TrimSpace(endpoint.URL)
+		if endpoint.Name == "" {
+			return nil, fmt.Errorf("webhook #%d: name is required", i+1)
+		}
+		if _, dup := seen[endpoint.Name]; dup {
+			return nil, fmt.Errorf("webhook %s: duplicated name", endpoint.Name)
+		}
+		seen[endpoint.Name] = struct{}{}
+		parsed, err := url.Parse(endpoint.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("webhook %s: invalid url %q", endpoint.Name, endpoint.URL)
+		}
+		if len(endpoint.Topics) == 0 {
+			return nil, fmt.Errorf("webhook %s: at least one topic pattern is required", endpoint.Name)
+		}
+		for _, pattern := range endpoint.Topics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("webhook %s: invalid topic pattern %q", endpoint.Name, pattern)
+			}
+		}
+		if endpoint.SecretEnv != "" {
+			endpoint.Secret = strings.TrimSpace(os.Getenv(endpoint.SecretEnv))
+		}
+		if endpoint.Secret == "" {
+			return nil, errors.New("webhook " + endpoint.Name + ": secret (or secretEnv) is required")
+		}
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,121 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// delivery is one pending POST. It is persisted until it succeeds or is dead-lettered so
+// restarts do not lose notifications.
+type delivery struct {
+	ID          string          `json:"id"`
+	Endpoint    string          `json:"endpoint"`
+	Topic       string          `json:"topic"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"nextAttempt"`
+	LastError   string          `json:"lastError,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+}
+
+// queueStore keeps one JSON file per pending delivery under dir/pending and moves exhausted
+// ones to dir/dead. An empty dir keeps the queue in memory only.
+type queueStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func newQueueStore(dir string) (*queueStore, error) {
+	store := &queueStore{dir: strings.TrimSpace(dir)}
+	if store.dir == "" {
+		return store, nil
+	}
+	for _, sub := range []string{"pending", "dead"} {
+		if err := os.MkdirAll(filepath.Join(store.dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("create webhook queue dir: %w", err)
+		}
+	}
+	return store, nil
+}
+
+func (s *queueStore) save(d *delivery) error {
+	if s.dir == "" {
+		return nil
+	}
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target := s.file("pending", d.ID)
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("write webhook delivery: %w", err)
+	}
+	return os.Rename(tmp, target)
+}
+
+func (s *queueStore) remove(d *delivery) {
+	if s.dir == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = os.Remove(s.file("pending", d.ID))
+}
+
+func (s *queueStore) deadLetter(d *delivery) error {
+	if s.dir == "" {
+		return nil
+	}
+	raw, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.WriteFile(s.file("dead", d.ID), raw, 0o644); err != nil {
+		return fmt.Errorf("write webhook dead letter: %w", err)
+	}
+	_ = os.Remove(s.file("pending", d.ID))
+	return nil
+}
+
+// load returns the deliveries left pending by a previous run.
+func (s *queueStore) load() ([]*delivery, error) {
+	if s.dir == "" {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(filepath.Join(s.dir, "pending"))
+	if err != nil {
+		return nil, fmt.Errorf("read webhook queue: %w", err)
+	}
+	deliveries := make([]*delivery, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(s.dir, "pending", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read webhook delivery %s: %w", entry.Name(), err)
+		}
+		var d delivery
+		if err := json.Unmarshal(raw, &d); err != nil {
+			continue
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, nil
+}
+
+func (s *queueStore) file(sub, id string) string {
+	return filepath.Join(s.dir, sub, id+".json")
+}
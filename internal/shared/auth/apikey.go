@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers accepted by KeySet.Verify.
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderKeyID     = "X-MesaYa-Key-Id"
+	HeaderTimestamp = "X-MesaYa-Timestamp"
+	HeaderSignature = "X-MesaYa-Signature"
+)
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrStaleSignature     = errors.New("signature timestamp outside tolerance")
+	ErrReplayedSignature  = errors.New("signature already used")
+)
+
+// APIKey is a publisher credential. It authenticates either with the raw key (X-API-Key) or
+// with an HMAC signature computed with Secret, and is limited to the listed topics
+// (glob patterns) and entities. Empty scopes allow everything.
+type APIKey struct {
+	ID        string   `json:"id"`
+	Key       string   `json:"apiKey,omitempty"`
+	KeyEnv    string   `json:"apiKeyEnv,omitempty"`
+	Secret    string   `json:"secret,omitempty"`
+	SecretEnv string   `json:"secretEnv,omitempty"`
+	Topics    []string `json:"topics,omitempty"`
+	Entities  []string `json:"entities,omitempty"`
+}
+
+// Allows reports whether the key may publish msg topic/entity.
+func (k *APIKey) Allows(topic, entity string) bool {
+	if k == nil {
+		return false
+	}
+	if len(k.Entities) > 0 {
+		allowed := false
+		for _, candidate := range k.Entities {
+			if strings.EqualFold(strings.TrimSpace(candidate), strings.TrimSpace(entity)) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if len(k.Topics) == 0 {
+		return true
+	}
+	for _, pattern := range k.Topics {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == topic {
+			return true
+		}
+		if ok, err := path.Match(pattern, topic); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// KeySet verifies publisher credentials. Signed requests carry X-MesaYa-Key-Id,
+// X-MesaYa-Timestamp (unix seconds) and X-MesaYa-Signature = "sha256=" +
+// hex(HMAC-SHA256(secret, timestamp + "." + body)); each signature is accepted once within
+// the tolerance window.
+type KeySet struct {
+	keys      []*APIKey
+	tolerance time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// LoadKeySet reads a JSON array of APIKey from file.
+func LoadKeySet(file string, tolerance time.Duration) (*KeySet, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read api keys: %w", err)
+	}
+	var keys []*APIKey
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, fmt.Errorf("parse api keys: %w", err)
+	}
+	return NewKeySet(keys, tolerance)
+}
+
+// NewKeySet validates keys, resolving *Env references from the environment.
+func NewKeySet(keys []*APIKey, tolerance time.Duration) (*KeySet, error) {
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+	ids := make(map[string]struct{}, len(keys))
+	for i, key := range keys {
+		if key == nil {
+			return nil, fmt.Errorf("api key #%d is empty", i+1)
+		}
+		key.ID = strings.TrimSpace(key.ID)
+		if key.ID == "" {
+			return nil, fmt.Errorf("api key #%d: id is required", i+1)
+		}
+		if _, dup := ids[key.ID]; dup {
+			return nil, fmt.Errorf("api key %s: duplicated id", key.ID)
+		}
+		ids[key.ID] = struct{}{}
+		if key.KeyEnv != "" {
+			key.Key = os.Getenv(key.KeyEnv)
+		}
+		if key.SecretEnv != "" {
+			key.Secret = os.Getenv(key.SecretEnv)
+		}
+		key.Key = strings.TrimSpace(key.Key)
+		key.Secret = strings.TrimSpace(key.Secret)
+		if key.Key == "" && key.Secret == "" {
+			return nil, fmt.Errorf("api key %s: apiKey or secret is required", key.ID)
+		}
+	}
+	return &KeySet{keys: keys, tolerance: tolerance, now: time.Now, seen: make(map[string]time.Time)}, nil
+}
+
+// Len returns the number of configured keys.
+func (s *KeySet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.keys)
+}
+
+// Verify authenticates a request from its headers and raw body.
+func (s *KeySet) Verify(header func(string) string, body []byte) (*APIKey, error) {
+	if s == nil || len(s.keys) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	if signature := strings.TrimSpace(header(HeaderSignature)); signature != "" {
+		return s.verifySignature(strings.TrimSpace(header(HeaderKeyID)), strings.TrimSpace(header(HeaderTimestamp)), signature, body)
+	}
+	if apiKey := strings.TrimSpace(header(HeaderAPIKey)); apiKey != "" {
+		return s.verifyAPIKey(apiKey)
+	}
+	return nil, ErrMissingCredentials
+}
+
+func (s *KeySet) verifyAPIKey(candidate string) (*APIKey, error) {
+	sum := sha256.Sum256([]byte(candidate))
+	var match *APIKey
+	for _, key := range s.keys {
+		if key.Key == "" {
+			continue
+		}
+		keySum := sha256.Sum256([]byte(key.Key))
+		if subtle.ConstantTimeCompare(sum[:], keySum[:]) == 1 {
+			match = key
+		}
+	}
+	if match == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return match, nil
+}
+
+func (s *KeySet) verifySignature(keyID, timestamp, signature string, body []byte) (*APIKey, error) {
+	var key *APIKey
+	for _, candidate := range s.keys {
+		if candidate.ID == keyID && candidate.Secret != "" {
+			key = candidate
+			break
+		}
+	}
+	if key == nil || timestamp == "" {
+		return nil, ErrInvalidCredentials
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	now := s.now()
+	signedAt := time.Unix(unix, 0)
+	if signedAt.Before(now.Add(-s.tolerance)) || signedAt.After(now.Add(s.tolerance)) {
+		return nil, ErrStaleSignature
+	}
+	provided, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return nil, ErrInvalidCredentials
+	}
+	// Key on the decoded MAC so case or prefix variants of the same signature count as replays.
+	if !s.markSeen(key.ID+":"+hex.EncodeToString(provided), now) {
+		return nil, ErrReplayedSignature
+	}
+	return key, nil
+}
+
+// markSeen records the signature and reports false when it was already used. Entries older
+// than twice the tolerance can no longer pass the timestamp check; they are swept at most
+// once per tolerance window.
+func (s *KeySet) markSeen(signature string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastSweep) > s.tolerance {
+		for seen, at := range s.seen {
+			if now.Sub(at) > 2*s.tolerance {
+				delete(s.seen, seen)
+			}
+		}
+		s.lastSweep = now
+	}
+	if _, ok := s.seen[signature]; ok {
+		return false
+	}
+	s.seen[signature] = now
+	return true
+}
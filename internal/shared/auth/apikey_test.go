@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signedHeaders(keyID, secret string, at time.Time, body []byte) http.Header {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set(HeaderKeyID, keyID)
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestKeySetVerify_SignatureReplayAndScope(t *testing.T) {
+	keys, err := NewKeySet([]*APIKey{
+		{ID: "n8n", Secret: "s3cret", Topics: []string{"payment.*"}, Entities: []string{"payment"}},
+		{ID: "ops", Key: "k-ops"},
+	}, time.Minute)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	keys.now = func() time.Time { return now }
+	body := []byte(`{"event":"payment.succeeded"}`)
+
+	header := signedHeaders("n8n", "s3cret", now, body)
+	key, err := keys.Verify(header.Get, body)
+	if err != nil || key.ID != "n8n" {
+		t.Fatalf("expected n8n key, got %v (%v)", key, err)
+	}
+	if !key.Allows("payment.succeeded", "payment") || key.Allows("reservations.created", "payment") || key.Allows("payment.succeeded", "reservations") {
+		t.Fatalf("unexpected scope evaluation")
+	}
+	if _, err := keys.Verify(header.Get, body); !errors.Is(err, ErrReplayedSignature) {
+		t.Fatalf("expected replay rejection, got %v", err)
+	}
+	variant := header.Clone()
+	variant.Set(HeaderSignature, strings.ToUpper(strings.TrimPrefix(header.Get(HeaderSignature), "sha256=")))
+	if _, err := keys.Verify(variant.Get, body); !errors.Is(err, ErrReplayedSignature) {
+		t.Fatalf("expected replay rejection for an uppercase unprefixed signature, got %v", err)
+	}
+	if _, err := keys.Verify(header.Get, []byte(`{"event":"tampered"}`)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected tampered body rejection, got %v", err)
+	}
+	stale := signedHeaders("n8n", "s3cret", now.Add(-2*time.Minute), body)
+	if _, err := keys.Verify(stale.Get, body); !errors.Is(err, ErrStaleSignature) {
+		t.Fatalf("expected stale signature rejection, got %v", err)
+	}
+
+	apiKey := http.Header{}
+	apiKey.Set(HeaderAPIKey, "k-ops")
+	if key, err := keys.Verify(apiKey.Get, nil); err != nil || key.ID != "ops" || !key.Allows("anything", "any") {
+		t.Fatalf("expected unscoped ops key, got %v (%v)", key, err)
+	}
+	if _, err := keys.Verify(http.Header{}.Get, nil); !errors.Is(err, ErrMissingCredentials) {
+		t.Fatalf("expected missing credentials, got %v", err)
+	}
+}
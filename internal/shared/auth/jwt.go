@@ -18,6 +18,8 @@ var (
 type Claims struct {
 	SessionID string   `json:"sid"`
 	Roles     []string `json:"roles"`
+	// Locale is the preferred language of the user (e.g. "es", "en-US"), when the token has it.
+	Locale string `json:"locale,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -0,0 +1,264 @@
+package entities
+
+var (
+	allRoles   = []string{"ADMIN", "OWNER", "USER"}
+	staffRoles = []string{"ADMIN", "OWNER"}
+	adminRoles = []string{"ADMIN"}
+)
+
+var defaultRegistry = mustNew(defaultDefinitions())
+
+// Default returns the built-in registry, used when ENTITY_REGISTRY_FILE is not set.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func mustNew(definitions []Definition) *Registry {
+	registry, err := New(definitions)
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+func defaultDefinitions() []Definition {
+	return []Definition{
+		{
+			Name:        "restaurants",
+			Aliases:     []string{"restaurant"},
+			Roles:       allRoles,
+			ResourceKey: "restaurantId",
+			Topics:      []string{"mesa-ya.restaurants.events"},
+			Commands:    Commands{List: []string{"list_restaurants"}, Detail: []string{"get_restaurant"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/restaurants", Detail: "/api/v1/restaurants/{id}"},
+				AudienceOwner:   {List: "/api/v1/restaurants/me"},
+			},
+		},
+		{
+			Name:        "tables",
+			Aliases:     []string{"table"},
+			Roles:       allRoles,
+			ResourceKey: "tableId",
+			// selecting/released are ephemeral events handled via WebSocket only
+			Topics:   []string{"mesa-ya.tables.events"},
+			Commands: Commands{List: []string{"list_tables"}, Detail: []string{"get_table"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/tables",
+					Detail:  "/api/v1/tables/{id}",
+					Filters: map[string]string{"restaurantid": "restaurantId", "sectionid": "sectionId"},
+				},
+				AudienceOwner: {List: "/api/v1/tables/section/{section}"},
+			},
+		},
+		{
+			Name:        "reservations",
+			Aliases:     []string{"reservation"},
+			Roles:       allRoles,
+			ResourceKey: "reservationId",
+			Topics:      []string{"mesa-ya.reservations.events"},
+			Commands:    Commands{List: []string{"list_reservations"}, Detail: []string{"get_reservation"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/reservations",
+					Detail:  "/api/v1/reservations/{id}",
+					Filters: map[string]string{"status": "status", "restaurantid": "restaurantId", "date": "date"},
+				},
+				AudienceOwner: {
+					SectionQuery: "restaurantId",
+					Filters:      map[string]string{"status": "status", "restaurantid": "restaurantId", "ownerid": "", "date": "date"},
+				},
+			},
+		},
+		{
+			Name:        "reviews",
+			Aliases:     []string{"review"},
+			Roles:       allRoles,
+			ResourceKey: "reviewId",
+			Topics:      []string{"mesa-ya.reviews.events"},
+			Commands:    Commands{List: []string{"list_reviews"}, Detail: []string{"get_review"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/reviews", Detail: "/api/v1/reviews/{id}"},
+			},
+		},
+		{
+			Name:        "sections",
+			Aliases:     []string{"section"},
+			Roles:       allRoles,
+			ResourceKey: "sectionId",
+			Topics:      []string{"mesa-ya.sections.events"},
+			Commands:    Commands{List: []string{"list_sections"}, Detail: []string{"get_section"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/sections",
+					Detail:  "/api/v1/sections/{id}",
+					Filters: map[string]string{"restaurantid": "restaurantId"},
+				},
+				AudienceOwner: {List: "/api/v1/sections/restaurant/{section}"},
+			},
+		},
+		{
+			Name:        "objects",
+			Aliases:     []string{"object"},
+			Roles:       allRoles,
+			ResourceKey: "objectId",
+			Topics:      []string{"mesa-ya.objects.events"},
+			Commands:    Commands{List: []string{"list_objects"}, Detail: []string{"get_object"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/objects", Detail: "/api/v1/objects/{id}"},
+			},
+		},
+		{
+			Name:        "menus",
+			Aliases:     []string{"menu"},
+			Roles:       allRoles,
+			ResourceKey: "menuId",
+			// Includes dishes as sub-entity (entity_subtype: 'menu' | 'dish')
+			Topics:   []string{"mesa-ya.menus.events"},
+			Commands: Commands{List: []string{"list_menus"}, Detail: []string{"get_menu"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/menus",
+					Detail:  "/api/v1/menus/{id}",
+					Filters: map[string]string{"restaurantid": "restaurantId"},
+				},
+			},
+		},
+		{
+			Name:        "dishes",
+			Aliases:     []string{"dish"},
+			Roles:       allRoles,
+			ResourceKey: "dishId",
+			Commands:    Commands{List: []string{"list_dishes"}, Detail: []string{"get_dish"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/dishes",
+					Detail:  "/api/v1/dishes/{id}",
+					Filters: map[string]string{"restaurantid": "restaurantId"},
+				},
+			},
+		},
+		{
+			Name:        "images",
+			Aliases:     []string{"image"},
+			Roles:       allRoles,
+			ResourceKey: "imageId",
+			Topics:      []string{"mesa-ya.images.events"},
+			Commands:    Commands{List: []string{"list_images"}, Detail: []string{"get_image"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/images",
+					Detail:  "/api/v1/images/{id}",
+					Filters: map[string]string{"entityid": "entityId"},
+				},
+			},
+		},
+		{
+			Name:        "section-objects",
+			Aliases:     []string{"section-object", "sectionobject", "sectionobjects"},
+			Roles:       staffRoles,
+			ResourceKey: "sectionObjectId",
+			Topics:      []string{"mesa-ya.section-objects.events"},
+			Commands:    Commands{List: []string{"list_section_objects"}, Detail: []string{"get_section_object"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/section-objects", Detail: "/api/v1/section-objects/{id}"},
+			},
+		},
+		{
+			Name:        "payments",
+			Aliases:     []string{"payment"},
+			Roles:       staffRoles,
+			ResourceKey: "paymentId",
+			Topics:      []string{"mesa-ya.payments.events"},
+			Commands:    Commands{List: []string{"list_payments"}, Detail: []string{"get_payment"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:   "/api/v1/payments",
+					Detail: "/api/v1/payments/{id}",
+					Filters: map[string]string{
+						"status":        "status",
+						"type":          "type",
+						"reservationid": "reservationId",
+						"restaurantid":  "restaurantId",
+						"startdate":     "startDate",
+						"enddate":       "endDate",
+						"minamount":     "minAmount",
+						"maxamount":     "maxAmount",
+					},
+				},
+				AudienceOwner: {List: "/api/v1/payments/restaurant/{section}"},
+			},
+		},
+		{
+			Name:        "schedules",
+			Aliases:     []string{"schedule"},
+			Roles:       allRoles,
+			ResourceKey: "scheduleId",
+			Commands:    Commands{List: []string{"list_schedules"}, Detail: []string{"get_schedule"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/restaurants/{section}/schedules", Detail: "/api/v1/schedules/{id}"},
+			},
+		},
+		{
+			Name:        "subscriptions",
+			Aliases:     []string{"subscription"},
+			Roles:       staffRoles,
+			ResourceKey: "subscriptionId",
+			// Includes plans as sub-entity (entity_subtype: 'subscription' | 'plan')
+			Topics:   []string{"mesa-ya.subscriptions.events"},
+			Commands: Commands{List: []string{"list_subscriptions"}, Detail: []string{"get_subscription"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/subscriptions", Detail: "/api/v1/subscriptions/{id}"},
+				AudienceOwner:   {List: "/api/v1/subscriptions/restaurant/{section}"},
+			},
+		},
+		{
+			Name:        "subscription-plans",
+			Aliases:     []string{"subscription-plan", "subscriptionplan", "subscriptionplans"},
+			Roles:       allRoles,
+			ResourceKey: "subscriptionPlanId",
+			Commands:    Commands{List: []string{"list_subscription_plans"}, Detail: []string{"get_subscription_plan"}},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {List: "/api/v1/subscription-plans", Detail: "/api/v1/subscription-plans/{id}"},
+			},
+		},
+		{
+			// Owners are users with the OWNER role: they share the users stream and endpoints.
+			Name:        "users",
+			Aliases:     []string{"user", "auth", "auth-user", "auth-users", "authuser", "authusers", "owner", "owners"},
+			Roles:       adminRoles,
+			ResourceKey: "userId",
+			Topics:      []string{"mesa-ya.auth.events"},
+			Commands: Commands{
+				List:   []string{"list_users", "list_auth_users", "list_owners"},
+				Detail: []string{"get_user", "get_auth_user", "get_owner"},
+			},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/users",
+					Detail:  "/api/v1/users/{id}",
+					Filters: map[string]string{"status": "status", "role": "role", "restaurantid": "restaurantId", "active": "active"},
+				},
+			},
+		},
+		{
+			Name:        "owner-upgrades",
+			Aliases:     []string{"owner-upgrade", "ownerupgrade", "ownerupgrades"},
+			Roles:       adminRoles,
+			ResourceKey: "ownerUpgradeId",
+			Topics:      []string{"mesa-ya.owner-upgrade.events"},
+			Commands: Commands{
+				List:   []string{"list_owner_upgrades", "list_owner_upgrade_requests"},
+				Detail: []string{"get_owner_upgrade", "get_owner_upgrade_request"},
+			},
+			REST: map[string]RESTEndpoint{
+				AudienceDefault: {
+					List:    "/api/v1/owner-upgrades",
+					Detail:  "/api/v1/owner-upgrades/{id}",
+					Filters: map[string]string{"status": "status", "userid": "userId"},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,265 @@
+// Package entities is the single source of truth about the domain entities exposed by the
+// realtime service: their aliases, the roles that may subscribe to them, the REST paths used
+// for snapshots per audience, the metadata key of their resource id, their Kafka topics and the
+// websocket command names that list or fetch them.
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// REST audiences accepted in Definition.REST. Default applies to every audience; the others
+// override its non-empty fields.
+const (
+	AudienceDefault = "default"
+	AudienceAdmin   = "admin"
+	AudienceOwner   = "owner"
+	AudienceUser    = "user"
+)
+
+// Path placeholders of RESTEndpoint.List and RESTEndpoint.Detail.
+const (
+	SectionPlaceholder  = "{section}"
+	ResourcePlaceholder = "{id}"
+)
+
+// Definition describes one canonical entity.
+type Definition struct {
+	// Name is the canonical entity (plural, kebab-case), e.g. "section-objects".
+	Name string `json:"name"`
+	// Aliases are the other spellings accepted in URLs, events and commands.
+	Aliases []string `json:"aliases,omitempty"`
+	// Roles may connect to the entity; empty allows every authenticated user.
+	Roles []string `json:"roles,omitempty"`
+	// ResourceKey is the metadata key of the resource id in detail messages.
+	ResourceKey string `json:"resourceKey,omitempty"`
+	// Topics are the Kafka topics carrying the entity events.
+	Topics   []string `json:"topics,omitempty"`
+	Commands Commands `json:"commands"`
+	// REST holds the snapshot endpoints per audience (see AudienceDefault).
+	REST map[string]RESTEndpoint `json:"rest,omitempty"`
+}
+
+// Commands are the entity specific websocket actions; "list"/"fetch_all" and
+// "detail"/"fetch_one" are always accepted too.
+type Commands struct {
+	List   []string `json:"list,omitempty"`
+	Detail []string `json:"detail,omitempty"`
+}
+
+// RESTEndpoint are the REST paths used to build snapshots. List may contain {section}, Detail
+// must contain {id}; SectionQuery sends the section as that query parameter and Filters maps
+// lower-cased client filters to REST query parameters (an empty value drops the filter).
+type RESTEndpoint struct {
+	List         string            `json:"list,omitempty"`
+	Detail       string            `json:"detail,omitempty"`
+	SectionQuery string            `json:"sectionQuery,omitempty"`
+	Filters      map[string]string `json:"filters,omitempty"`
+}
+
+// Registry indexes the definitions by name and alias.
+type Registry struct {
+	definitions []Definition
+	byName      map[string]int
+	aliases     map[string]string
+}
+
+// registryFile is the JSON shape of ENTITY_REGISTRY_FILE.
+type registryFile struct {
+	Entities []Definition `json:"entities"`
+}
+
+// New validates definitions and builds the registry.
+func New(definitions []Definition) (*Registry, error) {
+	registry := &Registry{
+		definitions: make([]Definition, 0, len(definitions)),
+		byName:      make(map[string]int, len(definitions)),
+		aliases:     make(map[string]string),
+	}
+	var errs []error
+	for _, definition := range definitions {
+		definition.Name = aliasKey(definition.Name)
+		if definition.Name == "" {
+			errs = append(errs, errors.New("entity without name"))
+			continue
+		}
+		if _, ok := registry.byName[definition.Name]; ok {
+			errs = append(errs, fmt.Errorf("entity %s: defined twice", definition.Name))
+			continue
+		}
+		for _, err := range validate(definition) {
+			errs = append(errs, fmt.Errorf("entity %s: %w", definition.Name, err))
+		}
+		for _, alias := range append([]string{definition.Name}, definition.Aliases...) {
+			key := aliasKey(alias)
+			if owner, ok := registry.aliases[key]; ok && owner != definition.Name {
+				errs = append(errs, fmt.Errorf("entity %s: alias %q already belongs to %s", definition.Name, alias, owner))
+				continue
+			}
+			registry.aliases[key] = definition.Name
+		}
+		registry.byName[definition.Name] = len(registry.definitions)
+		registry.definitions = append(registry.definitions, definition)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+func validate(definition Definition) []error {
+	var errs []error
+	for audience, endpoint := range definition.REST {
+		switch audience {
+		case AudienceDefault, AudienceAdmin, AudienceOwner, AudienceUser:
+		default:
+			errs = append(errs, fmt.Errorf("unknown REST audience %q", audience))
+			continue
+		}
+		if endpoint.List != "" && !strings.HasPrefix(endpoint.List, "/") {
+			errs = append(errs, fmt.Errorf("%s list path %q must start with /", audience, endpoint.List))
+		}
+		if strings.Contains(endpoint.List, ResourcePlaceholder) {
+			errs = append(errs, fmt.Errorf("%s list path %q only accepts %s", audience, endpoint.List, SectionPlaceholder))
+		}
+		if endpoint.Detail != "" && (!strings.HasPrefix(endpoint.Detail, "/") || !strings.Contains(endpoint.Detail, ResourcePlaceholder)) {
+			errs = append(errs, fmt.Errorf("%s detail path %q must start with / and contain %s", audience, endpoint.Detail, ResourcePlaceholder))
+		}
+	}
+	if len(definition.REST) > 0 {
+		if _, ok := definition.REST[AudienceDefault]; !ok {
+			errs = append(errs, fmt.Errorf("REST paths need a %q audience", AudienceDefault))
+		}
+	}
+	for _, command := range slices.Concat(definition.Commands.List, definition.Commands.Detail) {
+		if strings.TrimSpace(command) == "" {
+			errs = append(errs, errors.New("empty command name"))
+		}
+	}
+	return errs
+}
+
+// Load reads the registry from a JSON file.
+func Load(file string) (*Registry, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read entity registry: %w", err)
+	}
+	var parsed registryFile
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return nil, fmt.Errorf("parse entity registry: %w", err)
+	}
+	registry, err := New(parsed.Entities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entity registry: %w", err)
+	}
+	return registry, nil
+}
+
+// MarshalJSON renders the registry in the ENTITY_REGISTRY_FILE format.
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(registryFile{Entities: r.definitions})
+}
+
+// Normalize returns the canonical name of raw: known aliases resolve to their entity, "",
+// "-" and "default" to "", and unknown names to their lower-cased kebab-case form.
+func (r *Registry) Normalize(raw string) string {
+	key := aliasKey(raw)
+	switch key {
+	case "", "-", "default":
+		return ""
+	}
+	if name, ok := r.aliases[key]; ok {
+		return name
+	}
+	return key
+}
+
+// Lookup returns the definition of raw (any alias).
+func (r *Registry) Lookup(raw string) (Definition, bool) {
+	index, ok := r.byName[r.Normalize(raw)]
+	if !ok {
+		return Definition{}, false
+	}
+	return r.definitions[index], true
+}
+
+// Names returns the canonical entities in definition order.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.definitions))
+	for i, definition := range r.definitions {
+		names[i] = definition.Name
+	}
+	return names
+}
+
+// Definitions returns the definitions in definition order.
+func (r *Registry) Definitions() []Definition {
+	return slices.Clone(r.definitions)
+}
+
+// Topics returns the Kafka topics per entity, skipping entities without topics.
+func (r *Registry) Topics() map[string][]string {
+	topics := make(map[string][]string)
+	for _, definition := range r.definitions {
+		if len(definition.Topics) > 0 {
+			topics[definition.Name] = slices.Clone(definition.Topics)
+		}
+	}
+	return topics
+}
+
+// ResourceKey returns the metadata key of the resource id of raw; unknown entities use
+// "restaurantId".
+func (r *Registry) ResourceKey(raw string) string {
+	if definition, ok := r.Lookup(raw); ok && definition.ResourceKey != "" {
+		return definition.ResourceKey
+	}
+	return "restaurantId"
+}
+
+// Endpoint returns the REST paths of the entity for audience: the default paths overridden by
+// the non-empty fields of the audience paths.
+func (d Definition) Endpoint(audience string) (RESTEndpoint, bool) {
+	endpoint, ok := d.REST[AudienceDefault]
+	if !ok {
+		return RESTEndpoint{}, false
+	}
+	override, ok := d.REST[strings.ToLower(strings.TrimSpace(audience))]
+	if !ok || audience == AudienceDefault {
+		return endpoint, true
+	}
+	if override.List != "" {
+		endpoint.List = override.List
+	}
+	if override.Detail != "" {
+		endpoint.Detail = override.Detail
+	}
+	if override.SectionQuery != "" {
+		endpoint.SectionQuery = override.SectionQuery
+	}
+	if override.Filters != nil {
+		endpoint.Filters = override.Filters
+	}
+	return endpoint, true
+}
+
+// AllowsRole reports whether role may connect to the entity.
+func (d Definition) AllowsRole(role string) bool {
+	if len(d.Roles) == 0 {
+		return true
+	}
+	normalized := strings.TrimSpace(role)
+	return slices.ContainsFunc(d.Roles, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSpace(allowed), normalized)
+	})
+}
+
+func aliasKey(raw string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), "_", "-")
+}
@@ -0,0 +1,74 @@
+package entities
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_DefaultsMatchExampleAndResolveAliases(t *testing.T) {
+	example, err := Load(filepath.Join("..", "..", "..", "docs", "entities", "entities.example.json"))
+	if err != nil {
+		t.Fatalf("example file: %v", err)
+	}
+	if !reflect.DeepEqual(example.Definitions(), Default().Definitions()) {
+		t.Fatal("example file drifted from the defaults")
+	}
+
+	registry := Default()
+	cases := map[string]string{
+		"":                 "",
+		"default":          "",
+		" Restaurant ":     "restaurants",
+		"SECTION_OBJECT":   "section-objects",
+		"SectionObjects":   "section-objects",
+		"auth_users":       "users",
+		"owners":           "users",
+		"owner_upgrade":    "owner-upgrades",
+		"subscriptionplan": "subscription-plans",
+		"custom_entity":    "custom-entity",
+	}
+	for input, expected := range cases {
+		if got := registry.Normalize(input); got != expected {
+			t.Fatalf("Normalize(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+
+	users, ok := registry.Lookup("owner")
+	if !ok || users.AllowsRole("OWNER") || !users.AllowsRole("admin") {
+		t.Fatalf("owners must resolve to the admin-only users entity: %+v", users)
+	}
+	if got := registry.ResourceKey("auth-users"); got != "userId" {
+		t.Fatalf("unexpected users resource key %q", got)
+	}
+	if got := registry.ResourceKey("unknown"); got != "restaurantId" {
+		t.Fatalf("unexpected fallback resource key %q", got)
+	}
+	if got := registry.Topics()["users"]; !reflect.DeepEqual(got, []string{"mesa-ya.auth.events"}) {
+		t.Fatalf("unexpected users topics %v", got)
+	}
+
+	tables, _ := registry.Lookup("tables")
+	owner, _ := tables.Endpoint(AudienceOwner)
+	if owner.List != "/api/v1/tables/section/{section}" || owner.Detail != "/api/v1/tables/{id}" || owner.Filters["sectionid"] != "sectionId" {
+		t.Fatalf("owner paths must override the default ones field by field: %+v", owner)
+	}
+}
+
+func TestRegistry_RejectsInvalidDefinitions(t *testing.T) {
+	_, err := New([]Definition{
+		{Name: "tables", Aliases: []string{"table"}},
+		{Name: "seats", Aliases: []string{"table"}},
+		{Name: "menus", REST: map[string]RESTEndpoint{AudienceDefault: {List: "/api/v1/menus", Detail: "/api/v1/menus"}}},
+		{Name: "dishes", REST: map[string]RESTEndpoint{"guest": {List: "/api/v1/dishes"}}},
+	})
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`alias "table" already belongs to tables`, "must start with / and contain {id}", `unknown REST audience "guest"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q in %v", want, err)
+		}
+	}
+}
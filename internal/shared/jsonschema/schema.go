@@ -0,0 +1,316 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema models the subset of JSON Schema (draft 7 / AsyncAPI 2.x payloads) used by
+// the event contracts: type, required, properties, additionalProperties, items,
+// enum, const, pattern, length/numeric bounds, date-time format and local $ref.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 typeList           `json:"type,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *additional        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Const                any                `json:"const,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+
+	pattern *regexp.Regexp
+	root    *Schema
+}
+
+// ValidationError describes a single violation found while validating a document.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Parse decodes a JSON schema document and prepares it for validation.
+func Parse(raw []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	if err := schema.compile(&schema); err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+func (s *Schema) compile(root *Schema) error {
+	if s == nil {
+		return nil
+	}
+	s.root = root
+	if s.Pattern != "" {
+		compiled, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = compiled
+	}
+	children := make([]*Schema, 0, len(s.Properties)+len(s.Definitions)+len(s.Defs)+2)
+	for _, child := range s.Properties {
+		children = append(children, child)
+	}
+	for _, child := range s.Definitions {
+		children = append(children, child)
+	}
+	for _, child := range s.Defs {
+		children = append(children, child)
+	}
+	children = append(children, s.Items)
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.schema)
+	}
+	for _, child := range children {
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the decoded JSON value (as produced by encoding/json into any) and
+// returns every violation found. A nil slice means the document is valid.
+func (s *Schema) Validate(value any) []ValidationError {
+	var errs []ValidationError
+	s.validate("", value, &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, value any, errs *[]ValidationError) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		resolved := s.resolve(s.Ref)
+		if resolved == nil {
+			*errs = append(*errs, ValidationError{Path: path, Message: "unresolved $ref " + s.Ref})
+			return
+		}
+		resolved.validate(path, value, errs)
+		return
+	}
+
+	if len(s.Type) > 0 && !s.Type.matches(value) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", strings.Join(s.Type, "|"), typeOf(value))})
+		return
+	}
+	if s.Const != nil && !equal(s.Const, value) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("expected constant %v", s.Const)})
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, candidate := range s.Enum {
+			if equal(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v not in enum", value)})
+		}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		s.validateString(path, typed, errs)
+	case float64:
+		s.validateNumber(path, typed, errs)
+	case map[string]any:
+		s.validateObject(path, typed, errs)
+	case []any:
+		if s.Items != nil {
+			for index, item := range typed {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, index), item, errs)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateString(path, value string, errs *[]ValidationError) {
+	length := len([]rune(value))
+	if s.MinLength != nil && length < *s.MinLength {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("length %d below minimum %d", length, *s.MinLength)})
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("length %d above maximum %d", length, *s.MaxLength)})
+	}
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("does not match pattern %q", s.Pattern)})
+	}
+	if strings.EqualFold(s.Format, "date-time") {
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			*errs = append(*errs, ValidationError{Path: path, Message: "invalid date-time"})
+		}
+	}
+}
+
+func (s *Schema) validateNumber(path string, value float64, errs *[]ValidationError) {
+	if s.Minimum != nil && value < *s.Minimum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v below minimum %v", value, *s.Minimum)})
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v above maximum %v", value, *s.Maximum)})
+	}
+}
+
+func (s *Schema) validateObject(path string, value map[string]any, errs *[]ValidationError) {
+	for _, key := range s.Required {
+		if _, ok := value[key]; !ok {
+			*errs = append(*errs, ValidationError{Path: joinPath(path, key), Message: "required property missing"})
+		}
+	}
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if property, ok := s.Properties[key]; ok {
+			property.validate(joinPath(path, key), value[key], errs)
+			continue
+		}
+		if s.AdditionalProperties == nil {
+			continue
+		}
+		if !s.AdditionalProperties.allowed {
+			*errs = append(*errs, ValidationError{Path: joinPath(path, key), Message: "additional property not allowed"})
+			continue
+		}
+		s.AdditionalProperties.schema.validate(joinPath(path, key), value[key], errs)
+	}
+}
+
+func (s *Schema) resolve(ref string) *Schema {
+	root := s.root
+	if root == nil {
+		root = s
+	}
+	switch {
+	case ref == "#":
+		return root
+	case strings.HasPrefix(ref, "#/definitions/"):
+		return root.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+	case strings.HasPrefix(ref, "#/$defs/"):
+		return root.Defs[strings.TrimPrefix(ref, "#/$defs/")]
+	default:
+		return nil
+	}
+}
+
+// typeList accepts both `"type": "string"` and `"type": ["string", "null"]`.
+type typeList []string
+
+func (t *typeList) UnmarshalJSON(raw []byte) error {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		*t = typeList{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return fmt.Errorf("invalid type keyword: %w", err)
+	}
+	*t = many
+	return nil
+}
+
+func (t typeList) matches(value any) bool {
+	actual := typeOf(value)
+	for _, expected := range t {
+		if expected == actual {
+			return true
+		}
+		if expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+// additional accepts both boolean and schema forms of additionalProperties.
+type additional struct {
+	allowed bool
+	schema  *Schema
+}
+
+func (a *additional) UnmarshalJSON(raw []byte) error {
+	var flag bool
+	if err := json.Unmarshal(raw, &flag); err == nil {
+		a.allowed = flag
+		return nil
+	}
+	var schema Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return fmt.Errorf("invalid additionalProperties keyword: %w", err)
+	}
+	a.allowed = true
+	a.schema = &schema
+	return nil
+}
+
+func typeOf(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func equal(expected, actual any) bool {
+	left, err := json.Marshal(expected)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(actual)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
+
+func joinPath(base, key string) string {
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
@@ -0,0 +1,55 @@
+package jsonschema
+
+import "testing"
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := Parse([]byte(`{
+		"type": "object",
+		"required": ["event_type", "entity_id"],
+		"properties": {
+			"event_type": {"type": "string", "enum": ["created", "updated"]},
+			"entity_id": {"type": "string", "minLength": 1},
+			"timestamp": {"type": "string", "format": "date-time"},
+			"data": {"$ref": "#/definitions/data"},
+			"metadata": {"type": "object", "additionalProperties": {"type": "string"}}
+		},
+		"definitions": {
+			"data": {"type": "object", "properties": {"amount": {"type": "number", "minimum": 0}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		document map[string]any
+		errors   int
+	}{
+		{
+			name:     "valid",
+			document: map[string]any{"event_type": "created", "entity_id": "r-1", "timestamp": "2024-05-01T10:00:00Z", "data": map[string]any{"amount": 12.5}, "metadata": map[string]any{"userId": "u-1"}},
+		},
+		{
+			name:     "missing required",
+			document: map[string]any{"event_type": "created"},
+			errors:   1,
+		},
+		{
+			name:     "enum and ref violations",
+			document: map[string]any{"event_type": "archived", "entity_id": "r-1", "data": map[string]any{"amount": -1.0}},
+			errors:   2,
+		},
+		{
+			name:     "metadata values must be strings",
+			document: map[string]any{"event_type": "updated", "entity_id": "r-1", "metadata": map[string]any{"count": 2.0}, "timestamp": "yesterday"},
+			errors:   2,
+		},
+	}
+
+	for _, tc := range cases {
+		if got := schema.Validate(tc.document); len(got) != tc.errors {
+			t.Fatalf("%s: expected %d errors, got %v", tc.name, tc.errors, got)
+		}
+	}
+}
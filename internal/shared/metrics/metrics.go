@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value safe for concurrent use.
+type Counter struct {
+	value atomic.Int64
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+// Add increments the counter by delta.
+func (c *Counter) Add(delta int64) {
+	c.value.Add(delta)
+}
+
+// Value returns the current counter value.
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Registry groups named counters so infrastructure adapters can publish operational
+// numbers without depending on a specific metrics backend.
+type Registry struct {
+	mu       sync.RWMutex
+	counters map[string]*Counter
+}
+
+// NewRegistry builds an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]*Counter)}
+}
+
+// Default is the process-wide registry exposed through the /metrics endpoint.
+var Default = NewRegistry()
+
+// Counter returns the counter registered under the given name, creating it when needed.
+// Labels are appended to the name as `name{key=value,...}` sorted by key.
+func (r *Registry) Counter(name string, labels ...string) *Counter {
+	key := counterKey(name, labels)
+	r.mu.RLock()
+	counter, ok := r.counters[key]
+	r.mu.RUnlock()
+	if ok {
+		return counter
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if counter, ok = r.counters[key]; ok {
+		return counter
+	}
+	counter = &Counter{}
+	r.counters[key] = counter
+	return counter
+}
+
+// Snapshot returns the current value of every registered counter.
+func (r *Registry) Snapshot() map[string]int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := make(map[string]int64, len(r.counters))
+	for key, counter := range r.counters {
+		result[key] = counter.Value()
+	}
+	return result
+}
+
+func counterKey(name string, labels []string) string {
+	name = strings.TrimSpace(name)
+	if len(labels) < 2 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		key := strings.TrimSpace(labels[i])
+		if key == "" {
+			continue
+		}
+		pairs = append(pairs, key+"="+strings.TrimSpace(labels[i+1]))
+	}
+	if len(pairs) == 0 {
+		return name
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}